@@ -265,6 +265,7 @@ export namespace Config {
       leader: z.string().optional().default("ctrl+x").describe("Leader key for keybind combinations"),
       app_help: z.string().optional().default("<leader>h").describe("Show help dialog"),
       app_exit: z.string().optional().default("ctrl+c,<leader>q").describe("Exit the application"),
+      app_logs: z.string().optional().default("<leader>o").describe("Show TUI logs"),
       editor_open: z.string().optional().default("<leader>e").describe("Open external editor"),
       theme_list: z.string().optional().default("<leader>t").describe("List available themes"),
       project_init: z.string().optional().default("<leader>i").describe("Create/update AGENTS.md"),
@@ -278,6 +279,11 @@ export namespace Config {
       session_unshare: z.string().optional().default("none").describe("Unshare current session"),
       session_interrupt: z.string().optional().default("esc").describe("Interrupt current session"),
       session_compact: z.string().optional().default("<leader>c").describe("Compact the session"),
+      permission_list: z
+        .string()
+        .optional()
+        .default("<leader>p")
+        .describe("Review permission requests and policy history"),
       session_child_cycle: z.string().optional().default("ctrl+right").describe("Cycle to next child session"),
       session_child_cycle_reverse: z
         .string()
@@ -292,8 +298,11 @@ export namespace Config {
         .optional()
         .default("ctrl+alt+d")
         .describe("Scroll messages down by half page"),
+      messages_previous: z.string().optional().default("ctrl+up").describe("Focus the previous message block"),
+      messages_next: z.string().optional().default("ctrl+down").describe("Focus the next message block"),
       messages_first: z.string().optional().default("ctrl+g").describe("Navigate to first message"),
       messages_last: z.string().optional().default("ctrl+alt+g").describe("Navigate to last message"),
+      messages_search: z.string().optional().default("<leader>f").describe("Search the conversation"),
       messages_copy: z.string().optional().default("<leader>y").describe("Copy message"),
       messages_undo: z.string().optional().default("<leader>u").describe("Undo message"),
       messages_redo: z.string().optional().default("<leader>r").describe("Redo message"),
@@ -307,6 +316,9 @@ export namespace Config {
       input_paste: z.string().optional().default("ctrl+v").describe("Paste from clipboard"),
       input_submit: z.string().optional().default("enter").describe("Submit input"),
       input_newline: z.string().optional().default("shift+enter,ctrl+j").describe("Insert newline in input"),
+      input_undo: z.string().optional().default("alt+z").describe("Undo the last edit in the input"),
+      input_redo: z.string().optional().default("alt+y").describe("Redo the last undone edit in the input"),
+      input_history_search: z.string().optional().default("ctrl+r").describe("Search prompt history"),
       // Deprecated commands
       switch_mode: z.string().optional().default("none").describe("@deprecated use agent_cycle. Next mode"),
       switch_mode_reverse: z
@@ -324,8 +336,6 @@ export namespace Config {
       file_close: z.string().optional().default("none").describe("@deprecated Close file"),
       file_search: z.string().optional().default("none").describe("@deprecated Search file"),
       file_diff_toggle: z.string().optional().default("none").describe("@deprecated Split/unified diff"),
-      messages_previous: z.string().optional().default("none").describe("@deprecated Navigate to previous message"),
-      messages_next: z.string().optional().default("none").describe("@deprecated Navigate to next message"),
       messages_layout_toggle: z.string().optional().default("none").describe("@deprecated Toggle layout"),
       messages_revert: z.string().optional().default("none").describe("@deprecated use messages_undo. Revert message"),
     })
@@ -336,6 +346,7 @@ export namespace Config {
 
   export const TUI = z.object({
     scroll_speed: z.number().min(1).optional().default(2).describe("TUI scroll speed"),
+    vim: z.boolean().optional().describe("Enable Vim keybindings in the prompt editor"),
   })
 
   export const Layout = z.enum(["auto", "stretch"]).openapi({
@@ -2,20 +2,35 @@ import { Hono, type Context } from "hono"
 import { AsyncQueue } from "../util/queue"
 
 interface Request {
+  id: string
   path: string
   body: any
 }
 
+// The TUI answers every request with this envelope, correlated by id
+interface Response {
+  id: string
+  path: string
+  ok: boolean
+  data?: any
+  error?: string
+}
+
 const request = new AsyncQueue<Request>()
-const response = new AsyncQueue<any>()
+const pending = new Map<string, (response: Response) => void>()
 
 export async function callTui(ctx: Context) {
   const body = await ctx.req.json()
+  const id = crypto.randomUUID()
+  const result = new Promise<Response>((resolve) => pending.set(id, resolve))
   request.push({
+    id,
     path: ctx.req.path,
     body,
   })
-  return response.next()
+  const response = await result
+  if (!response.ok) throw new Error(response.error ?? `TUI request ${response.path} failed`)
+  return response.data ?? true
 }
 
 export const TuiRoute = new Hono()
@@ -24,7 +39,11 @@ export const TuiRoute = new Hono()
     return c.json(req)
   })
   .post("/response", async (c) => {
-    const body = await c.req.json()
-    response.push(body)
+    const body = (await c.req.json()) as Response
+    const resolve = pending.get(body?.id)
+    if (resolve) {
+      pending.delete(body.id)
+      resolve(body)
+    }
     return c.json(true)
   })
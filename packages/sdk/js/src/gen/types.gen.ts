@@ -510,6 +510,10 @@ export type Config = {
          * TUI scroll speed
          */
         scroll_speed: number;
+        /**
+         * Enable Vim keybindings in the prompt editor
+         */
+        vim?: boolean;
     };
     /**
      * Command configuration, see https://opencode.ai/docs/commands
@@ -26,7 +26,7 @@ func NewClient(serverURL string, opts ...apiPkg.ClientOption) (*Client, error) {
 	client.App = &AppService{client: client}
 	client.Session = &SessionService{
 		Client:      client,
-		Permissions: &SessionPermissionsService.Respond(client),
+		Permissions: NewSessionPermissionService(),
 	}
 	return client, nil
 }
@@ -100,7 +100,7 @@ type SessionPermissionsService struct {
 
 func (s *SessionPermissionsService) Respond(ctx context.Context, sessionID string, permissionID string, body SessionPermissionRespondParams) (any, error) {
 	var response apiPkg.PostSessionByIdPermissionsByPermissionIDReqResponse
-	switch *body.Response {
+	switch body.Response.Value {
 	case SessionPermissionRespondParamsResponseOnce:
 		response = apiPkg.PostSessionByIdPermissionsByPermissionIDReqResponseOnce
 	case SessionPermissionRespondParamsResponseAlways:
@@ -122,4 +122,3 @@ func (s *SessionPermissionsService) Respond(ctx context.Context, sessionID strin
 
 // AppLog response placeholder
 type AppLogResponse struct{}
-
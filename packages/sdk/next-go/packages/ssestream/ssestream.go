@@ -8,7 +8,9 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Decoder interface {
@@ -44,14 +46,22 @@ func RegisterDecoder(contentType string, decoder func(io.ReadCloser) Decoder) {
 type Event struct {
 	Type string
 	Data []byte
+	// ID is the last event ID seen on the stream, which persists across
+	// events until the server sends a new "id:" field.
+	ID string
+	// Retry is the reconnection time most recently requested by the server
+	// with a "retry:" field, or zero if none was sent.
+	Retry time.Duration
 }
 
 // A base implementation of a Decoder for text/event-stream.
 type eventStreamDecoder struct {
-	evt Event
-	rc  io.ReadCloser
-	scn *bufio.Scanner
-	err error
+	evt    Event
+	rc     io.ReadCloser
+	scn    *bufio.Scanner
+	err    error
+	lastID string
+	retry  time.Duration
 }
 
 func (s *eventStreamDecoder) Next() bool {
@@ -68,8 +78,10 @@ func (s *eventStreamDecoder) Next() bool {
 		// Dispatch event on an empty line
 		if len(txt) == 0 {
 			s.evt = Event{
-				Type: event,
-				Data: data.Bytes(),
+				Type:  event,
+				Data:  data.Bytes(),
+				ID:    s.lastID,
+				Retry: s.retry,
 			}
 			return true
 		}
@@ -88,6 +100,15 @@ func (s *eventStreamDecoder) Next() bool {
 			continue
 		case "event":
 			event = string(value)
+		case "id":
+			// Per the spec, ids containing NULL are ignored.
+			if !bytes.ContainsRune(value, 0) {
+				s.lastID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(string(value)); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
 		case "data":
 			_, s.err = data.Write(value)
 			if s.err != nil {
@@ -123,6 +144,8 @@ type Stream[T any] struct {
 	decoder Decoder
 	cur     T
 	err     error
+	lastID  string
+	retry   time.Duration
 }
 
 func NewStream[T any](decoder Decoder, err error) *Stream[T] {
@@ -149,8 +172,11 @@ func (s *Stream[T]) Next() bool {
 	}
 
 	for s.decoder.Next() {
+		evt := s.decoder.Event()
+		s.lastID = evt.ID
+		s.retry = evt.Retry
 		var nxt T
-		s.err = json.Unmarshal(s.decoder.Event().Data, &nxt)
+		s.err = json.Unmarshal(evt.Data, &nxt)
 		if s.err != nil {
 			return false
 		}
@@ -172,6 +198,17 @@ func (s *Stream[T]) Err() error {
 	return s.err
 }
 
+// LastEventID returns the id of the most recent event, suitable for the
+// Last-Event-ID header when reconnecting.
+func (s *Stream[T]) LastEventID() string {
+	return s.lastID
+}
+
+// Retry returns the reconnection time requested by the server, or zero.
+func (s *Stream[T]) Retry() time.Duration {
+	return s.retry
+}
+
 func (s *Stream[T]) Close() error {
 	if s.decoder == nil {
 		// already closed
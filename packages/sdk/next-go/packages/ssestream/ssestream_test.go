@@ -0,0 +1,39 @@
+package ssestream
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecoderTracksIDAndRetry(t *testing.T) {
+	body := "retry: 1500\nid: 1\ndata: {\"n\":1}\n\n" +
+		"data: {\"n\":2}\n\n" +
+		"id: 3\ndata: {\"n\":3}\n\n"
+	res := &http.Response{
+		Header: http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:   io.NopCloser(strings.NewReader(body)),
+	}
+	stream := NewStream[struct{ N int }](NewDecoder(res), nil)
+
+	want := []string{"1", "1", "3"}
+	for i, id := range want {
+		if !stream.Next() {
+			t.Fatalf("event %d: unexpected end of stream: %v", i, stream.Err())
+		}
+		if stream.Current().N != i+1 {
+			t.Errorf("event %d: got data %d", i, stream.Current().N)
+		}
+		if stream.LastEventID() != id {
+			t.Errorf("event %d: got id %q, want %q", i, stream.LastEventID(), id)
+		}
+		if stream.Retry() != 1500*time.Millisecond {
+			t.Errorf("event %d: got retry %s", i, stream.Retry())
+		}
+	}
+	if stream.Next() {
+		t.Fatal("expected end of stream")
+	}
+}
@@ -8,8 +8,6 @@ import (
 	"net/url"
 	"reflect"
 
-
-	"github.com/sst/opencode-api-go/api"
 	"github.com/sst/opencode-api-go/internal/apijson"
 	"github.com/sst/opencode-api-go/internal/apiquery"
 	"github.com/sst/opencode-api-go/internal/param"
@@ -26,7 +24,7 @@ import (
 // automatically. You should not instantiate this service directly, and instead use
 // the [NewSessionService] method instead.
 type SessionService struct {
-	Client			*Client
+	Client      *Client
 	Options     []option.RequestOption
 	Permissions *SessionPermissionService
 }
@@ -35,9 +33,9 @@ type SessionService struct {
 // request. These options are applied after the parent client's options (if there
 // is one), and before any request-specific options.
 func NewSessionService(c *Client, opts ...option.RequestOption) *SessionService {
-	return &SessionService {
-		Client: c,
-		Options: opts,
+	return &SessionService{
+		Client:      c,
+		Options:     opts,
 		Permissions: NewSessionPermissionService(opts...),
 	}
 }
@@ -118,70 +116,9 @@ func (r *SessionService) Command(ctx context.Context, id string, params SessionC
 	return
 }
 
-func (s *SessionService) convertToApiParams(id string, params SessionGetParams) api.SessionGetParams {
-	directory := ""
-	if params.Directory.IsSet(){
-		directory = params.Directory.Value()
-	}
-	
-	return api.SessionGetParams{
-		ID:        id,
-		Directory: api.OptString{Set: true, Value: directory},
-	}
-}
-
+// Get fetches a session through the generated client.
 func (s *SessionService) Get(ctx context.Context, id string, params SessionGetParams) (Session, error) {
-	if id == "" {
-		return Session{}, fmt.Errorf("missing required id parameter")
-	}
-
-	apiParams := s.convertToApiParams(id, params)
-
-	apiSession, err := s.Client.apiClient.SessionGet(ctx, apiParams)
-	if err != nil {
-		return Session{}, fmt.Errorf("failed to fetch session from API: %w", err)
-	}
-
-	// Start mapping to custom Session
-	customSession := Session{
-		ID:        apiSession.ID,
-		Title:     apiSession.Title,
-		Directory: params.Directory.Or(""),
-		ProjectID: "fallback-project-id", // Placeholder - should come from apiSession or context
-		Time: SessionTime{
-			Created: apiSession.Time.Created,
-			Updated: apiSession.Time.Updated,
-		},
-		Version: apiSession.Version,
-		ParentID: func() string {
-			if apiSession.ParentID.Set {
-				return apiSession.ParentID.Value
-			}
-			return ""
-		}(),
-		Revert: SessionRevert{
-			MessageID: apiSession.Revert.MessageID,
-			Diff:      apiSession.Revert.Diff,
-			PartID:    apiSession.Revert.PartID,
-			Snapshot:  apiSession.Revert.Snapshot,
-		},
-		Share: SessionShare{
-			URL: func() string {
-				if apiSession.Share.Set {
-					return apiSession.Share.Value.URL
-				}
-				return ""
-			}(),
-		},
-		JSON: sessionJSON{},
-	}
-
-	// Validate required fields post-mapping
-	if customSession.Directory == "" || customSession.ProjectID == "" || customSession.Version == "" {
-		return Session{}, fmt.Errorf("missing required fields in session data")
-	}
-
-	return customSession, nil
+	return NewGeneratedSessionAdapter(s.Client).Get(ctx, id, params)
 }
 
 // Analyze the app and create an AGENTS.md file
@@ -2,8 +2,13 @@ package opencode
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 
+	"github.com/sst/opencode-api-go/api"
+	"github.com/sst/opencode-api-go/internal/param"
 	"github.com/sst/opencode-api-go/internal/requestconfig"
 )
 
@@ -14,109 +19,359 @@ type SessionServiceInterface interface {
 	Update(ctx context.Context, id string, params SessionUpdateParams) (*Session, error)
 	List(ctx context.Context, query SessionListParams) (*[]Session, error)
 	Delete(ctx context.Context, id string, body SessionDeleteParams) (*bool, error)
+	Abort(ctx context.Context, id string, body SessionAbortParams) (*bool, error)
+	Children(ctx context.Context, id string, query SessionChildrenParams) (*[]Session, error)
+	Command(ctx context.Context, id string, params SessionCommandParams) (*SessionCommandResponse, error)
+	Init(ctx context.Context, id string, params SessionInitParams) (*bool, error)
+	Message(ctx context.Context, id string, messageID string, query SessionMessageParams) (*SessionMessageResponse, error)
+	Messages(ctx context.Context, id string, query SessionMessagesParams) (*[]SessionMessagesResponse, error)
+	Prompt(ctx context.Context, id string, params SessionPromptParams) (*SessionPromptResponse, error)
+	Revert(ctx context.Context, id string, params SessionRevertParams) (*Session, error)
+	Unrevert(ctx context.Context, id string, body SessionUnrevertParams) (*Session, error)
+	Share(ctx context.Context, id string, body SessionShareParams) (*Session, error)
+	Unshare(ctx context.Context, id string, body SessionUnshareParams) (*Session, error)
+	Shell(ctx context.Context, id string, params SessionShellParams) (*AssistantMessage, error)
+	Summarize(ctx context.Context, id string, params SessionSummarizeParams) (*bool, error)
 }
 
+var errMissingID = errors.New("missing required id parameter")
+
 // GeneratedSessionAdapter implements SessionServiceInterface using the generated API client
 // This adapter handles parameter conversion and response mapping between
 // the generated API types and custom types
 type GeneratedSessionAdapter struct {
-	client *Client
+	client api.Invoker
 }
 
 func NewGeneratedSessionAdapter(client *Client) *GeneratedSessionAdapter {
-	return &GeneratedSessionAdapter{client: client}
+	return &GeneratedSessionAdapter{client: client.apiClient}
 }
 
-func (a *GeneratedSessionAdapter) Get(ctx context.Context, id string, params SessionGetParams) (Session, error) {
-	if id == "" {
-		return Session{}, fmt.Errorf("missing required id parameter")
+// optString converts an optional hand-written field to the generated form.
+func optString(field param.Field[string]) api.OptString {
+	if !field.Present || field.Null {
+		return api.OptString{}
 	}
+	return api.NewOptString(field.Value)
+}
 
-	// Convert custom params to API params
-	directory := ""
-	if params.Directory.Present {
-		directory = params.Directory.Value
+func stringOr(opt api.OptString, fallback string) string {
+	if opt.Set {
+		return opt.Value
 	}
+	return fallback
+}
 
-	// Call generated API (placeholder)
-	// apiSession, err := a.client.apiClient.SessionGet(ctx, api.SessionGetParams{ID: id, Directory: directory})
-	// if err != nil {
-	//     return Session{}, fmt.Errorf("failed to fetch session from API: %w", err)
-	// }
-
-	// For demonstration, return a placeholder session
-	// In real implementation, this would map from api.Session to Session
-	return Session{
-		ID:        id,
-		Directory: directory,
-		ProjectID: "project-123",
-		Version:   "1.0",
+// mapSession converts a generated session to the hand-written type.
+func mapSession(s *api.Session) Session {
+	session := Session{
+		ID:        s.ID,
+		Directory: s.Directory,
+		ProjectID: s.ProjectID,
+		Title:     s.Title,
+		Version:   s.Version,
+		ParentID:  stringOr(s.ParentID, ""),
 		Time: SessionTime{
-			Created: 1234567890,
-			Updated: 1234567890,
+			Created: s.Time.Created,
+			Updated: s.Time.Updated,
 		},
-	}, nil
+	}
+	if s.Share.Set {
+		session.Share = SessionShare{URL: s.Share.Value.URL}
+	}
+	if s.Revert.Set {
+		session.Revert = SessionRevert{
+			MessageID: s.Revert.Value.MessageID,
+			PartID:    stringOr(s.Revert.Value.PartID, ""),
+			Snapshot:  stringOr(s.Revert.Value.Snapshot, ""),
+			Diff:      stringOr(s.Revert.Value.Diff, ""),
+		}
+	}
+	return session
 }
 
-// Extend the pattern to other methods
-func (a *GeneratedSessionAdapter) New(ctx context.Context, params SessionNewParams) (*Session, error) {
-	// Convert params and call generated API
-	// apiParams := a.convertNewParams(params)
-	// apiSession, err := a.client.apiClient.SessionNew(ctx, apiParams)
-	// return a.mapToCustomSession(apiSession), err
+func mapSessions(items []api.Session) *[]Session {
+	sessions := make([]Session, 0, len(items))
+	for i := range items {
+		sessions = append(sessions, mapSession(&items[i]))
+	}
+	return &sessions
+}
 
-	// Placeholder implementation
-	return &Session{
-		ID:        "new-session-id",
-		Directory: "/tmp",
-		ProjectID: "project-123",
-		Version:   "1.0",
-	}, nil
+// convertJSON maps between generated and hand-written types that share a wire
+// format. Messages and parts are unions on both sides, so going through their
+// JSON keeps every variant and field without a mapping per variant.
+func convertJSON(src json.Marshaler, dst json.Unmarshaler) error {
+	data, err := src.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return dst.UnmarshalJSON(data)
 }
 
-func (a *GeneratedSessionAdapter) Update(ctx context.Context, id string, params SessionUpdateParams) (*Session, error) {
+func (a *GeneratedSessionAdapter) Get(ctx context.Context, id string, params SessionGetParams) (Session, error) {
 	if id == "" {
-		return nil, fmt.Errorf("missing required id parameter")
+		return Session{}, errMissingID
 	}
+	res, err := a.client.SessionGet(ctx, api.SessionGetParams{ID: id, Directory: optString(params.Directory)})
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to fetch session from API: %w", err)
+	}
+	return mapSession(res), nil
+}
 
-	// Convert and call generated API
-	// apiParams := a.convertUpdateParams(id, params)
-	// apiSession, err := a.client.apiClient.SessionUpdate(ctx, apiParams)
-	// return a.mapToCustomSession(apiSession), err
+func (a *GeneratedSessionAdapter) New(ctx context.Context, params SessionNewParams) (*Session, error) {
+	req := api.SessionCreateReq{
+		ParentID: optString(params.ParentID),
+		Title:    optString(params.Title),
+	}
+	res, err := a.client.SessionCreate(ctx, api.NewOptSessionCreateReq(req), api.SessionCreateParams{Directory: optString(params.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	switch res := res.(type) {
+	case *api.Session:
+		session := mapSession(res)
+		return &session, nil
+	case *api.Error:
+		data, _ := json.Marshal(res.Data)
+		return nil, fmt.Errorf("failed to create session: %s", data)
+	}
+	return nil, fmt.Errorf("unexpected response type %T", res)
+}
 
-	return &Session{
-		ID:        id,
-		Directory: "/updated/path",
-		ProjectID: "project-123",
-		Version:   "1.1",
-	}, nil
+func (a *GeneratedSessionAdapter) Update(ctx context.Context, id string, params SessionUpdateParams) (*Session, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	req := api.SessionUpdateReq{Title: optString(params.Title)}
+	res, err := a.client.SessionUpdate(ctx, api.NewOptSessionUpdateReq(req), api.SessionUpdateParams{ID: id, Directory: optString(params.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	session := mapSession(res)
+	return &session, nil
 }
 
 func (a *GeneratedSessionAdapter) List(ctx context.Context, query SessionListParams) (*[]Session, error) {
-	// Convert query params and call generated API
-	// apiParams := a.convertListParams(query)
-	// apiSessions, err := a.client.apiClient.SessionList(ctx, apiParams)
-	// return a.mapToCustomSessions(apiSessions), err
-
-	sessions := []Session{
-		{ID: "session-1", Directory: "/path1", ProjectID: "project-123"},
-		{ID: "session-2", Directory: "/path2", ProjectID: "project-456"},
+	res, err := a.client.SessionList(ctx, api.SessionListParams{Directory: optString(query.Directory)})
+	if err != nil {
+		return nil, err
 	}
-	return &sessions, nil
+	return mapSessions(res), nil
 }
 
 func (a *GeneratedSessionAdapter) Delete(ctx context.Context, id string, body SessionDeleteParams) (*bool, error) {
 	if id == "" {
-		return nil, fmt.Errorf("missing required id parameter")
+		return nil, errMissingID
+	}
+	res, err := a.client.SessionDelete(ctx, api.SessionDeleteParams{ID: id, Directory: optString(body.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (a *GeneratedSessionAdapter) Abort(ctx context.Context, id string, body SessionAbortParams) (*bool, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	res, err := a.client.SessionAbort(ctx, api.SessionAbortParams{ID: id, Directory: optString(body.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (a *GeneratedSessionAdapter) Children(ctx context.Context, id string, query SessionChildrenParams) (*[]Session, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	res, err := a.client.SessionChildren(ctx, api.SessionChildrenParams{ID: id, Directory: optString(query.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	return mapSessions(res), nil
+}
+
+func (a *GeneratedSessionAdapter) Command(ctx context.Context, id string, params SessionCommandParams) (*SessionCommandResponse, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	req := api.SessionCommandReq{
+		Command:   params.Command.Value,
+		Arguments: params.Arguments.Value,
+		Agent:     optString(params.Agent),
+		MessageID: optString(params.MessageID),
+		Model:     optString(params.Model),
+	}
+	res, err := a.client.SessionCommand(ctx, api.NewOptSessionCommandReq(req), api.SessionCommandParams{ID: id, Directory: optString(params.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	var out SessionCommandResponse
+	if err := convertJSON(res, &out); err != nil {
+		return nil, fmt.Errorf("failed to map command response: %w", err)
+	}
+	return &out, nil
+}
+
+func (a *GeneratedSessionAdapter) Init(ctx context.Context, id string, params SessionInitParams) (*bool, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	req := api.SessionInitReq{
+		MessageID:  params.MessageID.Value,
+		ProviderID: params.ProviderID.Value,
+		ModelID:    params.ModelID.Value,
+	}
+	res, err := a.client.SessionInit(ctx, api.NewOptSessionInitReq(req), api.SessionInitParams{ID: id, Directory: optString(params.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (a *GeneratedSessionAdapter) Message(ctx context.Context, id string, messageID string, query SessionMessageParams) (*SessionMessageResponse, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	if messageID == "" {
+		return nil, errors.New("missing required messageID parameter")
+	}
+	res, err := a.client.SessionMessage(ctx, api.SessionMessageParams{ID: id, MessageID: messageID, Directory: optString(query.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	var out SessionMessageResponse
+	if err := convertJSON(res, &out); err != nil {
+		return nil, fmt.Errorf("failed to map message: %w", err)
+	}
+	return &out, nil
+}
+
+func (a *GeneratedSessionAdapter) Messages(ctx context.Context, id string, query SessionMessagesParams) (*[]SessionMessagesResponse, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	res, err := a.client.SessionMessages(ctx, api.SessionMessagesParams{ID: id, Directory: optString(query.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]SessionMessagesResponse, len(res))
+	for i := range res {
+		if err := convertJSON(&res[i], &messages[i]); err != nil {
+			return nil, fmt.Errorf("failed to map message %d: %w", i, err)
+		}
+	}
+	return &messages, nil
+}
+
+func (a *GeneratedSessionAdapter) Prompt(ctx context.Context, id string, params SessionPromptParams) (*SessionPromptResponse, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	// parts are a union of text, file and agent inputs; the body already
+	// marshals to the wire format the generated request decodes
+	var req api.SessionPromptReq
+	if err := convertJSON(params, &req); err != nil {
+		return nil, fmt.Errorf("failed to map prompt: %w", err)
+	}
+	res, err := a.client.SessionPrompt(ctx, api.NewOptSessionPromptReq(req), api.SessionPromptParams{ID: id, Directory: optString(params.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	var out SessionPromptResponse
+	if err := convertJSON(res, &out); err != nil {
+		return nil, fmt.Errorf("failed to map prompt response: %w", err)
+	}
+	return &out, nil
+}
+
+func (a *GeneratedSessionAdapter) Revert(ctx context.Context, id string, params SessionRevertParams) (*Session, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	req := api.SessionRevertReq{
+		MessageID: params.MessageID.Value,
+		PartID:    optString(params.PartID),
+	}
+	res, err := a.client.SessionRevert(ctx, api.NewOptSessionRevertReq(req), api.SessionRevertParams{ID: id, Directory: optString(params.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	session := mapSession(res)
+	return &session, nil
+}
+
+func (a *GeneratedSessionAdapter) Unrevert(ctx context.Context, id string, body SessionUnrevertParams) (*Session, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	res, err := a.client.SessionUnrevert(ctx, api.SessionUnrevertParams{ID: id, Directory: optString(body.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	session := mapSession(res)
+	return &session, nil
+}
+
+func (a *GeneratedSessionAdapter) Share(ctx context.Context, id string, body SessionShareParams) (*Session, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	res, err := a.client.SessionShare(ctx, api.SessionShareParams{ID: id, Directory: optString(body.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	session := mapSession(res)
+	return &session, nil
+}
+
+func (a *GeneratedSessionAdapter) Unshare(ctx context.Context, id string, body SessionUnshareParams) (*Session, error) {
+	if id == "" {
+		return nil, errMissingID
 	}
+	res, err := a.client.SessionUnshare(ctx, api.SessionUnshareParams{ID: id, Directory: optString(body.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	session := mapSession(res)
+	return &session, nil
+}
 
-	// Call generated API
-	// apiParams := a.convertDeleteParams(id, body)
-	// result, err := a.client.apiClient.SessionDelete(ctx, apiParams)
-	// return &result, err
+func (a *GeneratedSessionAdapter) Shell(ctx context.Context, id string, params SessionShellParams) (*AssistantMessage, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	req := api.SessionShellReq{
+		Agent:   params.Agent.Value,
+		Command: params.Command.Value,
+	}
+	res, err := a.client.SessionShell(ctx, api.NewOptSessionShellReq(req), api.SessionShellParams{ID: id, Directory: optString(params.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	var out AssistantMessage
+	if err := convertJSON(res, &out); err != nil {
+		return nil, fmt.Errorf("failed to map shell response: %w", err)
+	}
+	return &out, nil
+}
 
-	result := true
-	return &result, nil
+func (a *GeneratedSessionAdapter) Summarize(ctx context.Context, id string, params SessionSummarizeParams) (*bool, error) {
+	if id == "" {
+		return nil, errMissingID
+	}
+	req := api.SessionSummarizeReq{
+		ProviderID: params.ProviderID.Value,
+		ModelID:    params.ModelID.Value,
+	}
+	res, err := a.client.SessionSummarize(ctx, api.NewOptSessionSummarizeReq(req), api.SessionSummarizeParams{ID: id, Directory: optString(params.Directory)})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
 }
 
 // CustomSessionAdapter implements SessionServiceInterface using custom request handling
@@ -132,33 +387,124 @@ func NewCustomSessionAdapter(client *Client) *CustomSessionAdapter {
 func (a *CustomSessionAdapter) Get(ctx context.Context, id string, params SessionGetParams) (Session, error) {
 	var res Session
 	path := fmt.Sprintf("session/%s", id)
-	err := requestconfig.ExecuteNewRequest(ctx, "GET", path, params, &res)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodGet, path, params, &res)
 	return res, err
 }
 
 func (a *CustomSessionAdapter) New(ctx context.Context, params SessionNewParams) (*Session, error) {
 	var res Session
-	err := requestconfig.ExecuteNewRequest(ctx, "POST", "session", params, &res)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, "session", params, &res)
 	return &res, err
 }
 
 func (a *CustomSessionAdapter) Update(ctx context.Context, id string, params SessionUpdateParams) (*Session, error) {
 	var res Session
 	path := fmt.Sprintf("session/%s", id)
-	err := requestconfig.ExecuteNewRequest(ctx, "PATCH", path, params, &res)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPatch, path, params, &res)
 	return &res, err
 }
 
 func (a *CustomSessionAdapter) List(ctx context.Context, query SessionListParams) (*[]Session, error) {
 	var res []Session
-	err := requestconfig.ExecuteNewRequest(ctx, "GET", "session", query, &res)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodGet, "session", query, &res)
 	return &res, err
 }
 
 func (a *CustomSessionAdapter) Delete(ctx context.Context, id string, body SessionDeleteParams) (*bool, error) {
 	var res bool
 	path := fmt.Sprintf("session/%s", id)
-	err := requestconfig.ExecuteNewRequest(ctx, "DELETE", path, body, &res)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodDelete, path, body, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Abort(ctx context.Context, id string, body SessionAbortParams) (*bool, error) {
+	var res bool
+	path := fmt.Sprintf("session/%s/abort", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, body, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Children(ctx context.Context, id string, query SessionChildrenParams) (*[]Session, error) {
+	var res []Session
+	path := fmt.Sprintf("session/%s/children", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodGet, path, query, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Command(ctx context.Context, id string, params SessionCommandParams) (*SessionCommandResponse, error) {
+	var res SessionCommandResponse
+	path := fmt.Sprintf("session/%s/command", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Init(ctx context.Context, id string, params SessionInitParams) (*bool, error) {
+	var res bool
+	path := fmt.Sprintf("session/%s/init", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Message(ctx context.Context, id string, messageID string, query SessionMessageParams) (*SessionMessageResponse, error) {
+	var res SessionMessageResponse
+	path := fmt.Sprintf("session/%s/message/%s", id, messageID)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodGet, path, query, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Messages(ctx context.Context, id string, query SessionMessagesParams) (*[]SessionMessagesResponse, error) {
+	var res []SessionMessagesResponse
+	path := fmt.Sprintf("session/%s/message", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodGet, path, query, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Prompt(ctx context.Context, id string, params SessionPromptParams) (*SessionPromptResponse, error) {
+	var res SessionPromptResponse
+	path := fmt.Sprintf("session/%s/message", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Revert(ctx context.Context, id string, params SessionRevertParams) (*Session, error) {
+	var res Session
+	path := fmt.Sprintf("session/%s/revert", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Unrevert(ctx context.Context, id string, body SessionUnrevertParams) (*Session, error) {
+	var res Session
+	path := fmt.Sprintf("session/%s/unrevert", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, body, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Share(ctx context.Context, id string, body SessionShareParams) (*Session, error) {
+	var res Session
+	path := fmt.Sprintf("session/%s/share", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, body, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Unshare(ctx context.Context, id string, body SessionUnshareParams) (*Session, error) {
+	var res Session
+	path := fmt.Sprintf("session/%s/share", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodDelete, path, body, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Shell(ctx context.Context, id string, params SessionShellParams) (*AssistantMessage, error) {
+	var res AssistantMessage
+	path := fmt.Sprintf("session/%s/shell", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res)
+	return &res, err
+}
+
+func (a *CustomSessionAdapter) Summarize(ctx context.Context, id string, params SessionSummarizeParams) (*bool, error) {
+	var res bool
+	path := fmt.Sprintf("session/%s/summarize", id)
+	err := requestconfig.ExecuteNewRequest(ctx, http.MethodPost, path, params, &res)
 	return &res, err
 }
 
@@ -176,46 +522,139 @@ func NewUnifiedSessionAdapter(client *Client) *UnifiedSessionAdapter {
 	}
 }
 
-func (a *UnifiedSessionAdapter) Get(ctx context.Context, id string, params SessionGetParams) (Session, error) {
-	// Try generated API first for better performance/features
-	session, err := a.generated.Get(ctx, id, params)
+// fallback runs the generated call and retries with the custom one on error.
+func fallback[T any](generated, custom func() (T, error)) (T, error) {
+	res, err := generated()
 	if err != nil {
-		// Fallback to custom implementation
-		return a.custom.Get(ctx, id, params)
+		return custom()
 	}
-	return session, nil
+	return res, nil
+}
+
+func (a *UnifiedSessionAdapter) Get(ctx context.Context, id string, params SessionGetParams) (Session, error) {
+	return fallback(
+		func() (Session, error) { return a.generated.Get(ctx, id, params) },
+		func() (Session, error) { return a.custom.Get(ctx, id, params) },
+	)
 }
 
 func (a *UnifiedSessionAdapter) New(ctx context.Context, params SessionNewParams) (*Session, error) {
-	session, err := a.generated.New(ctx, params)
-	if err != nil {
-		return a.custom.New(ctx, params)
-	}
-	return session, nil
+	return fallback(
+		func() (*Session, error) { return a.generated.New(ctx, params) },
+		func() (*Session, error) { return a.custom.New(ctx, params) },
+	)
 }
 
 func (a *UnifiedSessionAdapter) Update(ctx context.Context, id string, params SessionUpdateParams) (*Session, error) {
-	session, err := a.generated.Update(ctx, id, params)
-	if err != nil {
-		return a.custom.Update(ctx, id, params)
-	}
-	return session, nil
+	return fallback(
+		func() (*Session, error) { return a.generated.Update(ctx, id, params) },
+		func() (*Session, error) { return a.custom.Update(ctx, id, params) },
+	)
 }
 
 func (a *UnifiedSessionAdapter) List(ctx context.Context, query SessionListParams) (*[]Session, error) {
-	sessions, err := a.generated.List(ctx, query)
-	if err != nil {
-		return a.custom.List(ctx, query)
-	}
-	return sessions, nil
+	return fallback(
+		func() (*[]Session, error) { return a.generated.List(ctx, query) },
+		func() (*[]Session, error) { return a.custom.List(ctx, query) },
+	)
 }
 
 func (a *UnifiedSessionAdapter) Delete(ctx context.Context, id string, body SessionDeleteParams) (*bool, error) {
-	result, err := a.generated.Delete(ctx, id, body)
-	if err != nil {
-		return a.custom.Delete(ctx, id, body)
-	}
-	return result, nil
+	return fallback(
+		func() (*bool, error) { return a.generated.Delete(ctx, id, body) },
+		func() (*bool, error) { return a.custom.Delete(ctx, id, body) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Abort(ctx context.Context, id string, body SessionAbortParams) (*bool, error) {
+	return fallback(
+		func() (*bool, error) { return a.generated.Abort(ctx, id, body) },
+		func() (*bool, error) { return a.custom.Abort(ctx, id, body) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Children(ctx context.Context, id string, query SessionChildrenParams) (*[]Session, error) {
+	return fallback(
+		func() (*[]Session, error) { return a.generated.Children(ctx, id, query) },
+		func() (*[]Session, error) { return a.custom.Children(ctx, id, query) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Command(ctx context.Context, id string, params SessionCommandParams) (*SessionCommandResponse, error) {
+	return fallback(
+		func() (*SessionCommandResponse, error) { return a.generated.Command(ctx, id, params) },
+		func() (*SessionCommandResponse, error) { return a.custom.Command(ctx, id, params) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Init(ctx context.Context, id string, params SessionInitParams) (*bool, error) {
+	return fallback(
+		func() (*bool, error) { return a.generated.Init(ctx, id, params) },
+		func() (*bool, error) { return a.custom.Init(ctx, id, params) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Message(ctx context.Context, id string, messageID string, query SessionMessageParams) (*SessionMessageResponse, error) {
+	return fallback(
+		func() (*SessionMessageResponse, error) { return a.generated.Message(ctx, id, messageID, query) },
+		func() (*SessionMessageResponse, error) { return a.custom.Message(ctx, id, messageID, query) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Messages(ctx context.Context, id string, query SessionMessagesParams) (*[]SessionMessagesResponse, error) {
+	return fallback(
+		func() (*[]SessionMessagesResponse, error) { return a.generated.Messages(ctx, id, query) },
+		func() (*[]SessionMessagesResponse, error) { return a.custom.Messages(ctx, id, query) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Prompt(ctx context.Context, id string, params SessionPromptParams) (*SessionPromptResponse, error) {
+	return fallback(
+		func() (*SessionPromptResponse, error) { return a.generated.Prompt(ctx, id, params) },
+		func() (*SessionPromptResponse, error) { return a.custom.Prompt(ctx, id, params) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Revert(ctx context.Context, id string, params SessionRevertParams) (*Session, error) {
+	return fallback(
+		func() (*Session, error) { return a.generated.Revert(ctx, id, params) },
+		func() (*Session, error) { return a.custom.Revert(ctx, id, params) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Unrevert(ctx context.Context, id string, body SessionUnrevertParams) (*Session, error) {
+	return fallback(
+		func() (*Session, error) { return a.generated.Unrevert(ctx, id, body) },
+		func() (*Session, error) { return a.custom.Unrevert(ctx, id, body) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Share(ctx context.Context, id string, body SessionShareParams) (*Session, error) {
+	return fallback(
+		func() (*Session, error) { return a.generated.Share(ctx, id, body) },
+		func() (*Session, error) { return a.custom.Share(ctx, id, body) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Unshare(ctx context.Context, id string, body SessionUnshareParams) (*Session, error) {
+	return fallback(
+		func() (*Session, error) { return a.generated.Unshare(ctx, id, body) },
+		func() (*Session, error) { return a.custom.Unshare(ctx, id, body) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Shell(ctx context.Context, id string, params SessionShellParams) (*AssistantMessage, error) {
+	return fallback(
+		func() (*AssistantMessage, error) { return a.generated.Shell(ctx, id, params) },
+		func() (*AssistantMessage, error) { return a.custom.Shell(ctx, id, params) },
+	)
+}
+
+func (a *UnifiedSessionAdapter) Summarize(ctx context.Context, id string, params SessionSummarizeParams) (*bool, error) {
+	return fallback(
+		func() (*bool, error) { return a.generated.Summarize(ctx, id, params) },
+		func() (*bool, error) { return a.custom.Summarize(ctx, id, params) },
+	)
 }
 
 // SessionServiceAdapterFactory creates the appropriate adapter based on configuration
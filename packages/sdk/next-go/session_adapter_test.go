@@ -0,0 +1,288 @@
+package opencode
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sst/opencode-api-go/api"
+	"github.com/sst/opencode-api-go/internal/param"
+)
+
+// fakeSession is the generated faker's session with the optional fields the
+// adapter maps explicitly filled in.
+func fakeSession() api.Session {
+	var session api.Session
+	session.SetFake()
+	session.ID = "ses_1"
+	session.Time = api.SessionTime{Created: 1000, Updated: 2000}
+	session.ParentID = api.NewOptString("ses_0")
+	session.Share = api.NewOptSessionShare(api.SessionShare{URL: "https://opncd.ai/s/1"})
+	session.Revert = api.NewOptSessionRevert(api.SessionRevert{MessageID: "msg_1", PartID: api.NewOptString("prt_1")})
+	return session
+}
+
+type recorded struct {
+	method, path, directory string
+	body                    []byte
+}
+
+// newTestAdapter serves responses from routes, keyed by "METHOD /path", and
+// records the last request.
+func newTestAdapter(t *testing.T, routes map[string]json.Marshaler) (*GeneratedSessionAdapter, *recorded) {
+	t.Helper()
+	last := &recorded{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*last = recorded{method: r.Method, path: r.URL.Path, directory: r.URL.Query().Get("directory"), body: body}
+		res, ok := routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := res.MarshalJSON()
+		if err != nil {
+			t.Errorf("encode %s %s: %v", r.Method, r.URL.Path, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewGeneratedSessionAdapter(client), last
+}
+
+type rawJSON string
+
+func (r rawJSON) MarshalJSON() ([]byte, error) { return []byte(r), nil }
+
+func TestGeneratedSessionAdapterMapsSession(t *testing.T) {
+	session := fakeSession()
+	adapter, last := newTestAdapter(t, map[string]json.Marshaler{
+		"GET /session/ses_1": &session,
+	})
+
+	got, err := adapter.Get(context.Background(), "ses_1", SessionGetParams{Directory: field("/work")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.directory != "/work" {
+		t.Errorf("directory = %q, want /work", last.directory)
+	}
+	want := Session{
+		ID:        "ses_1",
+		Directory: session.Directory,
+		ProjectID: session.ProjectID,
+		Title:     session.Title,
+		Version:   session.Version,
+		ParentID:  "ses_0",
+		Time:      SessionTime{Created: 1000, Updated: 2000},
+		Share:     SessionShare{URL: "https://opncd.ai/s/1"},
+		Revert:    SessionRevert{MessageID: "msg_1", PartID: "prt_1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+
+	if _, err := adapter.Get(context.Background(), "", SessionGetParams{}); err == nil {
+		t.Error("expected missing id to fail")
+	}
+}
+
+func TestGeneratedSessionAdapterSessionOperations(t *testing.T) {
+	session := fakeSession()
+	sessions := rawJSON(`[` + string(must(session.MarshalJSON())) + `]`)
+	adapter, last := newTestAdapter(t, map[string]json.Marshaler{
+		"POST /session":                 &session,
+		"PATCH /session/ses_1":          &session,
+		"GET /session":                  sessions,
+		"GET /session/ses_1/children":   sessions,
+		"POST /session/ses_1/revert":    &session,
+		"POST /session/ses_1/unrevert":  &session,
+		"POST /session/ses_1/share":     &session,
+		"DELETE /session/ses_1/share":   &session,
+		"DELETE /session/ses_1":         rawJSON(`true`),
+		"POST /session/ses_1/abort":     rawJSON(`true`),
+		"POST /session/ses_1/init":      rawJSON(`true`),
+		"POST /session/ses_1/summarize": rawJSON(`true`),
+	})
+	ctx := context.Background()
+
+	one := func(s *Session, err error) (*Session, error) { return s, err }
+	many := func(s *[]Session, err error) (*Session, error) {
+		if err != nil || len(*s) != 1 {
+			return nil, err
+		}
+		return &(*s)[0], nil
+	}
+	ok := func(b *bool, err error) (*Session, error) {
+		if err != nil || !*b {
+			return nil, err
+		}
+		return &Session{ID: "ses_1"}, nil
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		call   func() (*Session, error)
+	}{
+		{"New", "POST", "/session", `{"title":"hello"}`, func() (*Session, error) {
+			return one(adapter.New(ctx, SessionNewParams{Title: field("hello")}))
+		}},
+		{"Update", "PATCH", "/session/ses_1", `{"title":"renamed"}`, func() (*Session, error) {
+			return one(adapter.Update(ctx, "ses_1", SessionUpdateParams{Title: field("renamed")}))
+		}},
+		{"List", "GET", "/session", "", func() (*Session, error) {
+			return many(adapter.List(ctx, SessionListParams{}))
+		}},
+		{"Children", "GET", "/session/ses_1/children", "", func() (*Session, error) {
+			return many(adapter.Children(ctx, "ses_1", SessionChildrenParams{}))
+		}},
+		{"Revert", "POST", "/session/ses_1/revert", `{"messageID":"msg_1","partID":"prt_1"}`, func() (*Session, error) {
+			return one(adapter.Revert(ctx, "ses_1", SessionRevertParams{MessageID: field("msg_1"), PartID: field("prt_1")}))
+		}},
+		{"Unrevert", "POST", "/session/ses_1/unrevert", "", func() (*Session, error) {
+			return one(adapter.Unrevert(ctx, "ses_1", SessionUnrevertParams{}))
+		}},
+		{"Share", "POST", "/session/ses_1/share", "", func() (*Session, error) {
+			return one(adapter.Share(ctx, "ses_1", SessionShareParams{}))
+		}},
+		{"Unshare", "DELETE", "/session/ses_1/share", "", func() (*Session, error) {
+			return one(adapter.Unshare(ctx, "ses_1", SessionUnshareParams{}))
+		}},
+		{"Delete", "DELETE", "/session/ses_1", "", func() (*Session, error) {
+			return ok(adapter.Delete(ctx, "ses_1", SessionDeleteParams{}))
+		}},
+		{"Abort", "POST", "/session/ses_1/abort", "", func() (*Session, error) {
+			return ok(adapter.Abort(ctx, "ses_1", SessionAbortParams{}))
+		}},
+		{"Init", "POST", "/session/ses_1/init", `{"messageID":"msg_1","providerID":"anthropic","modelID":"claude"}`, func() (*Session, error) {
+			return ok(adapter.Init(ctx, "ses_1", SessionInitParams{MessageID: field("msg_1"), ProviderID: field("anthropic"), ModelID: field("claude")}))
+		}},
+		{"Summarize", "POST", "/session/ses_1/summarize", `{"providerID":"anthropic","modelID":"claude"}`, func() (*Session, error) {
+			return ok(adapter.Summarize(ctx, "ses_1", SessionSummarizeParams{ProviderID: field("anthropic"), ModelID: field("claude")}))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil || got.ID != "ses_1" {
+				t.Errorf("unexpected result %+v", got)
+			}
+			if last.method != tt.method || last.path != tt.path {
+				t.Errorf("request = %s %s, want %s %s", last.method, last.path, tt.method, tt.path)
+			}
+			if tt.body != "" && string(last.body) != tt.body {
+				t.Errorf("body = %s, want %s", last.body, tt.body)
+			}
+		})
+	}
+}
+
+func TestGeneratedSessionAdapterMapsMessages(t *testing.T) {
+	var item api.SessionMessagesOKItem
+	item.SetFake()
+	var text api.TextPart
+	text.SetFake()
+	text.Text = "hello"
+	item.Parts = append(item.Parts, api.NewTextPartPart(text))
+
+	var prompt api.SessionPromptOK
+	prompt.SetFake()
+	prompt.Info.ModelID = "claude"
+
+	var shell api.AssistantMessage
+	shell.SetFake()
+	shell.ID = "msg_shell"
+
+	adapter, last := newTestAdapter(t, map[string]json.Marshaler{
+		"GET /session/ses_1/message":       rawJSON(`[` + string(must(item.MarshalJSON())) + `]`),
+		"GET /session/ses_1/message/msg_1": &api.SessionMessageOK{Info: item.Info, Parts: item.Parts},
+		"POST /session/ses_1/message":      &prompt,
+		"POST /session/ses_1/shell":        &shell,
+		"POST /session/ses_1/command":      &api.SessionCommandOK{Info: prompt.Info, Parts: prompt.Parts},
+	})
+	ctx := context.Background()
+
+	messages, err := adapter.Messages(ctx, "ses_1", SessionMessagesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(*messages))
+	}
+	parts := (*messages)[0].Parts
+	if part, ok := parts[len(parts)-1].AsUnion().(TextPart); !ok || part.Text != "hello" {
+		t.Errorf("last part = %#v, want text part", parts[len(parts)-1].AsUnion())
+	}
+	if _, ok := (*messages)[0].Info.AsUnion().(AssistantMessage); !ok {
+		t.Errorf("info = %T, want AssistantMessage", (*messages)[0].Info.AsUnion())
+	}
+
+	message, err := adapter.Message(ctx, "ses_1", "msg_1", SessionMessageParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(message.Parts) != len(item.Parts) {
+		t.Errorf("got %d parts, want %d", len(message.Parts), len(item.Parts))
+	}
+
+	response, err := adapter.Prompt(ctx, "ses_1", SessionPromptParams{
+		Parts: field([]SessionPromptParamsPartUnion{TextPartInputParam{Type: field(TextPartInputTypeText), Text: field("hi")}}),
+		Model: field(SessionPromptParamsModel{ProviderID: field("anthropic"), ModelID: field("claude")}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Info.ModelID != "claude" {
+		t.Errorf("prompt response model = %q, want claude", response.Info.ModelID)
+	}
+	var sent api.SessionPromptReq
+	if err := sent.UnmarshalJSON(last.body); err != nil {
+		t.Fatalf("prompt body %s: %v", last.body, err)
+	}
+	if len(sent.Parts) != 1 || sent.Parts[0].TextPartInput.Text != "hi" || sent.Model.Value.ProviderID != "anthropic" {
+		t.Errorf("unexpected prompt request %s", last.body)
+	}
+
+	assistant, err := adapter.Shell(ctx, "ses_1", SessionShellParams{Agent: field("build"), Command: field("ls")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assistant.ID != "msg_shell" || string(last.body) != `{"agent":"build","command":"ls"}` {
+		t.Errorf("shell = %s with body %s", assistant.ID, last.body)
+	}
+
+	command, err := adapter.Command(ctx, "ses_1", SessionCommandParams{Command: field("review"), Arguments: field("")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command.Info.ModelID != "claude" {
+		t.Errorf("command response model = %q, want claude", command.Info.ModelID)
+	}
+}
+
+func field[T any](v T) param.Field[T] {
+	return param.Field[T]{Value: v, Present: true}
+}
+
+func must(data []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
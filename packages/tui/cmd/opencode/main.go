@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	flag "github.com/spf13/pflag"
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode-api-go/option"
+	"github.com/sst/opencode/internal/api"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/backend"
+	"github.com/sst/opencode/internal/clipboard"
+	"github.com/sst/opencode/internal/connection"
+	"github.com/sst/opencode/internal/exporter"
+	"github.com/sst/opencode/internal/headless"
+	"github.com/sst/opencode/internal/logbuffer"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/tui"
+	"github.com/sst/opencode/internal/util"
+	"golang.org/x/sync/errgroup"
+)
+
+var Version = "dev"
+
+func main() {
+	version := Version
+	if version != "dev" && !strings.HasPrefix(Version, "v") {
+		version = "v" + Version
+	}
+
+	var model *string = flag.String("model", "", "model to begin with")
+	var prompt *string = flag.String("prompt", "", "prompt to begin with")
+	var agent *string = flag.String("agent", "", "agent to begin with")
+	var sessionID *string = flag.String("session", "", "session ID")
+	var server *string = flag.String("server", "", "server URL, or unix:///path for a socket (default $OPENCODE_SERVER)")
+	var headlessMode *bool = flag.Bool("headless", false, "send the prompt and print the response without the TUI")
+	var format *string = flag.String("format", "text", "headless output format: text or json")
+	var permissions *string = flag.String("permissions", "reject", "headless answer to permission requests no policy rule decides: reject, once or always")
+	var exportPath *string = flag.String("export", "", "write the --session transcript to this file and exit")
+	var exportFormat *string = flag.String("export-format", "", "export format: markdown, json or html (default from the file extension)")
+	var importPath *string = flag.String("import", "", "open an exported JSON transcript read-only")
+	var replaySpeed *float64 = flag.Float64("replay", 0, "replay the --import transcript at this speed (1 is original timing)")
+	var backendName *string = flag.String("backend", backend.Stainless, "API client to use: "+strings.Join(backend.Names, " or "))
+	flag.Parse()
+
+	// `opencode theme ...` works on theme files without a server.
+	if flag.Arg(0) == "theme" {
+		os.Exit(runThemeCommand(flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+
+	// --import only shows a transcript, so it doesn't connect to a server
+	if *importPath != "" {
+		os.Exit(runImport(version, *importPath, *replaySpeed))
+	}
+
+	// `opencode run <prompt...>` is shorthand for --headless --prompt.
+	if flag.Arg(0) == "run" {
+		*headlessMode = true
+		if args := strings.Join(flag.Args()[1:], " "); args != "" {
+			if *prompt != "" {
+				args = *prompt + "\n" + args
+			}
+			prompt = &args
+		}
+	}
+
+	var headlessOpts headless.Options
+	if *headlessMode {
+		outputFormat, err := headless.ParseFormat(*format)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "opencode:", err)
+			os.Exit(1)
+		}
+		permissionMode, err := headless.ParsePermissionMode(*permissions)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "opencode:", err)
+			os.Exit(1)
+		}
+		headlessOpts = headless.Options{Format: outputFormat, Permissions: permissionMode}
+	}
+
+	profile, err := connection.Resolve(connection.Flags{Server: *server}, connection.DefaultConfigPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "opencode:", err)
+		os.Exit(1)
+	}
+
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		slog.Error("Failed to stat stdin", "error", err)
+		os.Exit(1)
+	}
+
+	// Check if there's data piped to stdin
+	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		stdin, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			slog.Error("Failed to read stdin", "error", err)
+			os.Exit(1)
+		}
+		stdinContent := strings.TrimSpace(string(stdin))
+		if stdinContent != "" {
+			if prompt == nil || *prompt == "" {
+				prompt = &stdinContent
+			} else {
+				combined := *prompt + "\n" + stdinContent
+				prompt = &combined
+			}
+		}
+	}
+
+	transport, err := profile.HTTPClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "opencode:", err)
+		os.Exit(1)
+	}
+	if err := profile.Check(context.Background(), transport); err != nil {
+		fmt.Fprintln(os.Stderr, "opencode:", err)
+		os.Exit(1)
+	}
+
+	httpClient := opencode.NewClient(
+		option.WithBaseURL(profile.Server),
+		option.WithHTTPClient(transport),
+	)
+	sdk, err := backend.New(*backendName, httpClient, profile.Server, transport)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "opencode:", err)
+		os.Exit(1)
+	}
+
+	var agents []opencode.Agent
+	var path *opencode.Path
+	var project *opencode.Project
+
+	batch := errgroup.Group{}
+
+	batch.Go(func() error {
+		result, err := httpClient.Project.Current(context.Background(), opencode.ProjectCurrentParams{})
+		if err != nil {
+			return err
+		}
+		project = result
+		return nil
+	})
+
+	batch.Go(func() error {
+		result, err := httpClient.Agent.List(context.Background(), opencode.AgentListParams{})
+		if err != nil {
+			return err
+		}
+		agents = *result
+		return nil
+	})
+
+	batch.Go(func() error {
+		result, err := httpClient.Path.Get(context.Background(), opencode.PathGetParams{})
+		if err != nil {
+			return err
+		}
+		path = result
+		return nil
+	})
+
+	err = batch.Wait()
+	if err != nil {
+		panic(err)
+	}
+	if len(agents) == 0 {
+		slog.Error("No agents returned from server")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	apiHandler := util.NewAPILogHandler(ctx, httpClient, "tui", slog.LevelDebug,
+		util.WithLogFile(filepath.Join(path.State, "log", "tui.log")),
+	)
+	logs := logbuffer.New(2000)
+	logger := slog.New(logbuffer.Tee(apiHandler, logs))
+	slog.SetDefault(logger)
+
+	slog.Debug("TUI launched")
+
+	go func() {
+		err = clipboard.Init()
+		if err != nil && !clipboard.Forced() {
+			slog.Info("System clipboard unavailable, copying with OSC52", "error", err)
+		} else if err != nil {
+			slog.Error("Failed to initialize clipboard", "error", err)
+		}
+	}()
+
+	// Create main context for the application
+	app_, err := app.New(ctx, version, project, path, agents, httpClient, sdk, profile, model, prompt, agent, sessionID)
+	if err != nil {
+		panic(err)
+	}
+	app_.LogHandler = apiHandler
+	app_.Logs = logs
+
+	if *exportPath != "" {
+		err := exportSession(ctx, app_, *sessionID, *exportFormat, *exportPath)
+		app_.Cleanup()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "opencode:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *headlessMode {
+		signalCtx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+		headlessOpts.Prompt = *prompt
+		err := headless.Run(signalCtx, app_, headlessOpts, os.Stdout, os.Stderr)
+		stop()
+		app_.Cleanup()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "opencode:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	tuiModel := tui.NewModel(app_).(*tui.Model)
+	program := tea.NewProgram(
+		tuiModel,
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+	)
+
+	// Set up signal handling for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+
+	go api.Start(ctx, program, httpClient)
+
+	// Handle signals in a separate goroutine
+	go func() {
+		sig := <-sigChan
+		slog.Info("Received signal, shutting down gracefully", "signal", sig)
+		tuiModel.Cleanup()
+		program.Quit()
+	}()
+
+	// Run the TUI
+	result, err := program.Run()
+	if err != nil {
+		slog.Error("TUI error", "error", err)
+	}
+
+	tuiModel.Cleanup()
+	slog.Info("TUI exited", "result", result)
+}
+
+func exportSession(ctx context.Context, a *app.App, sessionID, format, path string) error {
+	if sessionID == "" {
+		return fmt.Errorf("--export needs --session")
+	}
+	session, err := a.Client.Session.Get(ctx, sessionID, opencode.SessionGetParams{})
+	if err != nil {
+		return fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	messages, err := a.ListMessages(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load messages for session %s: %w", sessionID, err)
+	}
+	return exporter.WriteFile(path, format, exporter.Transcript{
+		Session:  *session,
+		Messages: messages,
+		Theme:    theme.CurrentTheme(),
+		// The TUI isn't running to report the background, so ask the terminal
+		Dark: lipgloss.HasDarkBackground(os.Stdin, os.Stdout),
+	})
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sst/opencode/internal/theme"
+)
+
+const themeUsage = `usage:
+  opencode theme import [flags] <file>
+    Converts a base16 (.yaml), VS Code (.json) or iTerm2 (.itermcolors)
+    color scheme to an opencode theme file.
+  opencode theme check [--all] [theme or file ...]
+    Reports theme colors below the WCAG contrast minimums. Without
+    arguments the custom theme files in the theme directories are checked.
+`
+
+// runThemeCommand runs `opencode theme ...` and returns the exit code.
+func runThemeCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, themeUsage)
+		return 2
+	}
+	switch args[0] {
+	case "import":
+		if err := importTheme(args[1:], stdout, stderr); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			fmt.Fprintln(stderr, "opencode:", err)
+			return 1
+		}
+		return 0
+	case "check":
+		failed, err := checkThemes(args[1:], stdout, stderr)
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, "opencode:", err)
+			return 1
+		}
+		if failed {
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintf(stderr, "opencode: unknown theme command %q\n", args[0])
+		fmt.Fprint(stderr, themeUsage)
+		return 2
+	}
+}
+
+func importTheme(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("theme import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, themeUsage+"\n")
+		flags.PrintDefaults()
+	}
+	formats := make([]string, len(theme.ImportFormats))
+	for i, format := range theme.ImportFormats {
+		formats[i] = string(format)
+	}
+	format := flags.String("format", "", "scheme format: "+strings.Join(formats, ", ")+" (default from the file)")
+	name := flags.String("name", "", "theme name (default from the file name)")
+	output := flags.String("o", "", "file to write, or - for stdout (default .opencode/themes/<name>.json)")
+	force := flags.Bool("force", false, "overwrite an existing theme file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected one scheme file")
+	}
+
+	input := flags.Arg(0)
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	importFormat := theme.ImportFormat(*format)
+	if importFormat == "" {
+		if importFormat, err = theme.DetectFormat(input, data); err != nil {
+			return err
+		}
+		if importFormat == "" {
+			return fmt.Errorf("%s is already an opencode theme", input)
+		}
+	}
+	jsonTheme, err := theme.Import(importFormat, data)
+	if err != nil {
+		return err
+	}
+	out, err := theme.MarshalJSONTheme(jsonTheme)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		base := filepath.Base(input)
+		*name = strings.ToLower(strings.ReplaceAll(strings.TrimSuffix(base, filepath.Ext(base)), " ", "-"))
+	}
+	if *output == "-" {
+		_, err := stdout.Write(out)
+		return err
+	}
+	if *output == "" {
+		*output = filepath.Join(".opencode", "themes", *name+".json")
+	}
+	if _, err := os.Stat(*output); err == nil && !*force {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", *output)
+	}
+	if err := os.MkdirAll(filepath.Dir(*output), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*output, out, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Wrote %s theme %q to %s\n", importFormat, *name, *output)
+	return nil
+}
+
+// checkThemes prints a contrast report and reports whether any theme
+// failed.
+func checkThemes(args []string, stdout, stderr io.Writer) (bool, error) {
+	flags := flag.NewFlagSet("theme check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	all := flags.Bool("all", false, "check every theme, built-in ones included")
+	if err := flags.Parse(args); err != nil {
+		return false, err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false, err
+	}
+	dirs := theme.Directories(userConfigDir(), cwd, cwd)
+	if err := theme.LoadThemesFromDirectories(userConfigDir(), cwd, cwd); err != nil {
+		return false, err
+	}
+	// a file or a theme name; with no arguments, every custom theme file
+	type target struct {
+		name string
+		load func() (theme.Theme, error)
+	}
+	var targets []target
+	if *all {
+		for _, name := range theme.AvailableThemes() {
+			if name == "system" {
+				continue
+			}
+			targets = append(targets, target{name, func() (theme.Theme, error) { return theme.GetTheme(name), nil }})
+		}
+	}
+	if flags.NArg() == 0 && !*all {
+		for _, dir := range dirs {
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				if entry.IsDir() || !theme.IsThemeFile(entry.Name()) {
+					continue
+				}
+				file := filepath.Join(dir, entry.Name())
+				targets = append(targets, target{file, func() (theme.Theme, error) { return theme.LoadThemeFile(file) }})
+			}
+		}
+	}
+	for _, arg := range flags.Args() {
+		if _, err := os.Stat(arg); err == nil {
+			targets = append(targets, target{arg, func() (theme.Theme, error) { return theme.LoadThemeFile(arg) }})
+			continue
+		}
+		targets = append(targets, target{arg, func() (theme.Theme, error) {
+			if t := theme.GetTheme(arg); t != nil {
+				return t, nil
+			}
+			return nil, fmt.Errorf("no theme or file named %s", arg)
+		}})
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(stdout, "No custom themes in %s\n", strings.Join(dirs, ", "))
+		return false, nil
+	}
+
+	failed := false
+	for _, target := range targets {
+		t, err := target.load()
+		if err != nil {
+			fmt.Fprintf(stdout, "✗ %s: %v\n", target.name, err)
+			failed = true
+			continue
+		}
+		issues := theme.CheckContrast(t)
+		if len(issues) == 0 {
+			fmt.Fprintf(stdout, "✓ %s\n", target.name)
+			continue
+		}
+		failed = true
+		fmt.Fprintf(stdout, "✗ %s\n", target.name)
+		for _, issue := range issues {
+			fmt.Fprintf(stdout, "    %s\n", issue)
+		}
+	}
+	return failed, nil
+}
+
+// userConfigDir is where the server keeps opencode's configuration.
+func userConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "opencode")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "opencode")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/logbuffer"
+	"github.com/sst/opencode/internal/replay"
+	"github.com/sst/opencode/internal/tui"
+)
+
+// runImport shows the transcript at path read-only, replaying it when speed
+// is positive, and returns the exit code. It needs no server: the app has no
+// event stream and the TUI listens on no control channel.
+func runImport(version, path string, speed float64) int {
+	logs := logbuffer.New(2000)
+	slog.SetDefault(slog.New(logbuffer.Tee(slog.DiscardHandler, logs)))
+
+	a := app.NewOffline(version, userConfigDir())
+	a.Logs = logs
+
+	tuiModel := tui.NewModel(a).(*tui.Model)
+	program := tea.NewProgram(
+		tuiModel,
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+	)
+
+	load := replay.Load(path, speed > 0, replay.Options{Speed: speed})
+	go func() { program.Send(load()) }()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigChan
+		program.Quit()
+	}()
+
+	_, err := program.Run()
+	tuiModel.Cleanup()
+	if err != nil {
+		slog.Error("TUI error", "error", err)
+		return 1
+	}
+	return 0
+}
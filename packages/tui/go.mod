@@ -24,7 +24,9 @@ require (
 	github.com/sst/opencode-api-go v0.1.0
 	golang.org/x/image v0.28.0
 	golang.org/x/sync v0.15.0
+	golang.org/x/sys v0.33.0
 	golang.org/x/text v0.26.0
+	gopkg.in/yaml.v2 v2.4.0
 	rsc.io/qr v0.2.0
 )
 
@@ -68,7 +70,5 @@ require (
 	go.uber.org/zap v1.27.0 // indirect
 	golang.org/x/exp v0.0.0-20250305212735-054e65f0b394 // indirect
 	golang.org/x/net v0.40.0 // indirect
-	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/term v0.32.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
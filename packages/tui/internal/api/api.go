@@ -3,24 +3,260 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
-	"github.com/sst/opencode-api-go"
 	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/google/uuid"
+	"github.com/sst/opencode-api-go/option"
 )
 
+// DefaultTimeout is how long a request may wait for the TUI to reply before
+// the controller answers on its behalf with a timeout error.
+const DefaultTimeout = 10 * time.Second
+
+// Request is a control message delivered to the running tea.Program. The TUI
+// must answer every request it receives with Reply.
 type Request struct {
+	ID   string          `json:"id"`
 	Path string          `json:"path"`
 	Body json.RawMessage `json:"body"`
+
+	controller *Controller
+}
+
+// Response is posted back to the sender of a Request, correlated by ID.
+type Response struct {
+	ID    string `json:"id"`
+	Path  string `json:"path"`
+	OK    bool   `json:"ok"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
 }
 
-func Start(ctx context.Context, program *tea.Program, client *opencode.Client) {
-	// TODO: Implement TUI control API or remove if not needed
-	// The /tui/control/next and /tui/control/response endpoints don't exist in the current API
+// Transport moves requests and responses between the TUI and whoever drives
+// it. Next blocks until a request is available or ctx is done.
+type Transport interface {
+	Next(ctx context.Context) (Request, error)
+	Respond(ctx context.Context, response Response) error
 }
 
-func Reply(ctx context.Context, client *opencode.Client, response interface{}) tea.Cmd {
+// Sender is the part of tea.Program the controller needs.
+type Sender interface {
+	Send(msg tea.Msg)
+}
+
+// Controller reads requests from a Transport, forwards them to the program
+// and makes sure each one is answered exactly once.
+type Controller struct {
+	transport Transport
+	timeout   time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*time.Timer
+}
+
+func NewController(transport Transport, timeout time.Duration) *Controller {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Controller{
+		transport: transport,
+		timeout:   timeout,
+		pending:   make(map[string]*time.Timer),
+	}
+}
+
+// Serve delivers requests to program until ctx is cancelled.
+func (c *Controller) Serve(ctx context.Context, program Sender) {
+	backoff := time.Duration(0)
+	for {
+		req, err := c.transport.Next(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			backoff = min(max(backoff*2, 250*time.Millisecond), 10*time.Second)
+			slog.Debug("Failed to get next TUI control request", "error", err, "retry", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		if req.ID == "" {
+			req.ID = uuid.NewString()
+		}
+		req.controller = c
+		if !c.track(ctx, req) {
+			slog.Warn("Dropping duplicate TUI control request", "id", req.ID, "path", req.Path)
+			continue
+		}
+		program.Send(req)
+	}
+}
+
+// track starts the timeout of req, unless a request with the same ID is
+// still waiting for its reply.
+func (c *Controller) track(ctx context.Context, req Request) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.pending[req.ID]; ok {
+		return false
+	}
+	c.pending[req.ID] = time.AfterFunc(c.timeout, func() {
+		slog.Warn("TUI control request timed out", "id", req.ID, "path", req.Path)
+		c.finish(ctx, Response{
+			ID:    req.ID,
+			Path:  req.Path,
+			Error: fmt.Sprintf("timed out after %s", c.timeout),
+		})
+	})
+	return true
+}
+
+// finish sends response unless the request was already answered.
+func (c *Controller) finish(ctx context.Context, response Response) error {
+	c.mu.Lock()
+	timer, ok := c.pending[response.ID]
+	if ok {
+		timer.Stop()
+		delete(c.pending, response.ID)
+	}
+	c.mu.Unlock()
+	if !ok {
+		slog.Debug("Dropping reply to unknown or expired request", "id", response.ID)
+		return nil
+	}
+	return c.transport.Respond(ctx, response)
+}
+
+// Start serves control requests from the server to program until ctx is
+// cancelled.
+func Start(ctx context.Context, program Sender, client Client) {
+	NewController(NewHTTPTransport(client), DefaultTimeout).Serve(ctx, program)
+}
+
+// Reply answers req. A nil or non-error response is reported as success with
+// the value as data; an error is reported as a failure.
+func Reply(ctx context.Context, req Request, response any) tea.Cmd {
 	return func() tea.Msg {
-		// TODO: Implement TUI control response API or remove if not needed
+		if req.controller == nil {
+			return nil
+		}
+		result := Response{ID: req.ID, Path: req.Path, OK: true, Data: response}
+		if err, ok := response.(error); ok {
+			result.OK = false
+			result.Data = nil
+			result.Error = err.Error()
+		}
+		if err := req.controller.finish(ctx, result); err != nil {
+			slog.Error("Failed to reply to TUI control request", "id", req.ID, "error", err)
+		}
 		return nil
 	}
 }
+
+// Client is the subset of *opencode.Client used by the HTTP transport.
+type Client interface {
+	Get(ctx context.Context, path string, params any, res any, opts ...option.RequestOption) error
+	Post(ctx context.Context, path string, params any, res any, opts ...option.RequestOption) error
+}
+
+// HTTPTransport long-polls the server for control requests.
+type HTTPTransport struct {
+	client Client
+}
+
+func NewHTTPTransport(client Client) *HTTPTransport {
+	return &HTTPTransport{client: client}
+}
+
+func (t *HTTPTransport) Next(ctx context.Context) (Request, error) {
+	var req Request
+	if err := t.client.Get(ctx, "/tui/control/next", nil, &req); err != nil {
+		return Request{}, err
+	}
+	if req.Path == "" {
+		return Request{}, errors.New("empty control request")
+	}
+	return req, nil
+}
+
+func (t *HTTPTransport) Respond(ctx context.Context, response Response) error {
+	return t.client.Post(ctx, "/tui/control/response", response, nil)
+}
+
+// LocalTransport is an in-process Transport for tests and embedding. Call
+// plays the role of the remote side.
+type LocalTransport struct {
+	requests chan Request
+
+	mu      sync.Mutex
+	waiting map[string]chan Response
+}
+
+func NewLocalTransport() *LocalTransport {
+	return &LocalTransport{
+		requests: make(chan Request),
+		waiting:  make(map[string]chan Response),
+	}
+}
+
+func (t *LocalTransport) Next(ctx context.Context) (Request, error) {
+	select {
+	case <-ctx.Done():
+		return Request{}, ctx.Err()
+	case req := <-t.requests:
+		return req, nil
+	}
+}
+
+func (t *LocalTransport) Respond(ctx context.Context, response Response) error {
+	t.mu.Lock()
+	ch, ok := t.waiting[response.ID]
+	delete(t.waiting, response.ID)
+	t.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no caller waiting for request %s", response.ID)
+	}
+	ch <- response
+	return nil
+}
+
+// Call sends a request to the TUI and waits for its response.
+func (t *LocalTransport) Call(ctx context.Context, path string, body any) (Response, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return Response{}, err
+	}
+	req := Request{ID: uuid.NewString(), Path: path, Body: raw}
+	ch := make(chan Response, 1)
+
+	t.mu.Lock()
+	t.waiting[req.ID] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.waiting, req.ID)
+		t.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	case t.requests <- req:
+	}
+	select {
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	case response := <-ch:
+		return response, nil
+	}
+}
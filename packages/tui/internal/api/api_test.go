@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode-api-go/option"
+)
+
+// replyingProgram answers every request it receives with reply.
+type replyingProgram struct {
+	reply func(Request) tea.Cmd
+}
+
+func (p replyingProgram) Send(msg tea.Msg) {
+	if req, ok := msg.(Request); ok {
+		go p.reply(req)()
+	}
+}
+
+func TestControllerRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := NewLocalTransport()
+	program := replyingProgram{reply: func(req Request) tea.Cmd {
+		if req.Path == "/tui/fail" {
+			return Reply(ctx, req, errors.New("boom"))
+		}
+		return Reply(ctx, req, req.Path)
+	}}
+	go NewController(transport, time.Second).Serve(ctx, program)
+
+	response, err := transport.Call(ctx, "/tui/show-toast", map[string]string{"message": "hi"})
+	if err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	if !response.OK || response.Data != "/tui/show-toast" || response.ID == "" {
+		t.Errorf("unexpected response: %+v", response)
+	}
+
+	response, err = transport.Call(ctx, "/tui/fail", nil)
+	if err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	if response.OK || response.Error != "boom" {
+		t.Errorf("expected error response, got %+v", response)
+	}
+}
+
+func TestControllerTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := NewLocalTransport()
+	var late Request
+	received := make(chan struct{})
+	program := replyingProgram{reply: func(req Request) tea.Cmd {
+		late = req
+		close(received)
+		return func() tea.Msg { return nil }
+	}}
+	go NewController(transport, 20*time.Millisecond).Serve(ctx, program)
+
+	response, err := transport.Call(ctx, "/tui/open-help", nil)
+	if err != nil {
+		t.Fatalf("Call returned error: %v", err)
+	}
+	if response.OK || response.Error == "" {
+		t.Errorf("expected timeout response, got %+v", response)
+	}
+
+	<-received
+	// A reply after the timeout must be dropped rather than sent twice.
+	if msg := Reply(ctx, late, true)(); msg != nil {
+		t.Errorf("expected nil msg, got %v", msg)
+	}
+}
+
+// scriptedTransport hands out requests in order and records the responses.
+type scriptedTransport struct {
+	requests  chan Request
+	responses chan Response
+}
+
+func (t scriptedTransport) Next(ctx context.Context) (Request, error) {
+	select {
+	case <-ctx.Done():
+		return Request{}, ctx.Err()
+	case req := <-t.requests:
+		return req, nil
+	}
+}
+
+func (t scriptedTransport) Respond(ctx context.Context, response Response) error {
+	t.responses <- response
+	return nil
+}
+
+func TestControllerDropsDuplicateRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := scriptedTransport{requests: make(chan Request, 2), responses: make(chan Response, 2)}
+	transport.requests <- Request{ID: "req_1", Path: "/tui/open-help"}
+	transport.requests <- Request{ID: "req_1", Path: "/tui/open-help"}
+	received := make(chan Request, 2)
+	program := replyingProgram{reply: func(req Request) tea.Cmd {
+		received <- req
+		return func() tea.Msg { return nil }
+	}}
+	go NewController(transport, 20*time.Millisecond).Serve(ctx, program)
+
+	response := <-transport.responses
+	if response.ID != "req_1" || response.OK {
+		t.Errorf("expected a timeout for req_1, got %+v", response)
+	}
+	select {
+	case response := <-transport.responses:
+		t.Errorf("the duplicate was answered too: %+v", response)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if len(received) != 1 {
+		t.Errorf("the program received %d requests, want 1", len(received))
+	}
+}
+
+func TestHTTPTransport(t *testing.T) {
+	requests := make(chan Request, 1)
+	requests <- Request{ID: "req_1", Path: "/tui/open-help"}
+	responses := make(chan Response, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/tui/control/next":
+			select {
+			case req := <-requests:
+				json.NewEncoder(w).Encode(req)
+			case <-r.Context().Done():
+			}
+		case "/tui/control/response":
+			var response Response
+			json.NewDecoder(r.Body).Decode(&response)
+			responses <- response
+			w.Write([]byte("true"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := opencode.NewClient(option.WithBaseURL(server.URL), option.WithHTTPClient(server.Client()))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	program := replyingProgram{reply: func(req Request) tea.Cmd {
+		return Reply(ctx, req, true)
+	}}
+	go NewController(NewHTTPTransport(client), time.Second).Serve(ctx, program)
+
+	select {
+	case response := <-responses:
+		if response.ID != "req_1" || !response.OK || response.Data != true {
+			t.Errorf("unexpected response: %+v", response)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no response was posted")
+	}
+}
@@ -2,21 +2,29 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"log/slog"
 
 	opencode "github.com/sst/opencode-api-go"
-	"github.com/sst/opencode-api-go/packages/ssestream"
 	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/backend"
 	"github.com/sst/opencode/internal/clipboard"
 	"github.com/sst/opencode/internal/commands"
 	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/connection"
+	"github.com/sst/opencode/internal/eventqueue"
 	"github.com/sst/opencode/internal/id"
+	"github.com/sst/opencode/internal/logbuffer"
+	"github.com/sst/opencode/internal/policy"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
 	"github.com/sst/opencode/internal/util"
@@ -35,28 +43,42 @@ type App struct {
 	StatePath         string
 	Config            *opencode.Config
 	Client            *opencode.Client
+	Backend           backend.Backend
+	Connection        *connection.Profile
 	State             *State
 	AgentIndex        int
 	Provider          *opencode.Provider
 	Model             *opencode.Model
 	Session           *opencode.Session
 	Messages          []Message
+	Transcript        string // path of an imported transcript shown read-only
 	Permissions       []opencode.Permission
 	CurrentPermission opencode.Permission
+	PermissionPolicy  *policy.Policy
+	PermissionAudit   *policy.Audit
+	LogHandler        *util.APILogHandler // flushed on Cleanup
+	Logs              *logbuffer.Ring     // recent records for the log viewer
 	Commands          commands.CommandRegistry
 	InitialModel      *string
 	InitialPrompt     *string
 	InitialAgent      *string
 	InitialSession    *string
 	compactCancel     context.CancelFunc
+	themeWatcher      *theme.Watcher
 
 	// SSE event stream handling
-	eventStream       *ssestream.Stream[opencode.EventListResponse]
+	eventStream       backend.EventStream
 	eventStreamCancel context.CancelFunc
-	eventChan         chan opencode.EventListResponse
+	eventStreamDone   chan struct{}
+	events            *eventqueue.Queue[opencode.EventListResponse]
+	lastEventID       string
+	streamMu          sync.Mutex
+	streamStatus      EventStreamStatusMsg
+	streamStatusChan  chan EventStreamStatusMsg
 	IsLeaderSequence  bool
 	IsBashMode        bool
 	ScrollSpeed       int
+	VimMode           bool
 }
 
 func (a *App) Agent() *opencode.Agent {
@@ -111,6 +133,8 @@ func New(
 	path *opencode.Path,
 	agents []opencode.Agent,
 	httpClient *opencode.Client,
+	api backend.Backend,
+	profile *connection.Profile,
 	initialModel *string,
 	initialPrompt *string,
 	initialAgent *string,
@@ -119,7 +143,7 @@ func New(
 	util.RootPath = project.Worktree
 	util.CwdPath, _ = os.Getwd()
 
-	configInfo, err := httpClient.Config.Get(ctx, opencode.ConfigGetParams{})
+	configInfo, err := api.Config(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -130,13 +154,16 @@ func New(
 
 	appStatePath := filepath.Join(path.State, "tui")
 	appState, err := LoadState(appStatePath)
-	if err != nil {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
 		appState = NewState()
 		SaveState(appStatePath, appState)
-	}
-
-	if appState.AgentModel == nil {
-		appState.AgentModel = make(map[string]AgentModel)
+	case err != nil:
+		// Keep the file for the user to repair, SaveState won't replace it
+		slog.Warn("Ignoring unreadable state file, changes will not be saved", "file", appStatePath, "error", err)
+		appState = NewState()
+	case appState.Version > stateVersion:
+		slog.Warn("State file was written by a newer version of opencode, changes will not be saved", "file", appStatePath)
 	}
 
 	if configInfo.Theme != "" {
@@ -148,13 +175,21 @@ func New(
 		appState.Theme = themeEnv
 	}
 
+	clipboardBackend := appState.Clipboard
+	if clipboardEnv := os.Getenv("OPENCODE_CLIPBOARD"); clipboardEnv != "" {
+		clipboardBackend = clipboardEnv
+	}
+	if err := clipboard.SetBackend(clipboard.Backend(clipboardBackend)); err != nil {
+		slog.Warn("Ignoring clipboard setting", "error", err)
+	}
+
 	agentIndex := slices.IndexFunc(agents, func(a opencode.Agent) bool {
 		return a.Mode != "subagent"
 	})
 	var agent *opencode.Agent
 	modeName := "build"
-	if appState.Agent != "" {
-		modeName = appState.Agent
+	if agent := appState.Project(project.Worktree).Agent; agent != "" {
+		modeName = agent
 	}
 	if initialAgent != nil && *initialAgent != "" {
 		modeName = *initialAgent
@@ -168,10 +203,10 @@ func New(
 	agent = &agents[agentIndex]
 
 	if agent.Model.ModelID != "" {
-		appState.AgentModel[agent.Name] = AgentModel{
+		appState.SetAgentModel(project.Worktree, agent.Name, AgentModel{
 			ProviderID: agent.Model.ProviderID,
 			ModelID:    agent.Model.ModelID,
-		}
+		})
 	}
 
 	if err := theme.LoadThemesFromDirectories(
@@ -181,6 +216,10 @@ func New(
 	); err != nil {
 		slog.Warn("Failed to load themes from directories", "error", err)
 	}
+	themeWatcher, err := theme.Watch(theme.Directories(path.Config, util.RootPath, util.CwdPath))
+	if err != nil {
+		slog.Warn("Failed to watch theme directories", "error", err)
+	}
 
 	if appState.Theme != "" {
 		if appState.Theme == "system" && styles.Terminal != nil {
@@ -194,28 +233,44 @@ func New(
 
 	slog.Debug("Loaded config", "config", configInfo)
 
-	customCommands, err := httpClient.Command.List(ctx, opencode.CommandListParams{})
+	customCommands, err := api.Commands(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	permissionPolicy, err := policy.Load(policy.UserPath(), project.Worktree)
+	if err != nil {
+		// an unreadable policy asks about everything rather than guessing
+		slog.Warn("Failed to load permission policy", "error", err)
+		permissionPolicy = &policy.Policy{}
+	}
+	for _, rule := range permissionPolicy.Ignored {
+		slog.Warn("Ignoring allow rule of an untrusted project", "rule", rule.Source())
+	}
+
 	app := &App{
-		Project:        *project,
-		Agents:         agents,
-		Version:        version,
-		StatePath:      appStatePath,
-		Config:         configInfo,
-		State:          appState,
-		Client:         httpClient,
-		AgentIndex:     agentIndex,
-		Session:        &opencode.Session{},
-		Messages:       []Message{},
-		Commands:       commands.LoadFromConfig(configInfo, *customCommands),
-		InitialModel:   initialModel,
-		InitialPrompt:  initialPrompt,
-		InitialAgent:   initialAgent,
-		InitialSession: initialSession,
-		ScrollSpeed:    int(configInfo.Tui.ScrollSpeed),
+		Project:          *project,
+		Agents:           agents,
+		Version:          version,
+		StatePath:        appStatePath,
+		Config:           configInfo,
+		State:            appState,
+		Client:           httpClient,
+		Backend:          api,
+		Connection:       profile,
+		AgentIndex:       agentIndex,
+		Session:          &opencode.Session{},
+		Messages:         []Message{},
+		Commands:         commands.LoadFromConfig(configInfo, customCommands),
+		PermissionPolicy: permissionPolicy,
+		PermissionAudit:  policy.OpenAudit(filepath.Join(path.State, policy.AuditFileName)),
+		InitialModel:     initialModel,
+		InitialPrompt:    initialPrompt,
+		InitialAgent:     initialAgent,
+		InitialSession:   initialSession,
+		ScrollSpeed:      int(configInfo.Tui.ScrollSpeed),
+		VimMode:          vimMode(configInfo.Tui),
+		themeWatcher:     themeWatcher,
 	}
 
 	// Start the SSE event stream for real-time updates
@@ -230,6 +285,38 @@ func New(
 	return app, nil
 }
 
+// NewOffline returns an app for viewing imported transcripts without a
+// server. It has no event stream, keeps its state in memory, and its API
+// calls fail with backend.ErrOffline.
+func NewOffline(version, configDir string) *App {
+	util.CwdPath, _ = os.Getwd()
+	util.RootPath = util.CwdPath
+
+	configInfo := &opencode.Config{}
+	configInfo.Keybinds.Leader = "ctrl+x"
+
+	if err := theme.LoadThemesFromDirectories(configDir, util.RootPath, util.CwdPath); err != nil {
+		slog.Warn("Failed to load themes from directories", "error", err)
+	}
+	if themeEnv := os.Getenv("OPENCODE_THEME"); themeEnv != "" {
+		theme.SetTheme(themeEnv)
+	}
+
+	return &App{
+		Project:          opencode.Project{Worktree: util.RootPath},
+		Agents:           []opencode.Agent{{Name: "build", Mode: opencode.AgentModePrimary}},
+		Version:          version,
+		Config:           configInfo,
+		State:            NewState(),
+		Client:           backend.OfflineClient(),
+		Backend:          backend.NewOffline(),
+		Session:          &opencode.Session{},
+		Messages:         []Message{},
+		Commands:         commands.LoadFromConfig(configInfo, nil),
+		PermissionPolicy: &policy.Policy{},
+	}
+}
+
 func (a *App) Keybind(commandName commands.CommandName) string {
 	command := a.Commands[commandName]
 	if len(command.Keybindings) == 0 {
@@ -258,12 +345,30 @@ func (a *App) Key(commandName commands.CommandName) string {
 
 func SetClipboard(text string) tea.Cmd {
 	var cmds []tea.Cmd
-	cmds = append(cmds, func() tea.Msg {
-		clipboard.Write(clipboard.FmtText, []byte(text))
-		return nil
-	})
-	// try to set the clipboard using OSC52 for terminals that support it
-	cmds = append(cmds, tea.SetClipboard(text))
+	active := clipboard.Active()
+	if active == clipboard.BackendSystem {
+		cmds = append(cmds, func() tea.Msg {
+			clipboard.Write(clipboard.FmtText, []byte(text))
+			return nil
+		})
+		if clipboard.Forced() {
+			return tea.Sequence(cmds...)
+		}
+	}
+	// try to set the clipboard using OSC52 for terminals that support it,
+	// which is the only way to reach the local clipboard over SSH
+	sequence, err := clipboard.OSC52Write([]byte(text))
+	if err != nil {
+		if active == clipboard.BackendOSC52 {
+			return toast.NewErrorToast(
+				"Text is too large for the terminal clipboard",
+				toast.WithTitle("Copy failed"),
+			)
+		}
+		slog.Debug("Skipping OSC52 clipboard", "error", err)
+		return tea.Sequence(cmds...)
+	}
+	cmds = append(cmds, tea.Raw(sequence))
 	return tea.Sequence(cmds...)
 }
 
@@ -286,7 +391,7 @@ func (a *App) cycleMode(forward bool) (*App, tea.Cmd) {
 	modelID := a.Agent().Model.ModelID
 	providerID := a.Agent().Model.ProviderID
 	if modelID == "" {
-		if model, ok := a.State.AgentModel[a.Agent().Name]; ok {
+		if model, ok := a.State.Project(a.Project.Worktree).AgentModel[a.Agent().Name]; ok {
 			modelID = model.ModelID
 			providerID = model.ProviderID
 		}
@@ -307,7 +412,7 @@ func (a *App) cycleMode(forward bool) (*App, tea.Cmd) {
 		}
 	}
 
-	a.State.Agent = a.Agent().Name
+	a.State.SetAgent(a.Project.Worktree, a.Agent().Name)
 	a.State.UpdateAgentUsage(a.Agent().Name)
 	return a, a.SaveState()
 }
@@ -351,10 +456,10 @@ func (a *App) cycleRecentModel(forward bool) (*App, tea.Cmd) {
 		)
 		if provider != nil && model != nil {
 			a.Provider, a.Model = provider, model
-			a.State.AgentModel[a.Agent().Name] = AgentModel{
+			a.State.SetAgentModel(a.Project.Worktree, a.Agent().Name, AgentModel{
 				ProviderID: provider.ID,
 				ModelID:    model.ID,
-			}
+			})
 			return a, tea.Sequence(
 				a.SaveState(),
 				toast.NewSuccessToast(
@@ -398,7 +503,7 @@ func (a *App) SwitchToAgent(agentName string) (*App, tea.Cmd) {
 	modelID := a.Agent().Model.ModelID
 	providerID := a.Agent().Model.ProviderID
 	if modelID == "" {
-		if model, ok := a.State.AgentModel[a.Agent().Name]; ok {
+		if model, ok := a.State.Project(a.Project.Worktree).AgentModel[a.Agent().Name]; ok {
 			modelID = model.ModelID
 			providerID = model.ProviderID
 		}
@@ -419,7 +524,7 @@ func (a *App) SwitchToAgent(agentName string) (*App, tea.Cmd) {
 		}
 	}
 
-	a.State.Agent = a.Agent().Name
+	a.State.SetAgent(a.Project.Worktree, a.Agent().Name)
 	a.State.UpdateAgentUsage(agentName)
 	return a, a.SaveState()
 }
@@ -474,23 +579,39 @@ func findProviderByID(providers []opencode.Provider, providerID string) *opencod
 	return nil
 }
 
-func (a *App) InitializeProvider() tea.Cmd {
-	providersResponse, err := a.Client.App.Providers(context.Background(), opencode.AppProvidersParams{})
+// vimMode reports whether the tui config enables Vim keybindings. The SDK
+// doesn't declare the vim field yet, so it's read from the raw config.
+func vimMode(tui opencode.ConfigTui) bool {
+	var raw struct {
+		Vim bool `json:"vim"`
+	}
+	if err := json.Unmarshal([]byte(tui.JSON.RawJSON()), &raw); err != nil {
+		return false
+	}
+	return raw.Vim
+}
+
+// SelectInitialModel loads the configured providers and picks the model to
+// start with, in order of the --model flag, config, agent, recent usage and
+// state. It returns nils if no model could be selected.
+func (a *App) SelectInitialModel() (*opencode.Provider, *opencode.Model) {
+	providersResponse, err := a.Backend.Providers(context.Background())
 	if err != nil {
 		slog.Error("Failed to list providers", "error", err)
 		// TODO: notify user
-		return nil
+		return nil, nil
 	}
 	providers := providersResponse.Providers
 	if len(providers) == 0 {
 		slog.Error("No providers configured")
-		return nil
+		return nil, nil
 	}
 
 	a.Providers = providers
 
 	// retains backwards compatibility with old state format
-	if model, ok := a.State.AgentModel[a.State.Agent]; ok {
+	choices := a.State.Project(a.Project.Worktree)
+	if model, ok := choices.AgentModel[choices.Agent]; ok {
 		a.State.Provider = model.ProviderID
 		a.State.Model = model.ModelID
 	}
@@ -616,6 +737,14 @@ func (a *App) InitializeProvider() tea.Cmd {
 	// Final safety check
 	if selectedProvider == nil || selectedModel == nil {
 		slog.Error("Failed to select any model")
+		return nil, nil
+	}
+	return selectedProvider, selectedModel
+}
+
+func (a *App) InitializeProvider() tea.Cmd {
+	selectedProvider, selectedModel := a.SelectInitialModel()
+	if selectedProvider == nil || selectedModel == nil {
 		return nil
 	}
 
@@ -698,8 +827,9 @@ func (a *App) HasAnimatingWork() bool {
 }
 
 func (a *App) SaveState() tea.Cmd {
+	state := a.State.Snapshot()
 	return func() tea.Msg {
-		err := SaveState(a.StatePath, a.State)
+		err := SaveState(a.StatePath, state)
 		if err != nil {
 			slog.Error("Failed to save state", "error", err)
 		}
@@ -720,7 +850,7 @@ func (a *App) InitializeProject(ctx context.Context) tea.Cmd {
 	cmds = append(cmds, util.CmdHandler(SessionCreatedMsg{Session: session}))
 
 	go func() {
-		_, err := a.Client.Session.Init(ctx, a.Session.ID, opencode.SessionInitParams{
+		_, err := a.Backend.Session().Init(ctx, a.Session.ID, opencode.SessionInitParams{
 			MessageID:  opencode.F(id.Ascending(id.Message)),
 			ProviderID: opencode.F(a.Provider.ID),
 			ModelID:    opencode.F(a.Model.ID),
@@ -747,7 +877,7 @@ func (a *App) CompactSession(ctx context.Context) tea.Cmd {
 			a.compactCancel = nil
 		}()
 
-		_, err := a.Client.Session.Summarize(
+		_, err := a.Backend.Session().Summarize(
 			compactCtx,
 			a.Session.ID,
 			opencode.SessionSummarizeParams{
@@ -777,7 +907,7 @@ func (a *App) MarkProjectInitialized(ctx context.Context) error {
 }
 
 func (a *App) CreateSession(ctx context.Context) (*opencode.Session, error) {
-	session, err := a.Client.Session.New(ctx, opencode.SessionNewParams{})
+	session, err := a.Backend.Session().New(ctx, opencode.SessionNewParams{})
 	if err != nil {
 		return nil, err
 	}
@@ -801,7 +931,7 @@ func (a *App) SendPrompt(ctx context.Context, prompt Prompt) (*App, tea.Cmd) {
 	a.Messages = append(a.Messages, message)
 
 	cmds = append(cmds, func() tea.Msg {
-		_, err := a.Client.Session.Prompt(ctx, a.Session.ID, opencode.SessionPromptParams{
+		_, err := a.Backend.Session().Prompt(ctx, a.Session.ID, opencode.SessionPromptParams{
 			Model: opencode.F(opencode.SessionPromptParamsModel{
 				ProviderID: opencode.F(a.Provider.ID),
 				ModelID:    opencode.F(a.Model.ID),
@@ -845,7 +975,7 @@ func (a *App) SendCommand(ctx context.Context, command string, args string) (*Ap
 		if a.Provider != nil && a.Model != nil {
 			params.Model = opencode.F(a.Provider.ID + "/" + a.Model.ID)
 		}
-		_, err := a.Client.Session.Command(
+		_, err := a.Backend.Session().Command(
 			context.Background(),
 			a.Session.ID,
 			params,
@@ -876,7 +1006,7 @@ func (a *App) SendShell(ctx context.Context, command string) (*App, tea.Cmd) {
 	}
 
 	cmds = append(cmds, func() tea.Msg {
-		_, err := a.Client.Session.Shell(
+		_, err := a.Backend.Session().Shell(
 			context.Background(),
 			a.Session.ID,
 			opencode.SessionShellParams{
@@ -905,7 +1035,7 @@ func (a *App) Cancel(ctx context.Context, sessionID string) error {
 		a.compactCancel = nil
 	}
 
-	_, err := a.Client.Session.Abort(ctx, sessionID, opencode.SessionAbortParams{})
+	_, err := a.Backend.Session().Abort(ctx, sessionID, opencode.SessionAbortParams{})
 	if err != nil {
 		slog.Error("Failed to cancel session", "error", err)
 		return err
@@ -914,7 +1044,7 @@ func (a *App) Cancel(ctx context.Context, sessionID string) error {
 }
 
 func (a *App) ListSessions(ctx context.Context) ([]opencode.Session, error) {
-	response, err := a.Client.Session.List(ctx, opencode.SessionListParams{})
+	response, err := a.Backend.Session().List(ctx, opencode.SessionListParams{})
 	if err != nil {
 		return nil, err
 	}
@@ -926,7 +1056,7 @@ func (a *App) ListSessions(ctx context.Context) ([]opencode.Session, error) {
 }
 
 func (a *App) DeleteSession(ctx context.Context, sessionID string) error {
-	_, err := a.Client.Session.Delete(ctx, sessionID, opencode.SessionDeleteParams{})
+	_, err := a.Backend.Session().Delete(ctx, sessionID, opencode.SessionDeleteParams{})
 	if err != nil {
 		slog.Error("Failed to delete session", "error", err)
 		return err
@@ -935,7 +1065,7 @@ func (a *App) DeleteSession(ctx context.Context, sessionID string) error {
 }
 
 func (a *App) UpdateSession(ctx context.Context, sessionID string, title string) error {
-	_, err := a.Client.Session.Update(ctx, sessionID, opencode.SessionUpdateParams{
+	_, err := a.Backend.Session().Update(ctx, sessionID, opencode.SessionUpdateParams{
 		Title: opencode.F(title),
 	})
 	if err != nil {
@@ -946,7 +1076,7 @@ func (a *App) UpdateSession(ctx context.Context, sessionID string, title string)
 }
 
 func (a *App) ListMessages(ctx context.Context, sessionId string) ([]Message, error) {
-	response, err := a.Client.Session.Messages(ctx, sessionId, opencode.SessionMessagesParams{})
+	response, err := a.Backend.Session().Messages(ctx, sessionId, opencode.SessionMessagesParams{})
 	if err != nil {
 		return nil, err
 	}
@@ -968,7 +1098,7 @@ func (a *App) ListMessages(ctx context.Context, sessionId string) ([]Message, er
 }
 
 func (a *App) ListProviders(ctx context.Context) ([]opencode.Provider, error) {
-	response, err := a.Client.App.Providers(ctx, opencode.AppProvidersParams{})
+	response, err := a.Backend.Providers(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -980,253 +1110,6 @@ func (a *App) ListProviders(ctx context.Context) ([]opencode.Provider, error) {
 	return providers.Providers, nil
 }
 
-// SSE Event Stream Handling
-// =============================
-
-// StartEventStream starts the server-sent events stream for real-time updates
-func (a *App) StartEventStream(ctx context.Context) error {
-	if a.eventStream != nil {
-		slog.Warn("Event stream already started, skipping")
-		return fmt.Errorf("event stream already started")
-	}
-
-	baseURL := os.Getenv("OPENCODE_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://api.opencode.dev" // default
-	}
-	slog.Info("🚀 Starting SSE event stream", "server_url", baseURL)
-
-	// Create a cancellable context for the event stream
-	streamCtx, cancel := context.WithCancel(ctx)
-	a.eventStreamCancel = cancel
-
-	// Start the event stream
-	slog.Info("📡 Connecting to SSE endpoint...")
-	stream := a.Client.Event.ListStreaming(streamCtx)
-	a.eventStream = stream
-
-	// Create a channel for events
-	a.eventChan = make(chan opencode.EventListResponse, 100)
-	slog.Info("📬 Created event channel with buffer size 100")
-
-	// Start goroutine to handle events
-	go a.handleEventStream(streamCtx)
-
-	slog.Info("✅ SSE event stream initialization complete")
-	return nil
-}
-
-// StopEventStream stops the server-sent events stream
-func (a *App) StopEventStream() {
-	if a.eventStreamCancel != nil {
-		slog.Info("Stopping SSE event stream")
-		a.eventStreamCancel()
-		a.eventStreamCancel = nil
-	}
-
-	if a.eventStream != nil {
-		a.eventStream.Close()
-		a.eventStream = nil
-	}
-
-	if a.eventChan != nil {
-		close(a.eventChan)
-		a.eventChan = nil
-	}
-}
-
-// handleEventStream processes incoming SSE events
-func (a *App) handleEventStream(ctx context.Context) {
-	defer func() {
-		if r := recover(); r != nil {
-			slog.Error("❌ Event stream panic", "panic", r)
-		}
-	}()
-
-	slog.Info("🔄 Starting event stream processing loop")
-
-	eventCount := 0
-	for a.eventStream.Next() {
-		eventCount++
-		event := a.eventStream.Current()
-
-		slog.Info("📨 SSE Event Received",
-			"count", eventCount,
-			"type", event.Type,
-			"has_properties", event.Properties != nil)
-
-		select {
-		case <-ctx.Done():
-			slog.Info("🛑 Event stream context cancelled")
-			return
-		default:
-			select {
-			case a.eventChan <- event:
-				slog.Info("✅ Event queued successfully", "type", event.Type)
-			case <-ctx.Done():
-				slog.Info("🛑 Event stream context cancelled while queuing")
-				return
-			default:
-				// Channel is full, skip this event
-				slog.Warn("⚠️  Event channel full, dropping event", "type", event.Type)
-			}
-		}
-	}
-
-	if err := a.eventStream.Err(); err != nil {
-		slog.Error("❌ Event stream error", "error", err)
-	} else {
-		slog.Info("🏁 Event stream ended normally", "total_events", eventCount)
-	}
-}
-
-// GetNextEvent returns the next event from the event stream (non-blocking)
-func (a *App) GetNextEvent() (*opencode.EventListResponse, bool) {
-	if a.eventChan == nil {
-		return nil, false
-	}
-
-	select {
-	case event := <-a.eventChan:
-		return &event, true
-	default:
-		return nil, false
-	}
-}
-
-// ProcessPendingEvents processes all pending events and returns appropriate tea.Cmd
-func (a *App) ProcessPendingEvents() tea.Cmd {
-	return func() tea.Msg {
-		processed := 0
-		for {
-			event, ok := a.GetNextEvent()
-			if !ok {
-				break
-			}
-
-			processed++
-			slog.Info("🎯 Processing pending event", "count", processed, "type", event.Type)
-
-			// Process the event
-			if err := a.processEvent(event); err != nil {
-				slog.Error("❌ Failed to process event", "error", err, "type", event.Type)
-			}
-		}
-
-		if processed > 0 {
-			slog.Info("✅ Processed batch of events", "count", processed)
-		}
-
-		return nil
-	}
-}
-
-// processEvent handles individual SSE events
-func (a *App) processEvent(event *opencode.EventListResponse) error {
-	if event == nil {
-		slog.Warn("⚠️  Received nil event")
-		return nil
-	}
-
-	slog.Info("🔍 Processing SSE event", "type", event.Type, "properties_type", fmt.Sprintf("%T", event.Properties))
-
-	// Handle different event types based on the EventListResponse structure
-	switch event.Type {
-	case opencode.EventListResponseTypeSessionUpdated:
-		slog.Info("📝 Handling session update event")
-		return a.handleSessionUpdate(event)
-	case opencode.EventListResponseTypeMessageUpdated:
-		slog.Info("💬 Handling message update event")
-		return a.handleMessageUpdate(event)
-	case opencode.EventListResponseTypeSessionError:
-		slog.Info("❌ Handling session error event")
-		return a.handleSessionError(event)
-	case opencode.EventListResponseTypePermissionUpdated:
-		slog.Info("🔐 Handling permission update event")
-		return a.handlePermissionUpdate(event)
-	default:
-		slog.Info("❓ Unhandled event type", "type", event.Type, "raw_type", string(event.Type))
-	}
-
-	return nil
-}
-
-// handleSessionUpdate handles session update events
-func (a *App) handleSessionUpdate(event *opencode.EventListResponse) error {
-	if props, ok := event.Properties.(opencode.EventListResponseEventSessionUpdatedProperties); ok {
-		slog.Info("📝 Session updated via SSE", "session_id", props.Info.ID, "title", props.Info.Title)
-		// Could update session data here if needed
-	} else {
-		slog.Warn("⚠️  Session update event has unexpected properties type", "actual_type", fmt.Sprintf("%T", event.Properties))
-	}
-	return nil
-}
-
-// handleMessageUpdate handles message update events
-func (a *App) handleMessageUpdate(event *opencode.EventListResponse) error {
-	if props, ok := event.Properties.(opencode.EventListResponseEventMessageUpdatedProperties); ok {
-		slog.Info("💬 Message updated via SSE", "message_id", props.Info.ID)
-		// Could update message data here if needed
-	} else {
-		slog.Warn("⚠️  Message update event has unexpected properties type", "actual_type", fmt.Sprintf("%T", event.Properties))
-	}
-	return nil
-}
-
-// handleSessionError handles session error events
-func (a *App) handleSessionError(event *opencode.EventListResponse) error {
-	if props, ok := event.Properties.(opencode.EventListResponseEventSessionErrorProperties); ok {
-		slog.Error("❌ Session error via SSE", "error", props.Error)
-		// Could dispatch a toast notification here
-	} else {
-		slog.Warn("⚠️  Session error event has unexpected properties type", "actual_type", fmt.Sprintf("%T", event.Properties))
-	}
-	return nil
-}
-
-// handlePermissionUpdate handles permission update events
-func (a *App) handlePermissionUpdate(event *opencode.EventListResponse) error {
-	if props, ok := event.Properties.(opencode.Permission); ok {
-		slog.Info("🔐 Permission updated via SSE", "permission_id", props.ID, "type", props.Type)
-		// Could update permission state here
-	} else {
-		slog.Warn("⚠️  Permission update event has unexpected properties type", "actual_type", fmt.Sprintf("%T", event.Properties))
-	}
-	return nil
-}
-
-// ShowSSEDebug returns a command that shows SSE debug information
-func (a *App) ShowSSEDebug() tea.Cmd {
-	return func() tea.Msg {
-		slog.Info("🔍 SSE Debug Information")
-		slog.Info("📊 SSE Status", "stream_active", a.eventStream != nil, "channel_active", a.eventChan != nil)
-
-		if a.eventStream != nil {
-			slog.Info("📡 Event stream is active")
-		} else {
-			slog.Warn("⚠️  Event stream is not active")
-		}
-
-		if a.eventChan != nil {
-			slog.Info("📬 Event channel is active", "buffer_size", cap(a.eventChan))
-		} else {
-			slog.Warn("⚠️  Event channel is not active")
-		}
-
-		// Check if we have any pending events
-		if a.eventChan != nil {
-			select {
-			case <-a.eventChan:
-				slog.Info("📨 Found pending event in channel")
-			default:
-				slog.Info("📭 No pending events in channel")
-			}
-		}
-
-		return nil
-	}
-}
-
 // Cleanup performs cleanup operations when the app is shutting down
 func (a *App) Cleanup() {
 	slog.Info("🧹 Cleaning up app resources")
@@ -1239,6 +1122,17 @@ func (a *App) Cleanup() {
 		a.compactCancel()
 		a.compactCancel = nil
 	}
+
+	if a.themeWatcher != nil {
+		a.themeWatcher.Close()
+	}
+
+	// Send buffered logs while the server is still reachable
+	if a.LogHandler != nil {
+		if err := a.LogHandler.Close(); err != nil {
+			slog.Warn("Failed to flush logs", "error", err, "file", a.LogHandler.LogFile())
+		}
+	}
 }
 
 // func (a *App) loadCustomKeybinds() {
@@ -1,9 +1,12 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/backend"
 )
 
 // TestFindModelByFullID tests the findModelByFullID function
@@ -226,3 +229,59 @@ func TestFindProviderByID(t *testing.T) {
 		})
 	}
 }
+
+func TestVimMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected bool
+	}{
+		{name: "enabled", config: `{"tui":{"scroll_speed":2,"vim":true}}`, expected: true},
+		{name: "disabled", config: `{"tui":{"scroll_speed":2,"vim":false}}`, expected: false},
+		{name: "unset", config: `{"tui":{"scroll_speed":2}}`, expected: false},
+		{name: "no tui settings", config: `{}`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config opencode.Config
+			if err := json.Unmarshal([]byte(tt.config), &config); err != nil {
+				t.Fatalf("Failed to decode config: %v", err)
+			}
+			if got := vimMode(config.Tui); got != tt.expected {
+				t.Errorf("Expected vim mode %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSessionCallsGoThroughBackend(t *testing.T) {
+	ctx := context.Background()
+	fake := backend.NewFake()
+	fake.AddSession(opencode.Session{ID: "ses_1", Title: "first"})
+	fake.AddMessages("ses_1", opencode.SessionMessagesResponse{
+		Info:  opencode.Message{ID: "msg_1", Role: opencode.MessageRoleUser},
+		Parts: []opencode.Part{{ID: "prt_1", Type: opencode.PartTypeText, Text: "hello"}},
+	})
+	a := &App{Backend: fake}
+
+	sessions, err := a.ListSessions(ctx)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("ListSessions() = %v, %v", sessions, err)
+	}
+	if err := a.UpdateSession(ctx, "ses_1", "renamed"); err != nil {
+		t.Fatal(err)
+	}
+	messages, err := a.ListMessages(ctx, "ses_1")
+	if err != nil || len(messages) != 1 || len(messages[0].Parts) != 1 {
+		t.Fatalf("ListMessages() = %v, %v", messages, err)
+	}
+	if err := a.DeleteSession(ctx, "ses_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 4 || calls[1].Method != "Session.Update" || calls[1].SessionID != "ses_1" {
+		t.Errorf("unexpected calls %+v", calls)
+	}
+}
@@ -0,0 +1,454 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/eventqueue"
+)
+
+// SSE Event Stream Handling
+// =============================
+
+const (
+	streamBackoffMin = 500 * time.Millisecond
+	streamBackoffMax = 30 * time.Second
+
+	eventQueueSize  = 256
+	eventBatchLimit = 64
+)
+
+// EventBatchMsg carries events from the stream to the TUI in arrival order.
+// Watch is set when the batch came from WaitForEvents, which must then be
+// re-issued.
+type EventBatchMsg struct {
+	Events []opencode.EventListResponse
+	Watch  bool
+}
+
+type EventStreamState int
+
+const (
+	EventStreamConnecting EventStreamState = iota
+	EventStreamConnected
+	EventStreamReconnecting
+	EventStreamStopped
+)
+
+func (s EventStreamState) String() string {
+	switch s {
+	case EventStreamConnecting:
+		return "connecting"
+	case EventStreamConnected:
+		return "connected"
+	case EventStreamReconnecting:
+		return "reconnecting"
+	case EventStreamStopped:
+		return "stopped"
+	}
+	return "unknown"
+}
+
+// EventStreamStatusMsg reports a change in the event stream connection.
+type EventStreamStatusMsg struct {
+	State   EventStreamState
+	Attempt int
+	RetryAt time.Time
+	Err     error
+}
+
+// StartEventStream starts the server-sent events stream for real-time updates.
+// The stream is supervised: when it drops it is reopened with exponential
+// backoff and resumed from the last seen event ID.
+func (a *App) StartEventStream(ctx context.Context) error {
+	if a.eventStreamCancel != nil {
+		slog.Warn("Event stream already started, skipping")
+		return fmt.Errorf("event stream already started")
+	}
+
+	// The stream goes through a.Backend, so it uses the same connection
+	// profile as every other request
+	slog.Info("🚀 Starting SSE event stream", "server_url", a.Connection.String())
+
+	// Create a cancellable context for the event stream
+	streamCtx, cancel := context.WithCancel(ctx)
+	a.eventStreamCancel = cancel
+	a.eventStreamDone = make(chan struct{})
+
+	// Create a queue for events
+	a.events = eventqueue.New(eventQueueSize, eventKey)
+	slog.Info("📬 Created event queue", "capacity", eventQueueSize)
+
+	if a.streamStatusChan == nil {
+		a.streamStatusChan = make(chan EventStreamStatusMsg, 1)
+	}
+
+	// Start goroutine to supervise the stream
+	go a.superviseEventStream(streamCtx, a.eventStreamDone)
+
+	slog.Info("✅ SSE event stream initialization complete")
+	return nil
+}
+
+// StopEventStream stops the server-sent events stream
+func (a *App) StopEventStream() {
+	if a.eventStreamCancel != nil {
+		slog.Info("Stopping SSE event stream")
+		a.eventStreamCancel()
+		a.eventStreamCancel = nil
+	}
+
+	// Wait for the supervisor so nothing is queued after the queue closes
+	if a.eventStreamDone != nil {
+		<-a.eventStreamDone
+		a.eventStreamDone = nil
+	}
+
+	if a.events != nil {
+		a.events.Close()
+	}
+}
+
+// eventKey identifies the entity an event updates so that successive updates
+// still waiting in the queue collapse into the latest one. Message updates
+// are not collapsed: the latest one would move behind the parts of the
+// message, which are ignored until the message has arrived.
+func eventKey(event opencode.EventListResponse) string {
+	switch evt := event.AsUnion().(type) {
+	case opencode.EventListResponseEventMessagePartUpdated:
+		return "part:" + evt.Properties.Part.ID
+	case opencode.EventListResponseEventSessionUpdated:
+		return "session:" + evt.Properties.Info.ID
+	}
+	return ""
+}
+
+// superviseEventStream keeps an event stream open until ctx is cancelled.
+func (a *App) superviseEventStream(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	defer a.setStreamStatus(EventStreamStatusMsg{State: EventStreamStopped})
+
+	attempt := 0
+	for {
+		a.streamMu.Lock()
+		lastEventID := a.lastEventID
+		a.streamMu.Unlock()
+
+		slog.Info("📡 Connecting to SSE endpoint...", "attempt", attempt, "last_event_id", lastEventID)
+		if attempt == 0 {
+			a.setStreamStatus(EventStreamStatusMsg{State: EventStreamConnecting})
+		}
+		stream := a.Backend.Events(ctx, lastEventID)
+		a.streamMu.Lock()
+		a.eventStream = stream
+		a.streamMu.Unlock()
+
+		received := a.handleEventStream(ctx)
+		err := stream.Err()
+		stream.Close()
+
+		a.streamMu.Lock()
+		a.eventStream = nil
+		a.streamMu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+		if received > 0 {
+			attempt = 0
+		}
+
+		delay := streamBackoff(attempt, stream.Retry())
+		attempt++
+		slog.Warn("⚠️  Event stream disconnected, reconnecting", "error", err, "attempt", attempt, "delay", delay)
+		a.setStreamStatus(EventStreamStatusMsg{
+			State:   EventStreamReconnecting,
+			Attempt: attempt,
+			RetryAt: time.Now().Add(delay),
+			Err:     err,
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// streamBackoff returns the delay before reconnect attempt n. A retry hint
+// from the server raises the floor; jitter of up to 25% either way keeps many
+// clients from reconnecting in lockstep after a server restart.
+func streamBackoff(attempt int, hint time.Duration) time.Duration {
+	delay := max(streamBackoffMin, hint)
+	for range attempt {
+		delay *= 2
+		if delay >= streamBackoffMax {
+			delay = streamBackoffMax
+			break
+		}
+	}
+	jitter := time.Duration(rand.Int64N(int64(delay)/2+1)) - delay/4
+	return delay + jitter
+}
+
+// handleEventStream processes incoming SSE events from the current stream and
+// returns how many were received before it ended.
+func (a *App) handleEventStream(ctx context.Context) (eventCount int) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("❌ Event stream panic", "panic", r)
+		}
+	}()
+
+	slog.Info("🔄 Starting event stream processing loop")
+
+	a.streamMu.Lock()
+	stream := a.eventStream
+	a.streamMu.Unlock()
+
+	for stream.Next() {
+		if eventCount == 0 {
+			a.setStreamStatus(EventStreamStatusMsg{State: EventStreamConnected})
+		}
+		eventCount++
+		event := stream.Current()
+		if id := stream.LastEventID(); id != "" {
+			a.streamMu.Lock()
+			a.lastEventID = id
+			a.streamMu.Unlock()
+		}
+
+		slog.Info("📨 SSE Event Received",
+			"count", eventCount,
+			"type", event.Type,
+			"has_properties", event.Properties != nil)
+
+		// Blocks while the queue is full, which in turn stops reading from
+		// the stream until the TUI catches up
+		if err := a.events.Push(ctx, event); err != nil {
+			slog.Info("🛑 Event stream stopped while queuing", "error", err)
+			return
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		slog.Error("❌ Event stream error", "error", err)
+	} else {
+		slog.Info("🏁 Event stream ended normally", "total_events", eventCount)
+	}
+	return
+}
+
+func (a *App) setStreamStatus(status EventStreamStatusMsg) {
+	a.streamMu.Lock()
+	a.streamStatus = status
+	a.streamMu.Unlock()
+
+	// Keep only the latest status for the watcher
+	select {
+	case <-a.streamStatusChan:
+	default:
+	}
+	select {
+	case a.streamStatusChan <- status:
+	default:
+	}
+}
+
+// EventStreamStatus returns the current state of the event stream connection.
+func (a *App) EventStreamStatus() EventStreamStatusMsg {
+	a.streamMu.Lock()
+	defer a.streamMu.Unlock()
+	return a.streamStatus
+}
+
+// WatchEventStream returns a command that waits for the next change in the
+// event stream connection. Re-issue it after each EventStreamStatusMsg.
+func (a *App) WatchEventStream() tea.Cmd {
+	if a.streamStatusChan == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-a.streamStatusChan
+	}
+}
+
+// WaitForEvents returns a command that blocks until events arrive and
+// delivers them as an EventBatchMsg. Re-issue it after each watched batch.
+func (a *App) WaitForEvents() tea.Cmd {
+	events := a.events
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		batch, err := events.PopBatch(context.Background(), eventBatchLimit)
+		if err != nil {
+			return nil
+		}
+		return EventBatchMsg{Events: batch, Watch: true}
+	}
+}
+
+// ProcessPendingEvents delivers whatever events are already queued without
+// waiting for more.
+func (a *App) ProcessPendingEvents() tea.Cmd {
+	events := a.events
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		batch := events.Drain(eventBatchLimit)
+		if len(batch) == 0 {
+			return nil
+		}
+		return EventBatchMsg{Events: batch}
+	}
+}
+
+// ProcessEvents logs each event in batch and returns them as messages for
+// the TUI to handle in order.
+func (a *App) ProcessEvents(batch []opencode.EventListResponse) []tea.Msg {
+	msgs := make([]tea.Msg, 0, len(batch))
+	for i := range batch {
+		event := &batch[i]
+		if err := a.processEvent(event); err != nil {
+			slog.Error("❌ Failed to process event", "error", err, "type", event.Type)
+		}
+		msgs = append(msgs, event.AsUnion())
+	}
+	return msgs
+}
+
+// processEvent handles individual SSE events
+func (a *App) processEvent(event *opencode.EventListResponse) error {
+	if event == nil {
+		slog.Warn("⚠️  Received nil event")
+		return nil
+	}
+
+	slog.Info("🔍 Processing SSE event", "type", event.Type, "properties_type", fmt.Sprintf("%T", event.Properties))
+
+	// Handle different event types based on the EventListResponse structure
+	switch event.Type {
+	case opencode.EventListResponseTypeSessionUpdated:
+		slog.Info("📝 Handling session update event")
+		return a.handleSessionUpdate(event)
+	case opencode.EventListResponseTypeMessageUpdated:
+		slog.Info("💬 Handling message update event")
+		return a.handleMessageUpdate(event)
+	case opencode.EventListResponseTypeSessionError:
+		slog.Info("❌ Handling session error event")
+		return a.handleSessionError(event)
+	case opencode.EventListResponseTypePermissionUpdated:
+		slog.Info("🔐 Handling permission update event")
+		return a.handlePermissionUpdate(event)
+	default:
+		slog.Info("❓ Unhandled event type", "type", event.Type, "raw_type", string(event.Type))
+	}
+
+	return nil
+}
+
+// handleSessionUpdate handles session update events
+func (a *App) handleSessionUpdate(event *opencode.EventListResponse) error {
+	if props, ok := event.Properties.(opencode.EventListResponseEventSessionUpdatedProperties); ok {
+		slog.Info("📝 Session updated via SSE", "session_id", props.Info.ID, "title", props.Info.Title)
+		// Could update session data here if needed
+	} else {
+		slog.Warn("⚠️  Session update event has unexpected properties type", "actual_type", fmt.Sprintf("%T", event.Properties))
+	}
+	return nil
+}
+
+// handleMessageUpdate handles message update events
+func (a *App) handleMessageUpdate(event *opencode.EventListResponse) error {
+	if props, ok := event.Properties.(opencode.EventListResponseEventMessageUpdatedProperties); ok {
+		slog.Info("💬 Message updated via SSE", "message_id", props.Info.ID)
+		// Could update message data here if needed
+	} else {
+		slog.Warn("⚠️  Message update event has unexpected properties type", "actual_type", fmt.Sprintf("%T", event.Properties))
+	}
+	return nil
+}
+
+// handleSessionError handles session error events
+func (a *App) handleSessionError(event *opencode.EventListResponse) error {
+	if props, ok := event.Properties.(opencode.EventListResponseEventSessionErrorProperties); ok {
+		slog.Error("❌ Session error via SSE", "error", props.Error)
+		// Could dispatch a toast notification here
+	} else {
+		slog.Warn("⚠️  Session error event has unexpected properties type", "actual_type", fmt.Sprintf("%T", event.Properties))
+	}
+	return nil
+}
+
+// handlePermissionUpdate handles permission update events
+func (a *App) handlePermissionUpdate(event *opencode.EventListResponse) error {
+	if props, ok := event.Properties.(opencode.Permission); ok {
+		slog.Info("🔐 Permission updated via SSE", "permission_id", props.ID, "type", props.Type)
+		// Could update permission state here
+	} else {
+		slog.Warn("⚠️  Permission update event has unexpected properties type", "actual_type", fmt.Sprintf("%T", event.Properties))
+	}
+	return nil
+}
+
+// ShowSSEDebug returns a command that shows SSE debug information
+func (a *App) ShowSSEDebug() tea.Cmd {
+	return func() tea.Msg {
+		a.streamMu.Lock()
+		streamActive := a.eventStream != nil
+		status := a.streamStatus
+		lastEventID := a.lastEventID
+		a.streamMu.Unlock()
+
+		slog.Info("🔍 SSE Debug Information")
+		slog.Info("📊 SSE Status",
+			"stream_active", streamActive,
+			"queue_active", a.events != nil,
+			"state", status.State.String(),
+			"attempt", status.Attempt,
+			"last_event_id", lastEventID)
+
+		if streamActive {
+			slog.Info("📡 Event stream is active")
+		} else {
+			slog.Warn("⚠️  Event stream is not active")
+		}
+
+		if a.events == nil {
+			slog.Warn("⚠️  Event queue is not active")
+			return toast.NewInfoToast("Event stream "+status.State.String(), toast.WithTitle("SSE debug"))()
+		}
+
+		stats := a.events.Stats()
+		slog.Info("📬 Event queue",
+			"received", stats.Received,
+			"coalesced", stats.Coalesced,
+			"dropped", stats.Dropped,
+			"delivered", stats.Delivered,
+			"pending", stats.Pending,
+			"capacity", stats.Capacity,
+			"high_water", stats.HighWater)
+
+		return toast.NewInfoToast(
+			fmt.Sprintf(
+				"%s · received %d · coalesced %d · dropped %d · pending %d/%d",
+				status.State,
+				stats.Received,
+				stats.Coalesced,
+				stats.Dropped,
+				stats.Pending,
+				stats.Capacity,
+			),
+			toast.WithTitle("SSE debug"),
+		)()
+	}
+}
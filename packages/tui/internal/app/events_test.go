@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/eventqueue"
+)
+
+func TestStreamBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		hint    time.Duration
+		base    time.Duration
+	}{
+		{name: "first attempt", attempt: 0, base: streamBackoffMin},
+		{name: "doubles per attempt", attempt: 3, base: 8 * streamBackoffMin},
+		{name: "capped", attempt: 20, base: streamBackoffMax},
+		{name: "server retry hint raises floor", attempt: 0, hint: 5 * time.Second, base: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 50 {
+				delay := streamBackoff(tt.attempt, tt.hint)
+				low, high := tt.base-tt.base/4, tt.base+tt.base/4
+				if delay < low || delay > high {
+					t.Fatalf("delay %s outside [%s, %s]", delay, low, high)
+				}
+			}
+		})
+	}
+}
+
+func TestEventQueueKeepsMessagesBeforeParts(t *testing.T) {
+	events := []string{
+		`{"type":"message.updated","properties":{"info":{"id":"msg_1","sessionID":"ses_1","role":"assistant"}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"prt_1","messageID":"msg_1","sessionID":"ses_1","type":"text","text":"hello"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"msg_1","sessionID":"ses_1","role":"assistant","time":{"created":1,"completed":2}}}}`,
+	}
+	queue := eventqueue.New(len(events), eventKey)
+	for _, data := range events {
+		var event opencode.EventListResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatal(err)
+		}
+		if err := queue.Push(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := &App{Session: &opencode.Session{ID: "ses_1"}, Messages: []Message{}}
+	for _, event := range queue.Drain(len(events)) {
+		switch evt := event.AsUnion().(type) {
+		case opencode.EventListResponseEventMessageUpdated:
+			a.UpdateMessage(evt.Properties.Info)
+		case opencode.EventListResponseEventMessagePartUpdated:
+			a.UpdatePart(evt.Properties.Part)
+		}
+	}
+	if len(a.Messages) != 1 || len(a.Messages[0].Parts) != 1 {
+		t.Fatalf("messages are %+v, want msg_1 with its part", a.Messages)
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"slices"
+
+	opencode "github.com/sst/opencode-api-go"
+)
+
+// ID returns the ID of the message regardless of its role.
+func (m Message) ID() string {
+	switch casted := m.Info.(type) {
+	case opencode.UserMessage:
+		return casted.ID
+	case opencode.AssistantMessage:
+		return casted.ID
+	}
+	return ""
+}
+
+// PartID returns the ID of a part regardless of its type.
+func PartID(part opencode.PartUnion) string {
+	switch casted := part.(type) {
+	case opencode.TextPart:
+		return casted.ID
+	case opencode.ReasoningPart:
+		return casted.ID
+	case opencode.FilePart:
+		return casted.ID
+	case opencode.ToolPart:
+		return casted.ID
+	case opencode.StepStartPart:
+		return casted.ID
+	case opencode.StepFinishPart:
+		return casted.ID
+	case opencode.SnapshotPart:
+		return casted.ID
+	case opencode.PartPatchPart:
+		return casted.ID
+	case opencode.AgentPart:
+		return casted.ID
+	}
+	return ""
+}
+
+func (a *App) messageIndex(messageID string) int {
+	return slices.IndexFunc(a.Messages, func(m Message) bool {
+		return m.ID() == messageID
+	})
+}
+
+// live reports whether server events for sessionID apply to what is shown.
+func (a *App) live(sessionID string) bool {
+	return a.Transcript == "" && sessionID == a.Session.ID
+}
+
+// UpsertMessage replaces the message with the same ID, keeping its parts, or
+// appends it.
+func (a *App) UpsertMessage(info opencode.MessageUnion) {
+	message := Message{Info: info, Parts: []opencode.PartUnion{}}
+	if index := a.messageIndex(message.ID()); index > -1 {
+		a.Messages[index] = Message{Info: info, Parts: a.Messages[index].Parts}
+		return
+	}
+	a.Messages = append(a.Messages, message)
+}
+
+// UpsertPart replaces the part with the same ID in messageID, or appends it.
+// Parts of messages that have not arrived yet are ignored, and parts without
+// an ID are always appended.
+func (a *App) UpsertPart(messageID string, part opencode.PartUnion) {
+	messageIndex := a.messageIndex(messageID)
+	if messageIndex == -1 {
+		return
+	}
+	message := a.Messages[messageIndex]
+	partID := PartID(part)
+	partIndex := -1
+	if partID != "" {
+		partIndex = slices.IndexFunc(message.Parts, func(p opencode.PartUnion) bool {
+			return PartID(p) == partID
+		})
+	}
+	if partIndex > -1 {
+		message.Parts[partIndex] = part
+	} else {
+		message.Parts = append(message.Parts, part)
+	}
+	a.Messages[messageIndex] = message
+}
+
+// UpdateMessage applies a message.updated event to the current session,
+// keeping the parts already received for it.
+func (a *App) UpdateMessage(info opencode.Message) {
+	if !a.live(info.SessionID) {
+		return
+	}
+	a.UpsertMessage(info.AsUnion())
+}
+
+// UpdatePart applies a message.part.updated event to the current session.
+func (a *App) UpdatePart(part opencode.Part) {
+	if !a.live(part.SessionID) {
+		return
+	}
+	a.UpsertPart(part.MessageID, part.AsUnion())
+}
+
+// RemovePart applies a message.part.removed event to the current session.
+func (a *App) RemovePart(sessionID, messageID, partID string) {
+	if !a.live(sessionID) || partID == "" {
+		return
+	}
+	messageIndex := a.messageIndex(messageID)
+	if messageIndex == -1 {
+		return
+	}
+	message := a.Messages[messageIndex]
+	partIndex := slices.IndexFunc(message.Parts, func(p opencode.PartUnion) bool {
+		return PartID(p) == partID
+	})
+	if partIndex > -1 {
+		message.Parts = append(message.Parts[:partIndex], message.Parts[partIndex+1:]...)
+		a.Messages[messageIndex] = message
+	}
+}
+
+// RemoveMessage applies a message.removed event to the current session.
+func (a *App) RemoveMessage(sessionID, messageID string) {
+	if !a.live(sessionID) {
+		return
+	}
+	if index := a.messageIndex(messageID); index > -1 {
+		a.Messages = append(a.Messages[:index], a.Messages[index+1:]...)
+	}
+}
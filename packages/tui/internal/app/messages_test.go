@@ -0,0 +1,47 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	opencode "github.com/sst/opencode-api-go"
+)
+
+func TestUpsertPart(t *testing.T) {
+	parts := []opencode.PartUnion{
+		opencode.TextPart{ID: "prt_text", Text: "draft"},
+		opencode.SnapshotPart{ID: "prt_snapshot", Snapshot: "a"},
+		opencode.PartPatchPart{ID: "prt_patch", Hash: "a"},
+		opencode.AgentPart{ID: "prt_agent", Name: "general"},
+		opencode.TextPart{Text: "no id"},
+	}
+	updates := []opencode.PartUnion{
+		opencode.TextPart{ID: "prt_text", Text: "final"},
+		opencode.SnapshotPart{ID: "prt_snapshot", Snapshot: "b"},
+		opencode.PartPatchPart{ID: "prt_patch", Hash: "b"},
+		opencode.AgentPart{ID: "prt_agent", Name: "build"},
+		opencode.TextPart{Text: "another without an id"},
+	}
+
+	a := &App{Messages: []Message{{Info: opencode.AssistantMessage{ID: "msg_1"}}}}
+	for _, part := range append(parts, updates...) {
+		a.UpsertPart("msg_1", part)
+	}
+	got := a.Messages[0].Parts
+	if len(got) != len(parts)+1 {
+		t.Fatalf("got %d parts, want %d: %+v", len(got), len(parts)+1, got)
+	}
+	for i, want := range updates[:4] {
+		if !reflect.DeepEqual(got[i], want) {
+			t.Errorf("part %d is %+v, want %+v", i, got[i], want)
+		}
+	}
+	if !reflect.DeepEqual(got[4:], []opencode.PartUnion{parts[4], updates[4]}) {
+		t.Errorf("parts without an ID were merged: %+v", got[4:])
+	}
+
+	a.UpsertPart("msg_missing", opencode.TextPart{ID: "prt_other"})
+	if len(a.Messages) != 1 {
+		t.Errorf("a part created message %+v", a.Messages[1:])
+	}
+}
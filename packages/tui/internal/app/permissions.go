@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/policy"
+)
+
+// QueuePermission adds a permission request to the end of the queue.
+func (a *App) QueuePermission(permission opencode.Permission) {
+	index := slices.IndexFunc(a.Permissions, func(p opencode.Permission) bool {
+		return p.ID == permission.ID
+	})
+	if index > -1 {
+		a.Permissions[index] = permission
+	} else {
+		a.Permissions = append(a.Permissions, permission)
+	}
+	a.CurrentPermission = a.Permissions[0]
+}
+
+// DequeuePermission removes a permission request from the queue, reporting
+// whether it was there. CurrentPermission moves on to the next request.
+func (a *App) DequeuePermission(permissionID string) bool {
+	index := slices.IndexFunc(a.Permissions, func(p opencode.Permission) bool {
+		return p.ID == permissionID
+	})
+	if index == -1 {
+		return false
+	}
+	a.Permissions = slices.Delete(a.Permissions, index, index+1)
+	if len(a.Permissions) > 0 {
+		a.CurrentPermission = a.Permissions[0]
+	} else {
+		a.CurrentPermission = opencode.Permission{}
+	}
+	return true
+}
+
+// RespondToPermissions removes the given requests from the queue and sends
+// the same response to each of them.
+func (a *App) RespondToPermissions(
+	permissions []opencode.Permission,
+	response opencode.SessionPermissionRespondParamsResponse,
+) tea.Cmd {
+	for _, permission := range permissions {
+		a.DequeuePermission(permission.ID)
+	}
+	return func() tea.Msg {
+		failed := 0
+		for _, permission := range permissions {
+			_, err := a.Client.Session.Permissions.Respond(
+				context.Background(),
+				permission.SessionID,
+				permission.ID,
+				opencode.SessionPermissionRespondParams{Response: opencode.F(response)},
+			)
+			if err != nil {
+				slog.Error("Failed to respond to permission request", "permission", permission.ID, "error", err)
+				failed++
+				continue
+			}
+			slog.Debug("Responded to permission request", "permission", permission.ID, "response", response)
+		}
+		if failed == 0 {
+			return nil
+		}
+		if len(permissions) == 1 {
+			return toast.NewErrorToast("Failed to respond to permission request")()
+		}
+		return toast.NewErrorToast(
+			fmt.Sprintf("Failed to respond to %d of %d permission requests", failed, len(permissions)),
+		)()
+	}
+}
+
+// PermissionScope describes what answering "always" to a permission request
+// allows without asking again.
+func PermissionScope(permission opencode.Permission) string {
+	if permission.Pattern != "" {
+		return fmt.Sprintf("every %s call matching %q in this session", permission.Type, permission.Pattern)
+	}
+	return fmt.Sprintf("every %s call in this session", permission.Type)
+}
+
+// ApplyPermissionPolicy answers a permission request from the local policy
+// when a rule allows or denies it, recording the decision in the audit log.
+// It reports false when the user has to be asked.
+func (a *App) ApplyPermissionPolicy(permission opencode.Permission) (tea.Cmd, bool) {
+	response, ok := a.PolicyResponse(permission)
+	if !ok {
+		return nil, false
+	}
+
+	cmd := a.RespondToPermissions([]opencode.Permission{permission}, response)
+	if response == opencode.SessionPermissionRespondParamsResponseReject {
+		cmd = tea.Batch(cmd, toast.NewInfoToast(
+			fmt.Sprintf("Denied %s: %s", permission.Type, permission.Title),
+			toast.WithTitle("Permission policy"),
+		))
+	}
+	return cmd, true
+}
+
+// PolicyResponse is the response the local policy gives to a permission
+// request, recorded in the audit log. It reports false when no rule allows or
+// denies the request.
+func (a *App) PolicyResponse(permission opencode.Permission) (opencode.SessionPermissionRespondParamsResponse, bool) {
+	req := PermissionRequest(permission, a.Project.Worktree)
+	action, rule := a.PermissionPolicy.Evaluate(req)
+	if action == policy.Ask {
+		return "", false
+	}
+
+	response := opencode.SessionPermissionRespondParamsResponseOnce
+	if action == policy.Deny {
+		response = opencode.SessionPermissionRespondParamsResponseReject
+	}
+	slog.Info("Answered permission request from policy",
+		"permission", permission.ID, "tool", permission.Type, "action", action, "rule", rule.Source())
+	if a.PermissionAudit != nil {
+		err := a.PermissionAudit.Record(policy.Decision{
+			SessionID:    permission.SessionID,
+			PermissionID: permission.ID,
+			Tool:         permission.Type,
+			Title:        permission.Title,
+			Command:      req.Command,
+			Path:         req.Path,
+			Action:       action,
+			Rule:         rule.Source(),
+		})
+		if err != nil {
+			slog.Error("Failed to record permission decision", "error", err)
+		}
+	}
+	return response, true
+}
+
+// PermissionRequest extracts what policy rules match on from a permission
+// request. File paths inside root are made relative to it, and relative paths
+// are cleaned.
+func PermissionRequest(permission opencode.Permission, root string) policy.Request {
+	req := policy.Request{Tool: permission.Type}
+	if command, ok := permission.Metadata["command"].(string); ok {
+		req.Command = command
+	} else if permission.Type == "bash" {
+		req.Command = permission.Pattern
+	}
+	for _, key := range []string{"filePath", "path"} {
+		if path, ok := permission.Metadata[key].(string); ok && path != "" {
+			req.Path = path
+			break
+		}
+	}
+	if req.Path == "" {
+		return req
+	}
+	// Cleaned so "src/../../etc" can't pass for a path under src. Relative
+	// paths that still lead out of the project are never allowed by a rule.
+	req.Path = filepath.Clean(req.Path)
+	if root != "" && filepath.IsAbs(req.Path) {
+		if rel, err := filepath.Rel(root, req.Path); err == nil && !policy.Escapes(filepath.ToSlash(rel)) {
+			req.Path = rel
+		}
+	}
+	req.Path = filepath.ToSlash(req.Path)
+	return req
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/policy"
+)
+
+func TestPermissionQueue(t *testing.T) {
+	a := &App{}
+	a.QueuePermission(opencode.Permission{ID: "per_1", Title: "ls"})
+	a.QueuePermission(opencode.Permission{ID: "per_2", Title: "rm"})
+	a.QueuePermission(opencode.Permission{ID: "per_1", Title: "ls -la"})
+
+	if len(a.Permissions) != 2 {
+		t.Fatalf("expected a repeated request to replace the queued one, got %d", len(a.Permissions))
+	}
+	if a.CurrentPermission.Title != "ls -la" {
+		t.Errorf("current permission = %+v", a.CurrentPermission)
+	}
+
+	if !a.DequeuePermission("per_1") || a.CurrentPermission.ID != "per_2" {
+		t.Errorf("expected the next request to become current, got %+v", a.CurrentPermission)
+	}
+	if a.DequeuePermission("per_1") {
+		t.Error("expected dequeuing an answered request to report false")
+	}
+	a.DequeuePermission("per_2")
+	if a.CurrentPermission.ID != "" || len(a.Permissions) != 0 {
+		t.Errorf("expected an empty queue, got %+v", a.Permissions)
+	}
+}
+
+func TestPermissionScope(t *testing.T) {
+	tests := []struct {
+		permission opencode.Permission
+		want       string
+	}{
+		{opencode.Permission{Type: "bash", Pattern: "git *"}, `every bash call matching "git *" in this session`},
+		{opencode.Permission{Type: "edit"}, "every edit call in this session"},
+	}
+	for _, tt := range tests {
+		if got := PermissionScope(tt.permission); got != tt.want {
+			t.Errorf("PermissionScope(%+v) = %q, want %q", tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestPermissionRequest(t *testing.T) {
+	tests := []struct {
+		permission opencode.Permission
+		want       policy.Request
+	}{
+		{
+			opencode.Permission{Type: "bash", Metadata: map[string]any{"command": "ls -la"}},
+			policy.Request{Tool: "bash", Command: "ls -la"},
+		},
+		{
+			opencode.Permission{Type: "bash", Pattern: "git status"},
+			policy.Request{Tool: "bash", Command: "git status"},
+		},
+		{
+			opencode.Permission{Type: "edit", Metadata: map[string]any{"filePath": "/project/src/main.go"}},
+			policy.Request{Tool: "edit", Path: "src/main.go"},
+		},
+		{
+			opencode.Permission{Type: "edit", Metadata: map[string]any{"filePath": "/etc/hosts"}},
+			policy.Request{Tool: "edit", Path: "/etc/hosts"},
+		},
+		{
+			opencode.Permission{Type: "edit", Metadata: map[string]any{"filePath": "/project/src/../../etc/hosts"}},
+			policy.Request{Tool: "edit", Path: "/etc/hosts"},
+		},
+		{
+			opencode.Permission{Type: "edit", Metadata: map[string]any{"filePath": "./src//main.go"}},
+			policy.Request{Tool: "edit", Path: "src/main.go"},
+		},
+		{
+			opencode.Permission{Type: "edit", Metadata: map[string]any{"filePath": "src/../../etc/hosts"}},
+			policy.Request{Tool: "edit", Path: "../etc/hosts"},
+		},
+	}
+	for _, tt := range tests {
+		if got := PermissionRequest(tt.permission, "/project"); got != tt.want {
+			t.Errorf("PermissionRequest(%+v) = %+v, want %+v", tt.permission, got, tt.want)
+		}
+	}
+}
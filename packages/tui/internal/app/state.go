@@ -1,13 +1,10 @@
 package app
 
 import (
-	"bufio"
-	"fmt"
-	"log/slog"
-	"os"
+	"maps"
+	"slices"
+	"strings"
 	"time"
-
-	"github.com/BurntSushi/toml"
 )
 
 type ModelUsage struct {
@@ -26,28 +23,119 @@ type AgentModel struct {
 	ModelID    string `toml:"model_id"`
 }
 
+// ProjectState holds the model and agent choices made in a project.
+type ProjectState struct {
+	AgentModel map[string]AgentModel `toml:"agent_model"`
+	Agent      string                `toml:"agent"`
+	// Draft is what was typed but not sent in a new session that has not
+	// been created yet.
+	Draft Prompt `toml:"draft,omitempty"`
+}
+
+const (
+	maxRecentModels = 50
+	maxRecentAgents = 20
+)
+
+// maxPromptHistory is the number of prompts kept per project.
+const maxPromptHistory = 50
+
+// HistoryEntry is a sent prompt and the project it was sent in.
+type HistoryEntry struct {
+	Prompt
+	// Project is the worktree of the project. It is empty for prompts saved
+	// before history was kept per project, which show in every project.
+	Project string `toml:"project,omitempty"`
+	// SentAt is zero for prompts saved before the state file was versioned.
+	SentAt time.Time `toml:"sent_at,omitempty"`
+}
+
 type State struct {
-	Theme              string                `toml:"theme"`
-	AgentModel         map[string]AgentModel `toml:"agent_model"`
-	Provider           string                `toml:"provider"`
-	Model              string                `toml:"model"`
-	Agent              string                `toml:"agent"`
-	RecentlyUsedModels []ModelUsage          `toml:"recently_used_models"`
-	RecentlyUsedAgents []AgentUsage          `toml:"recently_used_agents"`
-	MessageHistory     []Prompt              `toml:"message_history"`
-	ShowToolDetails    *bool                 `toml:"show_tool_details"`
-	ShowThinkingBlocks *bool                 `toml:"show_thinking_blocks"`
+	// Version is the format of the file the state was read from, see
+	// stateVersion
+	Version int    `toml:"version"`
+	Theme   string `toml:"theme"`
+	// AgentModel and Agent are the choices last made in any project, which
+	// a project starts from until choices are made in it
+	AgentModel map[string]AgentModel `toml:"agent_model"`
+	Provider   string                `toml:"provider"`
+	Model      string                `toml:"model"`
+	Agent      string                `toml:"agent"`
+	// Projects holds the choices made in each project, by worktree
+	Projects           map[string]*ProjectState `toml:"projects,omitempty"`
+	RecentlyUsedModels []ModelUsage             `toml:"recently_used_models"`
+	RecentlyUsedAgents []AgentUsage             `toml:"recently_used_agents"`
+	MessageHistory     []HistoryEntry           `toml:"message_history"`
+	ShowToolDetails    *bool                    `toml:"show_tool_details"`
+	ShowThinkingBlocks *bool                    `toml:"show_thinking_blocks"`
+	// Clipboard forces a clipboard backend: "auto", "system" or "osc52"
+	Clipboard string `toml:"clipboard,omitempty"`
+	// Drafts holds what was typed but not sent, by session ID. Drafts of new
+	// sessions are kept per project, see ProjectState.
+	Drafts map[string]Prompt `toml:"drafts,omitempty"`
+
+	// saved is shared by the snapshots of the state, see SaveState
+	saved *savedState
+	// seq orders the snapshots taken for saving
+	seq uint64
 }
 
 func NewState() *State {
-	return &State{
+	state := &State{
+		Version:            stateVersion,
 		Theme:              "opencode",
 		Agent:              "build",
 		AgentModel:         make(map[string]AgentModel),
 		RecentlyUsedModels: make([]ModelUsage, 0),
 		RecentlyUsedAgents: make([]AgentUsage, 0),
-		MessageHistory:     make([]Prompt, 0),
+		MessageHistory:     make([]HistoryEntry, 0),
+	}
+	state.saved = &savedState{state: state.clone()}
+	return state
+}
+
+// Project returns the choices made in a project, or those last made in any
+// project when none have been made in it.
+func (s *State) Project(worktree string) ProjectState {
+	if project, ok := s.Projects[worktree]; ok {
+		return *project
+	}
+	return ProjectState{AgentModel: s.AgentModel, Agent: s.Agent}
+}
+
+// SetAgent records the agent chosen in a project.
+func (s *State) SetAgent(worktree, agent string) {
+	s.project(worktree).Agent = agent
+	s.Agent = agent
+}
+
+// SetAgentModel records the model chosen for an agent in a project.
+func (s *State) SetAgentModel(worktree, agent string, model AgentModel) {
+	s.project(worktree).AgentModel[agent] = model
+	if s.AgentModel == nil {
+		s.AgentModel = make(map[string]AgentModel)
 	}
+	s.AgentModel[agent] = model
+}
+
+// project returns the choices of a project for changing them. A project
+// without choices of its own starts from those last made in any project.
+func (s *State) project(worktree string) *ProjectState {
+	if project, ok := s.Projects[worktree]; ok {
+		return project
+	}
+	project := &ProjectState{
+		AgentModel: maps.Clone(s.AgentModel),
+		Agent:      s.Agent,
+	}
+	if project.AgentModel == nil {
+		project.AgentModel = make(map[string]AgentModel)
+	}
+	if s.Projects == nil {
+		s.Projects = make(map[string]*ProjectState)
+	}
+	s.Projects[worktree] = project
+	return project
 }
 
 // UpdateModelUsage updates the recently used models list with the specified model
@@ -73,8 +161,8 @@ func (s *State) UpdateModelUsage(providerID, modelID string) {
 
 	// Prepend to slice and limit to last 50 entries
 	s.RecentlyUsedModels = append([]ModelUsage{newUsage}, s.RecentlyUsedModels...)
-	if len(s.RecentlyUsedModels) > 50 {
-		s.RecentlyUsedModels = s.RecentlyUsedModels[:50]
+	if len(s.RecentlyUsedModels) > maxRecentModels {
+		s.RecentlyUsedModels = s.RecentlyUsedModels[:maxRecentModels]
 	}
 }
 
@@ -109,8 +197,8 @@ func (s *State) UpdateAgentUsage(agentName string) {
 
 	// Prepend to slice and limit to last 20 entries
 	s.RecentlyUsedAgents = append([]AgentUsage{newUsage}, s.RecentlyUsedAgents...)
-	if len(s.RecentlyUsedAgents) > 20 {
-		s.RecentlyUsedAgents = s.RecentlyUsedAgents[:20]
+	if len(s.RecentlyUsedAgents) > maxRecentAgents {
+		s.RecentlyUsedAgents = s.RecentlyUsedAgents[:maxRecentAgents]
 	}
 }
 
@@ -123,52 +211,74 @@ func (s *State) RemoveAgentFromRecentlyUsed(agentName string) {
 	}
 }
 
-func (s *State) AddPromptToHistory(prompt Prompt) {
-	s.MessageHistory = append([]Prompt{prompt}, s.MessageHistory...)
-	if len(s.MessageHistory) > 50 {
-		s.MessageHistory = s.MessageHistory[:50]
+// PromptHistory returns the prompts sent in the project, newest first.
+func (s *State) PromptHistory(project string) []Prompt {
+	var prompts []Prompt
+	for _, entry := range s.MessageHistory {
+		if entry.Project == "" || entry.Project == project {
+			prompts = append(prompts, entry.Prompt)
+		}
 	}
+	return prompts
 }
 
-// SaveState writes the provided Config struct to the specified TOML file.
-// It will create the file if it doesn't exist, or overwrite it if it does.
-func SaveState(filePath string, state *State) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create/open config file %s: %w", filePath, err)
-	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-	encoder := toml.NewEncoder(writer)
-	if err := encoder.Encode(state); err != nil {
-		return fmt.Errorf("failed to encode state to TOML file %s: %w", filePath, err)
-	}
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush writer for state file %s: %w", filePath, err)
-	}
+// AddPromptToHistory records a prompt sent in the project, dropping the
+// oldest of the project's prompts beyond maxPromptHistory. Prompts of other
+// projects are kept.
+func (s *State) AddPromptToHistory(project string, prompt Prompt) {
+	entry := HistoryEntry{Prompt: prompt, Project: project, SentAt: time.Now()}
+	s.MessageHistory = trimHistory(append([]HistoryEntry{entry}, s.MessageHistory...), project)
+}
 
-	slog.Debug("State saved to file", "file", filePath)
-	return nil
+// trimHistory drops the oldest prompts of the project beyond maxPromptHistory.
+func trimHistory(history []HistoryEntry, project string) []HistoryEntry {
+	count := 0
+	return slices.DeleteFunc(history, func(entry HistoryEntry) bool {
+		if entry.Project != "" && entry.Project != project {
+			return false
+		}
+		count++
+		return count > maxPromptHistory
+	})
 }
 
-// LoadState loads the state from the specified TOML file.
-// It returns a pointer to the State struct and an error if any issues occur.
-func LoadState(filePath string) (*State, error) {
-	var state State
-	if _, err := toml.DecodeFile(filePath, &state); err != nil {
-		if _, statErr := os.Stat(filePath); os.IsNotExist(statErr) {
-			return nil, fmt.Errorf("state file not found at %s: %w", filePath, statErr)
+// SetDraft keeps the unsent prompt of a session, or forgets the draft when
+// the prompt is empty. The draft of a new session without an ID is kept for
+// the project at worktree.
+func (s *State) SetDraft(worktree, sessionID string, prompt Prompt) {
+	empty := strings.TrimSpace(prompt.Text) == "" && len(prompt.Attachments) == 0
+	switch {
+	case sessionID == "" && empty:
+		if project, ok := s.Projects[worktree]; ok {
+			project.Draft = Prompt{}
+		}
+	case sessionID == "":
+		s.project(worktree).Draft = prompt
+	case empty:
+		delete(s.Drafts, sessionID)
+	default:
+		if s.Drafts == nil {
+			s.Drafts = make(map[string]Prompt)
 		}
-		return nil, fmt.Errorf("failed to decode TOML from file %s: %w", filePath, err)
+		s.Drafts[sessionID] = prompt
 	}
+}
 
-	// Restore attachment sources types that were deserialized as map[string]any
-	for _, prompt := range state.MessageHistory {
-		for _, att := range prompt.Attachments {
-			att.RestoreSourceType()
+// Draft returns the draft of a session, see SetDraft. The draft is kept
+// while it is back in the editor, until it is replaced or sent, so that it
+// isn't lost if opencode exits before saving again.
+func (s *State) Draft(worktree, sessionID string) Prompt {
+	if sessionID == "" {
+		if project, ok := s.Projects[worktree]; ok {
+			return project.Draft
 		}
+		return Prompt{}
 	}
+	return s.Drafts[sessionID]
+}
 
-	return &state, nil
+// HasDraft reports whether a session has a draft.
+func (s *State) HasDraft(sessionID string) bool {
+	_, ok := s.Drafts[sessionID]
+	return ok
 }
@@ -0,0 +1,298 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func promptTexts(prompts []Prompt) []string {
+	texts := make([]string, len(prompts))
+	for i, prompt := range prompts {
+		texts[i] = prompt.Text
+	}
+	return texts
+}
+
+func TestPromptHistory(t *testing.T) {
+	state := NewState()
+	// saved before history was kept per project
+	state.MessageHistory = append(state.MessageHistory, HistoryEntry{Prompt: Prompt{Text: "old"}})
+	state.AddPromptToHistory("/a", Prompt{Text: "a1"})
+	state.AddPromptToHistory("/b", Prompt{Text: "b1"})
+	state.AddPromptToHistory("/a", Prompt{Text: "a2"})
+
+	if got, want := promptTexts(state.PromptHistory("/a")), []string{"a2", "a1", "old"}; !slices.Equal(got, want) {
+		t.Errorf("history of /a is %q, want %q", got, want)
+	}
+	if got, want := promptTexts(state.PromptHistory("/b")), []string{"b1", "old"}; !slices.Equal(got, want) {
+		t.Errorf("history of /b is %q, want %q", got, want)
+	}
+
+	for i := range maxPromptHistory {
+		state.AddPromptToHistory("/a", Prompt{Text: fmt.Sprint(i)})
+	}
+	if got := state.PromptHistory("/a"); len(got) != maxPromptHistory || got[len(got)-1].Text != "0" {
+		t.Errorf("/a kept %d prompts, the oldest %q", len(got), got[len(got)-1].Text)
+	}
+	if got, want := promptTexts(state.PromptHistory("/b")), []string{"b1"}; !slices.Equal(got, want) {
+		t.Errorf("a busy project pushed out the history of another: %q", got)
+	}
+}
+
+func TestProjectChoices(t *testing.T) {
+	state := NewState()
+	state.SetAgent("/a", "plan")
+	state.SetAgentModel("/a", "plan", AgentModel{ProviderID: "anthropic", ModelID: "claude"})
+	state.SetAgentModel("/b", "build", AgentModel{ProviderID: "openai", ModelID: "gpt"})
+
+	a, b := state.Project("/a"), state.Project("/b")
+	if a.Agent != "plan" || a.AgentModel["plan"].ModelID != "claude" {
+		t.Errorf("/a has choices %+v", a)
+	}
+	if _, ok := a.AgentModel["build"]; ok {
+		t.Errorf("a model chosen in /b was chosen in /a too: %+v", a)
+	}
+	// /b started from the choices made in /a
+	if b.Agent != "plan" || b.AgentModel["build"].ModelID != "gpt" || b.AgentModel["plan"].ModelID != "claude" {
+		t.Errorf("/b has choices %+v", b)
+	}
+	if c := state.Project("/c"); c.Agent != "plan" || c.AgentModel["build"].ModelID != "gpt" {
+		t.Errorf("a new project has choices %+v, want those made last", c)
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	state := NewState()
+	state.SetDraft("/a", "", Prompt{Text: "new in a"})
+	state.SetDraft("/a", "ses_1", Prompt{Text: "reply"})
+
+	if got := state.Draft("/b", "").Text; got != "" {
+		t.Errorf("the new session draft of /a shows in /b: %q", got)
+	}
+	for range 2 {
+		if got := state.Draft("/a", "").Text; got != "new in a" {
+			t.Errorf("new session draft is %q", got)
+		}
+		if got := state.Draft("/b", "ses_1").Text; got != "reply" {
+			t.Errorf("session draft is %q", got)
+		}
+	}
+
+	state.SetDraft("/a", "", Prompt{Text: "  "})
+	state.SetDraft("/a", "ses_1", Prompt{})
+	if state.Draft("/a", "").Text != "" || state.HasDraft("ses_1") {
+		t.Errorf("empty prompts did not clear the drafts: %+v", state.Drafts)
+	}
+}
+
+func TestLoadLegacyState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tui")
+	legacy := `theme = "tokyonight"
+provider = "anthropic"
+model = "claude"
+agent = "plan"
+
+[[message_history]]
+text = "hello"
+`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != stateVersion || state.Theme != "tokyonight" {
+		t.Errorf("loaded version %d with theme %q", state.Version, state.Theme)
+	}
+	if choices := state.Project("/a"); choices.AgentModel["plan"].ModelID != "claude" {
+		t.Errorf("the last model was not kept for the agent: %+v", choices)
+	}
+	if got := promptTexts(state.PromptHistory("/a")); !slices.Equal(got, []string{"hello"}) {
+		t.Errorf("history is %q", got)
+	}
+
+	if err := SaveState(path, state); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = LoadState(path); err != nil || state.Version != stateVersion {
+		t.Errorf("saved state loaded as version %d: %v", state.Version, err)
+	}
+}
+
+func TestSaveStateMerges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tui")
+	initial := NewState()
+	initial.UpdateModelUsage("openai", "gpt")
+	initial.UpdateModelUsage("anthropic", "claude")
+	if err := SaveState(path, initial); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two instances that started at the same time
+	first, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.Theme = "nord"
+	first.SetAgentModel("/a", "build", AgentModel{ProviderID: "anthropic", ModelID: "claude"})
+	first.AddPromptToHistory("/a", Prompt{Text: "first"})
+	first.SetDraft("/a", "ses_1", Prompt{Text: "draft"})
+	if err := SaveState(path, first.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	second.SetAgent("/b", "plan")
+	second.RemoveModelFromRecentlyUsed("openai", "gpt")
+	second.UpdateAgentUsage("plan")
+	second.AddPromptToHistory("/a", Prompt{Text: "second"})
+	second.SetDraft("/b", "", Prompt{Text: "new session"})
+	if err := SaveState(path, second); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Theme != "nord" {
+		t.Errorf("theme is %q", merged.Theme)
+	}
+	if got := merged.Project("/a").AgentModel["build"].ModelID; got != "claude" {
+		t.Errorf("/a uses %q for build", got)
+	}
+	if got := merged.Project("/b").Agent; got != "plan" {
+		t.Errorf("/b uses agent %q", got)
+	}
+	if got := merged.RecentlyUsedModels; len(got) != 1 || got[0].ModelID != "claude" {
+		t.Errorf("recently used models are %+v", got)
+	}
+	if got := merged.RecentlyUsedAgents; len(got) != 1 || got[0].AgentName != "plan" {
+		t.Errorf("recently used agents are %+v", got)
+	}
+	if got, want := promptTexts(merged.PromptHistory("/a")), []string{"second", "first"}; !slices.Equal(got, want) {
+		t.Errorf("history is %q, want %q", got, want)
+	}
+	if !merged.HasDraft("ses_1") {
+		t.Error("the draft of the first instance was lost")
+	}
+	if got := merged.Draft("/b", "").Text; got != "new session" {
+		t.Errorf("the new session draft of /b is %q", got)
+	}
+
+	// Saving again without changes keeps what the other instance saved
+	if err := SaveState(path, first); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := LoadState(path); err != nil || again.Project("/b").Agent != "plan" {
+		t.Errorf("saving again dropped the choices of /b: %v", err)
+	}
+}
+
+func TestSaveStateMergesFreshInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tui")
+
+	// Two instances that started before there was a state file
+	first := NewState()
+	second := NewState()
+
+	first.Theme = "nord"
+	first.SetDraft("/a", "ses_1", Prompt{Text: "draft"})
+	if err := SaveState(path, first.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	second.SetAgent("/b", "plan")
+	second.AddPromptToHistory("/b", Prompt{Text: "second"})
+	if err := SaveState(path, second.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	// A state built without NewState has nothing to merge against
+	third := &State{Clipboard: "osc52"}
+	if err := SaveState(path, third); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Theme != "nord" || !merged.HasDraft("ses_1") {
+		t.Errorf("the choices of the first instance were lost: theme %q, drafts %v", merged.Theme, merged.Projects)
+	}
+	if got := merged.Project("/b").Agent; got != "plan" {
+		t.Errorf("/b uses agent %q", got)
+	}
+	if got := promptTexts(merged.PromptHistory("/b")); !slices.Equal(got, []string{"second"}) {
+		t.Errorf("history is %q", got)
+	}
+	if merged.Clipboard != "osc52" {
+		t.Errorf("clipboard is %q", merged.Clipboard)
+	}
+}
+
+func TestSaveStateSkipsOlderSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tui")
+	state := NewState()
+	state.Theme = "nord"
+	older := state.Snapshot()
+	state.Theme = "tokyonight"
+	newer := state.Snapshot()
+
+	if err := SaveState(path, newer); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveState(path, older); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := LoadState(path); err != nil || loaded.Theme != "tokyonight" {
+		t.Errorf("theme is %q after saving an older snapshot: %v", loaded.Theme, err)
+	}
+}
+
+func TestStateFilesNotRewritten(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		loads    bool
+	}{
+		{"newer version", "version = 99\ntheme = \"nord\"\n", true},
+		{"negative version", "version = -1\ntheme = \"nord\"\n", false},
+		{"unreadable", "theme = \n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tui")
+			if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			state, err := LoadState(path)
+			if tt.loads && (err != nil || state.Theme != "nord") {
+				t.Fatalf("expected the file to load, got %+v, %v", state, err)
+			}
+			if !tt.loads {
+				if err == nil {
+					t.Fatal("expected an error loading the file")
+				}
+				state = NewState()
+			}
+
+			state.Theme = "tokyonight"
+			if err := SaveState(path, state); err == nil {
+				t.Error("expected an error saving over the file")
+			}
+			if contents, _ := os.ReadFile(path); string(contents) != tt.contents {
+				t.Errorf("file was rewritten to %q", contents)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package app
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// stateVersion is the format of the state file written by this version.
+// Files written before the state was versioned have no version, which reads
+// as 0.
+const stateVersion = 1
+
+// migrations upgrade the state read from an older file, the one at index n
+// from version n to n+1.
+var migrations = [stateVersion]func(*State){
+	// The choices at the top level become those every project starts from.
+	// Files from before agent_model only kept the last model used.
+	func(state *State) {
+		if state.Provider == "" || state.Model == "" {
+			return
+		}
+		agent := cmp.Or(state.Agent, "build")
+		if _, ok := state.AgentModel[agent]; ok {
+			return
+		}
+		if state.AgentModel == nil {
+			state.AgentModel = make(map[string]AgentModel)
+		}
+		state.AgentModel[agent] = AgentModel{ProviderID: state.Provider, ModelID: state.Model}
+	},
+}
+
+// savedState is what this process last read from or wrote to the state file,
+// so that saving applies only the changes made since and keeps those other
+// instances saved in the meantime.
+type savedState struct {
+	mu    sync.Mutex
+	state *State
+	// seq is that of the last snapshot written, and taken that of the last
+	// snapshot taken
+	seq   uint64
+	taken uint64
+}
+
+// Snapshot copies the state for saving it in the background while it keeps
+// changing.
+func (s *State) Snapshot() *State {
+	snapshot := s.clone()
+	if s.saved != nil {
+		s.saved.mu.Lock()
+		s.saved.taken++
+		snapshot.seq = s.saved.taken
+		s.saved.mu.Unlock()
+	}
+	return snapshot
+}
+
+func (s *State) clone() *State {
+	c := *s
+	c.AgentModel = maps.Clone(s.AgentModel)
+	c.Projects = make(map[string]*ProjectState, len(s.Projects))
+	for worktree, project := range s.Projects {
+		c.Projects[worktree] = &ProjectState{
+			AgentModel: maps.Clone(project.AgentModel),
+			Agent:      project.Agent,
+			Draft:      project.Draft,
+		}
+	}
+	c.RecentlyUsedModels = slices.Clone(s.RecentlyUsedModels)
+	c.RecentlyUsedAgents = slices.Clone(s.RecentlyUsedAgents)
+	c.MessageHistory = slices.Clone(s.MessageHistory)
+	// The settings point into the components that change them
+	c.ShowToolDetails = cloneBool(s.ShowToolDetails)
+	c.ShowThinkingBlocks = cloneBool(s.ShowThinkingBlocks)
+	c.Drafts = maps.Clone(s.Drafts)
+	return &c
+}
+
+func cloneBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	value := *b
+	return &value
+}
+
+// SaveState writes the state to the specified TOML file. Other instances may
+// have saved since this one read the file, so it takes the file's lock and
+// applies only what changed here since the last read or save to what is on
+// disk. The file is replaced in one rename, so it is never seen half written.
+// Apps without a state file pass an empty filePath, and nothing is written.
+func SaveState(filePath string, state *State) error {
+	if filePath == "" {
+		return nil
+	}
+	saved := state.saved
+	if saved == nil {
+		// Nothing was read from the file, so everything in state is a change
+		saved = &savedState{state: &State{}}
+		state.saved = saved
+	}
+	saved.mu.Lock()
+	defer saved.mu.Unlock()
+
+	// Snapshots are saved in the background, possibly out of order
+	if state.seq != 0 && state.seq < saved.seq {
+		return nil
+	}
+
+	unlock, err := lockFile(filePath + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock state file %s: %w", filePath, err)
+	}
+	defer unlock()
+
+	merged := state.clone()
+	disk, err := readState(filePath)
+	switch {
+	case err == nil && disk.Version > stateVersion:
+		return fmt.Errorf("state file %s was written by a newer version of opencode", filePath)
+	case err == nil:
+		merged = mergeState(saved.state, state, disk)
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("not replacing unreadable state file %s: %w", filePath, err)
+	}
+	merged.Version = stateVersion
+
+	if err := writeStateFile(filePath, merged); err != nil {
+		return err
+	}
+	saved.state = state.clone()
+	saved.seq = max(saved.seq, state.seq)
+
+	slog.Debug("State saved to file", "file", filePath)
+	return nil
+}
+
+// writeStateFile writes the state to a temporary file next to the state file
+// and renames it over the state file.
+func writeStateFile(filePath string, state *State) error {
+	file, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for state file %s: %w", filePath, err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := toml.NewEncoder(writer)
+	if err := encoder.Encode(state); err != nil {
+		return fmt.Errorf("failed to encode state to TOML file %s: %w", filePath, err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer for state file %s: %w", filePath, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync state file %s: %w", filePath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close state file %s: %w", filePath, err)
+	}
+	if err := os.Rename(file.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to replace state file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// LoadState loads the state from the specified TOML file, upgrading it from
+// older formats. It returns a pointer to the State struct and an error if any
+// issues occur.
+func LoadState(filePath string) (*State, error) {
+	state, err := readState(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("state file not found at %s: %w", filePath, err)
+		}
+		return nil, fmt.Errorf("failed to decode TOML from file %s: %w", filePath, err)
+	}
+	state.saved = &savedState{state: state.clone()}
+	return state, nil
+}
+
+func readState(filePath string) (*State, error) {
+	var state State
+	if _, err := toml.DecodeFile(filePath, &state); err != nil {
+		return nil, err
+	}
+
+	// Files from newer versions are read as they are, SaveState refuses to
+	// write over them
+	if state.Version < 0 {
+		return nil, fmt.Errorf("unknown state file version %d", state.Version)
+	}
+	for state.Version < stateVersion {
+		migrations[state.Version](&state)
+		state.Version++
+	}
+
+	if state.AgentModel == nil {
+		state.AgentModel = make(map[string]AgentModel)
+	}
+	for worktree, project := range state.Projects {
+		if project == nil {
+			delete(state.Projects, worktree)
+		} else if project.AgentModel == nil {
+			project.AgentModel = make(map[string]AgentModel)
+		}
+	}
+
+	// Restore attachment sources types that were deserialized as map[string]any
+	for _, prompt := range state.MessageHistory {
+		for _, att := range prompt.Attachments {
+			att.RestoreSourceType()
+		}
+	}
+	for _, prompt := range state.Drafts {
+		for _, att := range prompt.Attachments {
+			att.RestoreSourceType()
+		}
+	}
+	for _, project := range state.Projects {
+		for _, att := range project.Draft.Attachments {
+			att.RestoreSourceType()
+		}
+	}
+
+	return &state, nil
+}
+
+// mergeState applies what changed from base to mine to the state on disk.
+func mergeState(base, mine, disk *State) *State {
+	merged := disk.clone()
+
+	mergeValue(&merged.Theme, base.Theme, mine.Theme)
+	mergeValue(&merged.Provider, base.Provider, mine.Provider)
+	mergeValue(&merged.Model, base.Model, mine.Model)
+	mergeValue(&merged.Agent, base.Agent, mine.Agent)
+	mergeValue(&merged.ShowToolDetails, base.ShowToolDetails, mine.ShowToolDetails)
+	mergeValue(&merged.ShowThinkingBlocks, base.ShowThinkingBlocks, mine.ShowThinkingBlocks)
+	mergeValue(&merged.Clipboard, base.Clipboard, mine.Clipboard)
+	merged.AgentModel = mergeMap(merged.AgentModel, base.AgentModel, mine.AgentModel)
+	merged.Drafts = mergeMap(merged.Drafts, base.Drafts, mine.Drafts)
+
+	for worktree, project := range mine.Projects {
+		old, ok := base.Projects[worktree]
+		if !ok {
+			old = &ProjectState{}
+		}
+		into, ok := merged.Projects[worktree]
+		if !ok {
+			into = &ProjectState{}
+			merged.Projects[worktree] = into
+		}
+		mergeValue(&into.Agent, old.Agent, project.Agent)
+		mergeValue(&into.Draft, old.Draft, project.Draft)
+		into.AgentModel = mergeMap(into.AgentModel, old.AgentModel, project.AgentModel)
+	}
+
+	merged.RecentlyUsedModels = mergeUsage(
+		merged.RecentlyUsedModels,
+		base.RecentlyUsedModels,
+		mine.RecentlyUsedModels,
+		func(usage ModelUsage) string { return usage.ProviderID + "/" + usage.ModelID },
+		func(usage ModelUsage) time.Time { return usage.LastUsed },
+		maxRecentModels,
+	)
+	merged.RecentlyUsedAgents = mergeUsage(
+		merged.RecentlyUsedAgents,
+		base.RecentlyUsedAgents,
+		mine.RecentlyUsedAgents,
+		func(usage AgentUsage) string { return usage.AgentName },
+		func(usage AgentUsage) time.Time { return usage.LastUsed },
+		maxRecentAgents,
+	)
+	merged.MessageHistory = mergeHistory(merged.MessageHistory, base.MessageHistory, mine.MessageHistory)
+
+	return merged
+}
+
+func mergeValue[T any](merged *T, base, mine T) {
+	if !reflect.DeepEqual(base, mine) {
+		*merged = mine
+	}
+}
+
+// mergeMap applies the keys added, changed or removed from base to mine.
+func mergeMap[V any](merged, base, mine map[string]V) map[string]V {
+	for key, value := range mine {
+		if old, ok := base[key]; ok && reflect.DeepEqual(old, value) {
+			continue
+		}
+		if merged == nil {
+			merged = make(map[string]V)
+		}
+		merged[key] = value
+	}
+	for key := range base {
+		if _, ok := mine[key]; !ok {
+			delete(merged, key)
+		}
+	}
+	return merged
+}
+
+// mergeUsage applies the entries used or removed from base to mine, keeping
+// the most recently used first. An entry removed here stays when it has been
+// used elsewhere since.
+func mergeUsage[T any](
+	merged, base, mine []T,
+	key func(T) string,
+	lastUsed func(T) time.Time,
+	limit int,
+) []T {
+	inBase := make(map[string]T, len(base))
+	for _, usage := range base {
+		inBase[key(usage)] = usage
+	}
+	inMine := make(map[string]bool, len(mine))
+	for _, usage := range mine {
+		inMine[key(usage)] = true
+		if old, ok := inBase[key(usage)]; ok && lastUsed(old).Equal(lastUsed(usage)) {
+			continue
+		}
+		i := slices.IndexFunc(merged, func(other T) bool { return key(other) == key(usage) })
+		switch {
+		case i == -1:
+			merged = append(merged, usage)
+		case lastUsed(merged[i]).Before(lastUsed(usage)):
+			merged[i] = usage
+		}
+	}
+	merged = slices.DeleteFunc(merged, func(usage T) bool {
+		old, ok := inBase[key(usage)]
+		return ok && !inMine[key(usage)] && !lastUsed(usage).After(lastUsed(old))
+	})
+
+	slices.SortStableFunc(merged, func(a, b T) int {
+		return lastUsed(b).Compare(lastUsed(a))
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+// mergeHistory adds the prompts sent from base to mine, newest first.
+func mergeHistory(merged, base, mine []HistoryEntry) []HistoryEntry {
+	type historyKey struct {
+		sentAt  int64
+		project string
+		text    string
+	}
+	keyOf := func(entry HistoryEntry) historyKey {
+		return historyKey{entry.SentAt.UnixNano(), entry.Project, entry.Text}
+	}
+
+	seen := make(map[historyKey]bool, len(base)+len(merged))
+	for _, entry := range base {
+		seen[keyOf(entry)] = true
+	}
+	for _, entry := range merged {
+		seen[keyOf(entry)] = true
+	}
+
+	projects := make(map[string]bool)
+	for _, entry := range mine {
+		// Prompts saved before the file was versioned were read from it
+		if entry.SentAt.IsZero() || seen[keyOf(entry)] {
+			continue
+		}
+		merged = append(merged, entry)
+		projects[entry.Project] = true
+	}
+
+	slices.SortStableFunc(merged, func(a, b HistoryEntry) int {
+		return b.SentAt.Compare(a.SentAt)
+	})
+	for project := range projects {
+		merged = trimHistory(merged, project)
+	}
+	return merged
+}
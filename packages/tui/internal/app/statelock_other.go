@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package app
+
+// lockFile does nothing where files cannot be locked. Saving still replaces
+// the state file in one rename, but instances may lose each other's changes.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
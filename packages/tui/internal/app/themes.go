@@ -0,0 +1,27 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// ThemesReloadedMsg is sent after custom theme files changed on disk.
+type ThemesReloadedMsg struct {
+	Events []theme.ReloadEvent
+}
+
+// WatchThemes returns a command that blocks until theme files change.
+// Re-issue it after each ThemesReloadedMsg.
+func (a *App) WatchThemes() tea.Cmd {
+	watcher := a.themeWatcher
+	if watcher == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		events, ok := watcher.Next()
+		if !ok {
+			return nil
+		}
+		return ThemesReloadedMsg{Events: events}
+	}
+}
@@ -0,0 +1,78 @@
+// Package backend is the seam between the TUI and the opencode API client.
+// The app and completions talk to a Backend instead of a concrete SDK, so the
+// hand-written client and the generated one can be swapped per run while the
+// migration happens call by call, and tests can run against a Fake.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	opencode "github.com/sst/opencode-api-go"
+)
+
+const (
+	Stainless = "stainless"
+	Generated = "generated"
+)
+
+// Names lists the backends New accepts.
+var Names = []string{Stainless, Generated}
+
+// Backend covers the API calls internal/app and internal/completions make.
+type Backend interface {
+	Config(ctx context.Context) (*opencode.Config, error)
+	Commands(ctx context.Context) ([]opencode.Command, error)
+	Providers(ctx context.Context) (*opencode.AppProvidersResponse, error)
+	Agents(ctx context.Context) ([]opencode.Agent, error)
+	FindFiles(ctx context.Context, query string) ([]string, error)
+	FindSymbols(ctx context.Context, query string) ([]opencode.Symbol, error)
+	FileStatus(ctx context.Context) ([]opencode.File, error)
+	// Events opens the server-sent event stream, resuming after lastEventID
+	// when it is set.
+	Events(ctx context.Context, lastEventID string) EventStream
+	Session() SessionService
+}
+
+// SessionService is the subset of session calls the app makes. The SDK's
+// session adapters satisfy it as they are.
+type SessionService interface {
+	New(ctx context.Context, params opencode.SessionNewParams) (*opencode.Session, error)
+	Update(ctx context.Context, id string, params opencode.SessionUpdateParams) (*opencode.Session, error)
+	List(ctx context.Context, query opencode.SessionListParams) (*[]opencode.Session, error)
+	Delete(ctx context.Context, id string, body opencode.SessionDeleteParams) (*bool, error)
+	Abort(ctx context.Context, id string, body opencode.SessionAbortParams) (*bool, error)
+	Init(ctx context.Context, id string, params opencode.SessionInitParams) (*bool, error)
+	Summarize(ctx context.Context, id string, params opencode.SessionSummarizeParams) (*bool, error)
+	Prompt(ctx context.Context, id string, params opencode.SessionPromptParams) (*opencode.SessionPromptResponse, error)
+	Command(ctx context.Context, id string, params opencode.SessionCommandParams) (*opencode.SessionCommandResponse, error)
+	Shell(ctx context.Context, id string, params opencode.SessionShellParams) (*opencode.AssistantMessage, error)
+	Messages(ctx context.Context, id string, query opencode.SessionMessagesParams) (*[]opencode.SessionMessagesResponse, error)
+}
+
+// EventStream is an open server-sent event stream.
+type EventStream interface {
+	Next() bool
+	Current() opencode.EventListResponse
+	Err() error
+	// LastEventID is the ID of the most recent event, used to resume.
+	LastEventID() string
+	// Retry is the reconnect delay the server asked for, if any.
+	Retry() time.Duration
+	Close() error
+}
+
+// New returns the backend called name on top of client. The generated
+// backend sends the calls it has taken over to serverURL through httpClient.
+func New(name string, client *opencode.Client, serverURL string, httpClient *http.Client) (Backend, error) {
+	switch strings.ToLower(name) {
+	case "", Stainless:
+		return NewStainless(client), nil
+	case Generated:
+		return NewGenerated(client, serverURL, httpClient)
+	}
+	return nil, fmt.Errorf("unknown backend %q: use one of %s", name, strings.Join(Names, ", "))
+}
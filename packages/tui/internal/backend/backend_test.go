@@ -0,0 +1,181 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode-api-go/option"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestNewSelectsBackend(t *testing.T) {
+	const url = "http://localhost:4096"
+	client := opencode.NewClient(option.WithBaseURL(url))
+	for name, want := range map[string]string{
+		"":          "*backend.StainlessBackend",
+		"stainless": "*backend.StainlessBackend",
+		"Generated": "*backend.GeneratedBackend",
+	} {
+		b, err := New(name, client, url, http.DefaultClient)
+		if err != nil {
+			t.Fatalf("New(%q): %v", name, err)
+		}
+		if got := fmt.Sprintf("%T", b); got != want {
+			t.Errorf("New(%q) = %s, want %s", name, got, want)
+		}
+	}
+	if _, err := New("grpc", client, url, http.DefaultClient); err == nil {
+		t.Error("expected unknown backend to fail")
+	}
+}
+
+func TestFakeSessions(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	sessions := fake.Session()
+
+	created, err := sessions.New(ctx, opencode.SessionNewParams{Title: opencode.F("first")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Update(ctx, created.ID, opencode.SessionUpdateParams{Title: opencode.F("renamed")}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := sessions.List(ctx, opencode.SessionListParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*list) != 1 || (*list)[0].Title != "renamed" {
+		t.Fatalf("unexpected sessions %+v", *list)
+	}
+
+	fake.AddMessages(created.ID, opencode.SessionMessagesResponse{})
+	messages, err := sessions.Messages(ctx, created.ID, opencode.SessionMessagesParams{})
+	if err != nil || len(*messages) != 1 {
+		t.Fatalf("messages = %v, %v", messages, err)
+	}
+
+	if _, err := sessions.Delete(ctx, created.ID, opencode.SessionDeleteParams{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Delete(ctx, created.ID, opencode.SessionDeleteParams{}); err == nil {
+		t.Error("expected deleting a missing session to fail")
+	}
+
+	var methods []string
+	for _, call := range fake.Calls() {
+		methods = append(methods, call.Method)
+	}
+	want := []string{"Session.New", "Session.Update", "Session.List", "Session.Messages", "Session.Delete", "Session.Delete"}
+	if len(methods) != len(want) {
+		t.Fatalf("calls = %v, want %v", methods, want)
+	}
+	for i := range want {
+		if methods[i] != want[i] {
+			t.Errorf("call %d = %s, want %s", i, methods[i], want[i])
+		}
+	}
+}
+
+func TestGeneratedSessions(t *testing.T) {
+	server := testserver.New()
+	defer server.Close()
+	server.Reply(testserver.Reply{Text: "hello"})
+	client := opencode.NewClient(option.WithBaseURL(server.URL()))
+	b, err := NewGenerated(client, server.URL(), http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	sessions := b.Session()
+
+	created, err := sessions.New(ctx, opencode.SessionNewParams{Title: opencode.F("first")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.Title != "first" {
+		t.Errorf("created %+v", created)
+	}
+	renamed, err := sessions.Update(ctx, created.ID, opencode.SessionUpdateParams{Title: opencode.F("renamed")})
+	if err != nil || renamed.Title != "renamed" {
+		t.Fatalf("renamed to %+v, %v", renamed, err)
+	}
+	list, err := sessions.List(ctx, opencode.SessionListParams{})
+	if err != nil || len(*list) != 1 || (*list)[0].ID != created.ID {
+		t.Fatalf("listed %+v, %v", list, err)
+	}
+
+	_, err = sessions.Prompt(ctx, created.ID, opencode.SessionPromptParams{
+		Model: opencode.F(opencode.SessionPromptParamsModel{
+			ProviderID: opencode.F("anthropic"),
+			ModelID:    opencode.F("claude-sonnet-4"),
+		}),
+		Parts: opencode.F([]opencode.SessionPromptParamsPartUnion{
+			opencode.TextPartInputParam{Type: opencode.F(opencode.TextPartInputTypeText), Text: opencode.F("hi")},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := sessions.Messages(ctx, created.ID, opencode.SessionMessagesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*messages) != 2 {
+		t.Fatalf("got %d messages, want the prompt and its answer", len(*messages))
+	}
+	answer := (*messages)[1]
+	if _, ok := answer.Info.AsUnion().(opencode.AssistantMessage); !ok {
+		t.Errorf("second message is %T, want an assistant message", answer.Info.AsUnion())
+	}
+	var text []string
+	for _, part := range answer.Parts {
+		if part, ok := part.AsUnion().(opencode.TextPart); ok {
+			text = append(text, part.Text)
+		}
+	}
+	if !slices.Equal(text, []string{"hello"}) {
+		t.Errorf("the answer's text parts are %q", text)
+	}
+
+	if deleted, err := sessions.Delete(ctx, created.ID, opencode.SessionDeleteParams{}); err != nil || !*deleted {
+		t.Errorf("deleted %v, %v", deleted, err)
+	}
+}
+
+func TestFakeErr(t *testing.T) {
+	fake := NewFake()
+	fake.CommandList = []opencode.Command{{Name: "init"}}
+	fake.Err = errors.New("offline")
+	commands, err := fake.Commands(context.Background())
+	if !errors.Is(err, fake.Err) || commands != nil {
+		t.Errorf("got %v, %v; want only the error", commands, err)
+	}
+	if calls := fake.Calls(); len(calls) != 1 || calls[0].Method != "Commands" {
+		t.Errorf("calls = %+v", calls)
+	}
+}
+
+func TestFakeEventStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fake := NewFake()
+	stream := fake.Events(ctx, "")
+
+	fake.Send(opencode.EventListResponse{Type: opencode.EventListResponseTypeSessionUpdated})
+	if !stream.Next() {
+		t.Fatal("expected an event")
+	}
+	if stream.Current().Type != opencode.EventListResponseTypeSessionUpdated || stream.LastEventID() != "1" {
+		t.Errorf("unexpected event %+v with id %q", stream.Current(), stream.LastEventID())
+	}
+
+	cancel()
+	if stream.Next() {
+		t.Error("expected the stream to end when its context is cancelled")
+	}
+}
@@ -0,0 +1,302 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/id"
+)
+
+// Fake is an in-memory Backend for tests. Set the exported fields before use;
+// sessions and messages can be seeded with AddSession and AddMessages and
+// events pushed to open streams with Send. Every call is recorded.
+type Fake struct {
+	ConfigInfo   opencode.Config
+	CommandList  []opencode.Command
+	ProviderList opencode.AppProvidersResponse
+	AgentList    []opencode.Agent
+	Files        []string
+	Symbols      []opencode.Symbol
+	Status       []opencode.File
+	// Err, when set, fails every call.
+	Err error
+
+	mu       sync.Mutex
+	calls    []Call
+	sessions []opencode.Session
+	messages map[string][]opencode.SessionMessagesResponse
+	events   chan opencode.EventListResponse
+}
+
+// Call is one recorded request.
+type Call struct {
+	Method    string
+	SessionID string
+	Params    any
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		messages: map[string][]opencode.SessionMessagesResponse{},
+		events:   make(chan opencode.EventListResponse, 64),
+	}
+}
+
+// Calls returns the requests made so far, oldest first.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+func (f *Fake) AddSession(session opencode.Session) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions = append(f.sessions, session)
+}
+
+func (f *Fake) AddMessages(sessionID string, messages ...opencode.SessionMessagesResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages[sessionID] = append(f.messages[sessionID], messages...)
+}
+
+// Send delivers events to the open event stream.
+func (f *Fake) Send(events ...opencode.EventListResponse) {
+	for _, event := range events {
+		f.events <- event
+	}
+}
+
+func (f *Fake) record(method, sessionID string, params any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, SessionID: sessionID, Params: params})
+	return f.Err
+}
+
+func (f *Fake) Config(ctx context.Context) (*opencode.Config, error) {
+	if err := f.record("Config", "", nil); err != nil {
+		return nil, err
+	}
+	config := f.ConfigInfo
+	return &config, nil
+}
+
+func (f *Fake) Commands(ctx context.Context) ([]opencode.Command, error) {
+	if err := f.record("Commands", "", nil); err != nil {
+		return nil, err
+	}
+	return f.CommandList, nil
+}
+
+func (f *Fake) Providers(ctx context.Context) (*opencode.AppProvidersResponse, error) {
+	if err := f.record("Providers", "", nil); err != nil {
+		return nil, err
+	}
+	providers := f.ProviderList
+	return &providers, nil
+}
+
+func (f *Fake) Agents(ctx context.Context) ([]opencode.Agent, error) {
+	if err := f.record("Agents", "", nil); err != nil {
+		return nil, err
+	}
+	return f.AgentList, nil
+}
+
+func (f *Fake) FindFiles(ctx context.Context, query string) ([]string, error) {
+	if err := f.record("FindFiles", "", query); err != nil {
+		return nil, err
+	}
+	return f.Files, nil
+}
+
+func (f *Fake) FindSymbols(ctx context.Context, query string) ([]opencode.Symbol, error) {
+	if err := f.record("FindSymbols", "", query); err != nil {
+		return nil, err
+	}
+	return f.Symbols, nil
+}
+
+func (f *Fake) FileStatus(ctx context.Context) ([]opencode.File, error) {
+	if err := f.record("FileStatus", "", nil); err != nil {
+		return nil, err
+	}
+	return f.Status, nil
+}
+
+func (f *Fake) Events(ctx context.Context, lastEventID string) EventStream {
+	err := f.record("Events", "", lastEventID)
+	return &fakeStream{ctx: ctx, events: f.events, err: err}
+}
+
+func (f *Fake) Session() SessionService {
+	return fakeSessions{f}
+}
+
+type fakeStream struct {
+	ctx     context.Context
+	events  <-chan opencode.EventListResponse
+	current opencode.EventListResponse
+	count   int
+	err     error
+}
+
+func (s *fakeStream) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	select {
+	case <-s.ctx.Done():
+		return false
+	case event := <-s.events:
+		s.current = event
+		s.count++
+		return true
+	}
+}
+
+func (s *fakeStream) Current() opencode.EventListResponse { return s.current }
+func (s *fakeStream) Err() error                          { return s.err }
+func (s *fakeStream) Retry() time.Duration                { return 0 }
+func (s *fakeStream) Close() error                        { return nil }
+
+func (s *fakeStream) LastEventID() string {
+	if s.count == 0 {
+		return ""
+	}
+	return fmt.Sprint(s.count)
+}
+
+type fakeSessions struct {
+	f *Fake
+}
+
+func (s fakeSessions) find(sessionID string) (int, error) {
+	index := slices.IndexFunc(s.f.sessions, func(session opencode.Session) bool {
+		return session.ID == sessionID
+	})
+	if index < 0 {
+		return -1, fmt.Errorf("session %s not found", sessionID)
+	}
+	return index, nil
+}
+
+func (s fakeSessions) New(ctx context.Context, params opencode.SessionNewParams) (*opencode.Session, error) {
+	if err := s.f.record("Session.New", "", params); err != nil {
+		return nil, err
+	}
+	now := float64(time.Now().UnixMilli())
+	session := opencode.Session{
+		ID:       id.Descending(id.Session),
+		Title:    params.Title.Value,
+		ParentID: params.ParentID.Value,
+		Time:     opencode.SessionTime{Created: now, Updated: now},
+	}
+	s.f.AddSession(session)
+	return &session, nil
+}
+
+func (s fakeSessions) Update(ctx context.Context, sessionID string, params opencode.SessionUpdateParams) (*opencode.Session, error) {
+	if err := s.f.record("Session.Update", sessionID, params); err != nil {
+		return nil, err
+	}
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	index, err := s.find(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if params.Title.Present {
+		s.f.sessions[index].Title = params.Title.Value
+	}
+	session := s.f.sessions[index]
+	return &session, nil
+}
+
+func (s fakeSessions) List(ctx context.Context, query opencode.SessionListParams) (*[]opencode.Session, error) {
+	if err := s.f.record("Session.List", "", query); err != nil {
+		return nil, err
+	}
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	sessions := slices.Clone(s.f.sessions)
+	return &sessions, nil
+}
+
+func (s fakeSessions) Delete(ctx context.Context, sessionID string, body opencode.SessionDeleteParams) (*bool, error) {
+	if err := s.f.record("Session.Delete", sessionID, body); err != nil {
+		return nil, err
+	}
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	index, err := s.find(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	s.f.sessions = slices.Delete(s.f.sessions, index, index+1)
+	delete(s.f.messages, sessionID)
+	return ok()
+}
+
+func (s fakeSessions) Abort(ctx context.Context, sessionID string, body opencode.SessionAbortParams) (*bool, error) {
+	return s.acknowledge("Session.Abort", sessionID, body)
+}
+
+func (s fakeSessions) Init(ctx context.Context, sessionID string, params opencode.SessionInitParams) (*bool, error) {
+	return s.acknowledge("Session.Init", sessionID, params)
+}
+
+func (s fakeSessions) Summarize(ctx context.Context, sessionID string, params opencode.SessionSummarizeParams) (*bool, error) {
+	return s.acknowledge("Session.Summarize", sessionID, params)
+}
+
+// Prompt, Command and Shell only record the request: the real server answers
+// through the event stream, which tests drive with Send.
+func (s fakeSessions) Prompt(ctx context.Context, sessionID string, params opencode.SessionPromptParams) (*opencode.SessionPromptResponse, error) {
+	if err := s.f.record("Session.Prompt", sessionID, params); err != nil {
+		return nil, err
+	}
+	return &opencode.SessionPromptResponse{}, nil
+}
+
+func (s fakeSessions) Command(ctx context.Context, sessionID string, params opencode.SessionCommandParams) (*opencode.SessionCommandResponse, error) {
+	if err := s.f.record("Session.Command", sessionID, params); err != nil {
+		return nil, err
+	}
+	return &opencode.SessionCommandResponse{}, nil
+}
+
+func (s fakeSessions) Shell(ctx context.Context, sessionID string, params opencode.SessionShellParams) (*opencode.AssistantMessage, error) {
+	if err := s.f.record("Session.Shell", sessionID, params); err != nil {
+		return nil, err
+	}
+	return &opencode.AssistantMessage{SessionID: sessionID}, nil
+}
+
+func (s fakeSessions) Messages(ctx context.Context, sessionID string, query opencode.SessionMessagesParams) (*[]opencode.SessionMessagesResponse, error) {
+	if err := s.f.record("Session.Messages", sessionID, query); err != nil {
+		return nil, err
+	}
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	messages := slices.Clone(s.f.messages[sessionID])
+	return &messages, nil
+}
+
+func (s fakeSessions) acknowledge(method, sessionID string, params any) (*bool, error) {
+	if err := s.f.record(method, sessionID, params); err != nil {
+		return nil, err
+	}
+	return ok()
+}
+
+func ok() (*bool, error) {
+	result := true
+	return &result, nil
+}
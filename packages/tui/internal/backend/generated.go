@@ -0,0 +1,207 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode-api-go/api"
+)
+
+// GeneratedBackend sends session calls through the client generated from the
+// OpenAPI spec. Calls that have not been migrated yet still go through the
+// hand-written services. Results are converted to the hand-written types
+// here, so the app sees the same values from either backend.
+type GeneratedBackend struct {
+	*StainlessBackend
+	sessions generatedSessions
+}
+
+// NewGenerated returns a backend that sends session calls to serverURL
+// through httpClient, and everything else through client.
+func NewGenerated(client *opencode.Client, serverURL string, httpClient *http.Client) (*GeneratedBackend, error) {
+	generated, err := api.NewClient(serverURL, api.WithClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create generated client: %w", err)
+	}
+	return &GeneratedBackend{
+		StainlessBackend: NewStainless(client),
+		sessions:         generatedSessions{generated},
+	}, nil
+}
+
+func (b *GeneratedBackend) Session() SessionService {
+	return b.sessions
+}
+
+// convert maps v to T by way of JSON. The generated and hand-written types
+// share their wire format, and messages and parts are unions on both sides,
+// so this keeps every variant and field without a mapping per type.
+func convert[T any](v any) (*T, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out T
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// directory is the generated form of a directory query parameter.
+func directory(dir string) api.OptString {
+	if dir == "" {
+		return api.OptString{}
+	}
+	return api.NewOptString(dir)
+}
+
+type generatedSessions struct {
+	client *api.Client
+}
+
+func (s generatedSessions) New(ctx context.Context, params opencode.SessionNewParams) (*opencode.Session, error) {
+	req, err := convert[api.SessionCreateReq](params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map session: %w", err)
+	}
+	res, err := s.client.SessionCreate(ctx, api.NewOptSessionCreateReq(*req), api.SessionCreateParams{
+		Directory: directory(params.Directory.Value),
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch res := res.(type) {
+	case *api.Session:
+		return convert[opencode.Session](res)
+	case *api.Error:
+		data, _ := json.Marshal(res.Data)
+		return nil, fmt.Errorf("failed to create session: %s", data)
+	}
+	return nil, fmt.Errorf("unexpected response type %T", res)
+}
+
+func (s generatedSessions) Update(ctx context.Context, id string, params opencode.SessionUpdateParams) (*opencode.Session, error) {
+	req, err := convert[api.SessionUpdateReq](params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map session: %w", err)
+	}
+	res, err := s.client.SessionUpdate(ctx, api.NewOptSessionUpdateReq(*req), api.SessionUpdateParams{
+		ID:        id,
+		Directory: directory(params.Directory.Value),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convert[opencode.Session](res)
+}
+
+func (s generatedSessions) List(ctx context.Context, query opencode.SessionListParams) (*[]opencode.Session, error) {
+	res, err := s.client.SessionList(ctx, api.SessionListParams{Directory: directory(query.Directory.Value)})
+	if err != nil {
+		return nil, err
+	}
+	return convert[[]opencode.Session](res)
+}
+
+func (s generatedSessions) Delete(ctx context.Context, id string, body opencode.SessionDeleteParams) (*bool, error) {
+	res, err := s.client.SessionDelete(ctx, api.SessionDeleteParams{ID: id, Directory: directory(body.Directory.Value)})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s generatedSessions) Abort(ctx context.Context, id string, body opencode.SessionAbortParams) (*bool, error) {
+	res, err := s.client.SessionAbort(ctx, api.SessionAbortParams{ID: id, Directory: directory(body.Directory.Value)})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s generatedSessions) Init(ctx context.Context, id string, params opencode.SessionInitParams) (*bool, error) {
+	req, err := convert[api.SessionInitReq](params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map init request: %w", err)
+	}
+	res, err := s.client.SessionInit(ctx, api.NewOptSessionInitReq(*req), api.SessionInitParams{
+		ID:        id,
+		Directory: directory(params.Directory.Value),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s generatedSessions) Summarize(ctx context.Context, id string, params opencode.SessionSummarizeParams) (*bool, error) {
+	req, err := convert[api.SessionSummarizeReq](params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map summarize request: %w", err)
+	}
+	res, err := s.client.SessionSummarize(ctx, api.NewOptSessionSummarizeReq(*req), api.SessionSummarizeParams{
+		ID:        id,
+		Directory: directory(params.Directory.Value),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s generatedSessions) Prompt(ctx context.Context, id string, params opencode.SessionPromptParams) (*opencode.SessionPromptResponse, error) {
+	req, err := convert[api.SessionPromptReq](params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map prompt: %w", err)
+	}
+	res, err := s.client.SessionPrompt(ctx, api.NewOptSessionPromptReq(*req), api.SessionPromptParams{
+		ID:        id,
+		Directory: directory(params.Directory.Value),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convert[opencode.SessionPromptResponse](res)
+}
+
+func (s generatedSessions) Command(ctx context.Context, id string, params opencode.SessionCommandParams) (*opencode.SessionCommandResponse, error) {
+	req, err := convert[api.SessionCommandReq](params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map command: %w", err)
+	}
+	res, err := s.client.SessionCommand(ctx, api.NewOptSessionCommandReq(*req), api.SessionCommandParams{
+		ID:        id,
+		Directory: directory(params.Directory.Value),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convert[opencode.SessionCommandResponse](res)
+}
+
+func (s generatedSessions) Shell(ctx context.Context, id string, params opencode.SessionShellParams) (*opencode.AssistantMessage, error) {
+	req, err := convert[api.SessionShellReq](params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map shell command: %w", err)
+	}
+	res, err := s.client.SessionShell(ctx, api.NewOptSessionShellReq(*req), api.SessionShellParams{
+		ID:        id,
+		Directory: directory(params.Directory.Value),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convert[opencode.AssistantMessage](res)
+}
+
+func (s generatedSessions) Messages(ctx context.Context, id string, query opencode.SessionMessagesParams) (*[]opencode.SessionMessagesResponse, error) {
+	res, err := s.client.SessionMessages(ctx, api.SessionMessagesParams{ID: id, Directory: directory(query.Directory.Value)})
+	if err != nil {
+		return nil, err
+	}
+	return convert[[]opencode.SessionMessagesResponse](res)
+}
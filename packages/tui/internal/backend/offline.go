@@ -0,0 +1,33 @@
+package backend
+
+import (
+	"errors"
+	"net/http"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode-api-go/option"
+)
+
+// ErrOffline fails every request of an offline client.
+var ErrOffline = errors.New("not connected to an opencode server")
+
+// OfflineClient returns a client that fails every request with ErrOffline
+// without touching the network, for apps opened without a server.
+func OfflineClient() *opencode.Client {
+	return opencode.NewClient(
+		option.WithBaseURL("http://offline.invalid/"),
+		option.WithHTTPClient(&http.Client{Transport: offlineTransport{}}),
+		option.WithMaxRetries(0),
+	)
+}
+
+// NewOffline returns a backend on top of OfflineClient.
+func NewOffline() *StainlessBackend {
+	return NewStainless(OfflineClient())
+}
+
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, ErrOffline
+}
@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"context"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode-api-go/option"
+)
+
+// StainlessBackend calls the hand-written client services.
+type StainlessBackend struct {
+	client *opencode.Client
+}
+
+func NewStainless(client *opencode.Client) *StainlessBackend {
+	return &StainlessBackend{client: client}
+}
+
+func (b *StainlessBackend) Config(ctx context.Context) (*opencode.Config, error) {
+	return b.client.Config.Get(ctx, opencode.ConfigGetParams{})
+}
+
+func (b *StainlessBackend) Commands(ctx context.Context) ([]opencode.Command, error) {
+	return deref(b.client.Command.List(ctx, opencode.CommandListParams{}))
+}
+
+func (b *StainlessBackend) Providers(ctx context.Context) (*opencode.AppProvidersResponse, error) {
+	return b.client.App.Providers(ctx, opencode.AppProvidersParams{})
+}
+
+func (b *StainlessBackend) Agents(ctx context.Context) ([]opencode.Agent, error) {
+	return deref(b.client.Agent.List(ctx, opencode.AgentListParams{}))
+}
+
+func (b *StainlessBackend) FindFiles(ctx context.Context, query string) ([]string, error) {
+	return deref(b.client.Find.Files(ctx, opencode.FindFilesParams{Query: opencode.F(query)}))
+}
+
+func (b *StainlessBackend) FindSymbols(ctx context.Context, query string) ([]opencode.Symbol, error) {
+	return deref(b.client.Find.Symbols(ctx, opencode.FindSymbolsParams{Query: opencode.F(query)}))
+}
+
+func (b *StainlessBackend) FileStatus(ctx context.Context) ([]opencode.File, error) {
+	return deref(b.client.File.Status(ctx, opencode.FileStatusParams{}))
+}
+
+func (b *StainlessBackend) Events(ctx context.Context, lastEventID string) EventStream {
+	var opts []option.RequestOption
+	if lastEventID != "" {
+		opts = append(opts, option.WithHeader("Last-Event-ID", lastEventID))
+	}
+	return b.client.Event.ListStreaming(ctx, opencode.EventListParams{}, opts...)
+}
+
+func (b *StainlessBackend) Session() SessionService {
+	return stainlessSessions{b.client.Session}
+}
+
+// deref turns the SDK's pointer-to-slice results into plain slices.
+func deref[T any](res *[]T, err error) ([]T, error) {
+	if err != nil || res == nil {
+		return nil, err
+	}
+	return *res, nil
+}
+
+// stainlessSessions drops the per-request options the service methods take.
+type stainlessSessions struct {
+	s *opencode.SessionService
+}
+
+func (s stainlessSessions) New(ctx context.Context, params opencode.SessionNewParams) (*opencode.Session, error) {
+	return s.s.New(ctx, params)
+}
+
+func (s stainlessSessions) Update(ctx context.Context, id string, params opencode.SessionUpdateParams) (*opencode.Session, error) {
+	return s.s.Update(ctx, id, params)
+}
+
+func (s stainlessSessions) List(ctx context.Context, query opencode.SessionListParams) (*[]opencode.Session, error) {
+	return s.s.List(ctx, query)
+}
+
+func (s stainlessSessions) Delete(ctx context.Context, id string, body opencode.SessionDeleteParams) (*bool, error) {
+	return s.s.Delete(ctx, id, body)
+}
+
+func (s stainlessSessions) Abort(ctx context.Context, id string, body opencode.SessionAbortParams) (*bool, error) {
+	return s.s.Abort(ctx, id, body)
+}
+
+func (s stainlessSessions) Init(ctx context.Context, id string, params opencode.SessionInitParams) (*bool, error) {
+	return s.s.Init(ctx, id, params)
+}
+
+func (s stainlessSessions) Summarize(ctx context.Context, id string, params opencode.SessionSummarizeParams) (*bool, error) {
+	return s.s.Summarize(ctx, id, params)
+}
+
+func (s stainlessSessions) Prompt(ctx context.Context, id string, params opencode.SessionPromptParams) (*opencode.SessionPromptResponse, error) {
+	return s.s.Prompt(ctx, id, params)
+}
+
+func (s stainlessSessions) Command(ctx context.Context, id string, params opencode.SessionCommandParams) (*opencode.SessionCommandResponse, error) {
+	return s.s.Command(ctx, id, params)
+}
+
+func (s stainlessSessions) Shell(ctx context.Context, id string, params opencode.SessionShellParams) (*opencode.AssistantMessage, error) {
+	return s.s.Shell(ctx, id, params)
+}
+
+func (s stainlessSessions) Messages(ctx context.Context, id string, query opencode.SessionMessagesParams) (*[]opencode.SessionMessagesResponse, error) {
+	return s.s.Messages(ctx, id, query)
+}
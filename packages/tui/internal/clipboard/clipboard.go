@@ -52,6 +52,11 @@ clipboard data is changed, use the watcher API:
 		// print out clipboard data whenever it is changed
 		println(string(data))
 	}
+
+Over SSH or without a display server, the system clipboard is out of reach
+and Read and Write do nothing. Active then reports BackendOSC52, and the
+terminal's clipboard is set by writing the sequence from OSC52Write to the
+terminal. SetBackend forces either backend.
 */
 package clipboard
 
@@ -113,6 +118,10 @@ func Init() error {
 // Read returns a chunk of bytes of the clipboard data if it presents
 // in the desired format t presents. Otherwise, it returns nil.
 func Read(t Format) []byte {
+	if Active() == BackendOSC52 {
+		return nil
+	}
+
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -133,6 +142,10 @@ func Read(t Format) []byte {
 // If format t indicates an image, then the given buf assumes
 // the image data is PNG encoded.
 func Write(t Format, buf []byte) <-chan struct{} {
+	if Active() == BackendOSC52 {
+		return nil
+	}
+
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -0,0 +1,145 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Backend chooses how the clipboard is reached.
+type Backend string
+
+const (
+	// BackendAuto uses the system clipboard when a display server is
+	// reachable and OSC 52 otherwise, such as over SSH or in a container.
+	BackendAuto Backend = "auto"
+	// BackendSystem only uses the platform clipboard tools.
+	BackendSystem Backend = "system"
+	// BackendOSC52 only asks the terminal to set the clipboard through the
+	// OSC 52 escape sequence.
+	BackendOSC52 Backend = "osc52"
+)
+
+// maxOSC52Size caps the bytes sent in one OSC 52 sequence. Terminals drop
+// longer sequences, often silently; hterm and tmux stop at about 100000
+// encoded bytes, which is this much before base64.
+const maxOSC52Size = 74994
+
+// ErrTooLarge is returned for payloads over the OSC 52 size cap.
+var ErrTooLarge = errors.New("clipboard: too large for OSC 52")
+
+var (
+	backendMu sync.Mutex
+	backend   = BackendAuto
+)
+
+// SetBackend forces a backend. An empty name means BackendAuto.
+func SetBackend(b Backend) error {
+	switch b {
+	case "":
+		b = BackendAuto
+	case BackendAuto, BackendSystem, BackendOSC52:
+	default:
+		return fmt.Errorf("clipboard: unknown backend %q, expected auto, system or osc52", b)
+	}
+	backendMu.Lock()
+	backend = b
+	backendMu.Unlock()
+	return nil
+}
+
+// Active returns the backend in use, BackendSystem or BackendOSC52. With
+// BackendAuto it waits for Init.
+func Active() Backend {
+	backendMu.Lock()
+	b := backend
+	backendMu.Unlock()
+	if b != BackendAuto {
+		return b
+	}
+	if !displayAvailable() || Init() != nil {
+		return BackendOSC52
+	}
+	return BackendSystem
+}
+
+// Forced reports whether a backend other than BackendAuto was set.
+func Forced() bool {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	return backend != BackendAuto
+}
+
+// displayAvailable reports whether the system clipboard belongs to the user
+// at the keyboard. Over SSH it would be the remote machine's clipboard.
+func displayAvailable() bool {
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == ""
+	default:
+		return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	}
+}
+
+// OSC52Write returns the escape sequence that sets the terminal's clipboard
+// to buf, wrapped for tmux or screen when running inside one. The sequence
+// must be written to the terminal by whoever owns its output.
+func OSC52Write(buf []byte) (string, error) {
+	if len(buf) > maxOSC52Size {
+		return "", fmt.Errorf("%w: %d bytes, the limit is %d", ErrTooLarge, len(buf), maxOSC52Size)
+	}
+	return osc52(base64.StdEncoding.EncodeToString(buf), currentMultiplexer()), nil
+}
+
+// OSC52Read returns the escape sequence asking the terminal for its
+// clipboard. Terminals that allow it answer with an OSC 52 sequence of
+// their own; most ignore the request.
+func OSC52Read() string {
+	return osc52("?", currentMultiplexer())
+}
+
+type multiplexer int
+
+const (
+	noMultiplexer multiplexer = iota
+	tmux
+	screen
+)
+
+func currentMultiplexer() multiplexer {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return tmux
+	case os.Getenv("STY") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		return screen
+	}
+	return noMultiplexer
+}
+
+// screenChunk is the longest string screen passes through in one DCS
+// sequence, less room for the wrapping.
+const screenChunk = 76
+
+func osc52(payload string, mux multiplexer) string {
+	// BEL rather than ST terminates the sequence, since ST would also end
+	// the passthrough wrapping
+	seq := "\x1b]52;c;" + payload + "\a"
+	switch mux {
+	case tmux:
+		// tmux needs allow-passthrough for this, and escapes inside doubled
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case screen:
+		var b strings.Builder
+		for len(seq) > 0 {
+			n := min(screenChunk, len(seq))
+			b.WriteString("\x1bP" + seq[:n] + "\x1b\\")
+			seq = seq[n:]
+		}
+		return b.String()
+	}
+	return seq
+}
@@ -0,0 +1,66 @@
+package clipboard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOSC52Passthrough(t *testing.T) {
+	plain := osc52("aGk=", noMultiplexer)
+	if plain != "\x1b]52;c;aGk=\a" {
+		t.Errorf("plain sequence = %q", plain)
+	}
+
+	wrapped := osc52("aGk=", tmux)
+	if wrapped != "\x1bPtmux;\x1b\x1b]52;c;aGk=\a\x1b\\" {
+		t.Errorf("tmux sequence = %q", wrapped)
+	}
+
+	payload := strings.Repeat("A", 200)
+	chunked := osc52(payload, screen)
+	chunks := strings.Split(strings.TrimSuffix(chunked, "\x1b\\"), "\x1b\\")
+	var joined strings.Builder
+	for _, chunk := range chunks {
+		if !strings.HasPrefix(chunk, "\x1bP") || len(chunk)-2 > screenChunk {
+			t.Fatalf("bad screen chunk %q", chunk)
+		}
+		joined.WriteString(strings.TrimPrefix(chunk, "\x1bP"))
+	}
+	if joined.String() != osc52(payload, noMultiplexer) {
+		t.Error("screen chunks do not join back into the sequence")
+	}
+}
+
+func TestOSC52WriteCapsSize(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("STY", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	if _, err := OSC52Write(make([]byte, maxOSC52Size+1)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	sequence, err := OSC52Write([]byte("hi"))
+	if err != nil || sequence != "\x1b]52;c;aGk=\a" {
+		t.Errorf("sequence = %q, %v", sequence, err)
+	}
+	if OSC52Read() != "\x1b]52;c;?\a" {
+		t.Errorf("read sequence = %q", OSC52Read())
+	}
+}
+
+func TestSetBackend(t *testing.T) {
+	defer SetBackend(BackendAuto)
+	if err := SetBackend("clippy"); err == nil {
+		t.Error("expected an unknown backend to be rejected")
+	}
+	if err := SetBackend(BackendOSC52); err != nil {
+		t.Fatal(err)
+	}
+	if !Forced() || Active() != BackendOSC52 {
+		t.Error("expected the forced backend to be active")
+	}
+	if Read(FmtText) != nil || Write(FmtText, []byte("x")) != nil {
+		t.Error("expected the system clipboard to be left alone under OSC52")
+	}
+}
@@ -32,6 +32,9 @@ type Command struct {
 	Keybindings []Keybinding
 	Trigger     []string
 	Custom      bool
+	// Args holds the text typed after the trigger when the command is run
+	// from the prompt, e.g. "json out.json" for "/export json out.json".
+	Args string
 }
 
 func (c Command) Keys() []string {
@@ -104,6 +107,16 @@ func (r CommandRegistry) Sorted() []Command {
 	return commands
 }
 
+// FindByTrigger returns the command with the given trigger.
+func (r CommandRegistry) FindByTrigger(trigger string) (Command, bool) {
+	for _, command := range r {
+		if command.MatchesTrigger(trigger) {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
+
 func (r CommandRegistry) Matches(msg tea.KeyPressMsg, leader bool) []Command {
 	var matched []Command
 	for _, command := range r.Sorted() {
@@ -132,6 +145,7 @@ const (
 	SessionInterruptCommand         CommandName = "session_interrupt"
 	SessionCompactCommand           CommandName = "session_compact"
 	SessionExportCommand            CommandName = "session_export"
+	SessionImportCommand            CommandName = "session_import"
 	ToolDetailsCommand              CommandName = "tool_details"
 	ThinkingBlocksCommand           CommandName = "thinking_blocks"
 	ModelListCommand                CommandName = "model_list"
@@ -147,6 +161,9 @@ const (
 	InputPasteCommand               CommandName = "input_paste"
 	InputSubmitCommand              CommandName = "input_submit"
 	InputNewlineCommand             CommandName = "input_newline"
+	InputUndoCommand                CommandName = "input_undo"
+	InputRedoCommand                CommandName = "input_redo"
+	InputHistorySearchCommand       CommandName = "input_history_search"
 	MessagesPageUpCommand           CommandName = "messages_page_up"
 	MessagesPageDownCommand         CommandName = "messages_page_down"
 	MessagesHalfPageUpCommand       CommandName = "messages_half_page_up"
@@ -156,9 +173,12 @@ const (
 	MessagesFirstCommand            CommandName = "messages_first"
 	MessagesLastCommand             CommandName = "messages_last"
 	MessagesLayoutToggleCommand     CommandName = "messages_layout_toggle"
+	MessagesSearchCommand           CommandName = "messages_search"
 	MessagesCopyCommand             CommandName = "messages_copy"
 	MessagesUndoCommand             CommandName = "messages_undo"
 	MessagesRedoCommand             CommandName = "messages_redo"
+	PermissionListCommand           CommandName = "permission_list"
+	AppLogsCommand                  CommandName = "app_logs"
 	AppExitCommand                  CommandName = "app_exit"
 	SSEDebugCommand                 CommandName = "sse_debug"
 )
@@ -207,10 +227,16 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 		},
 		{
 			Name:        SessionExportCommand,
-			Description: "export conversation",
+			Description: "export conversation (/export [markdown|json|html] [file])",
 			Keybindings: parseBindings("<leader>x"),
 			Trigger:     []string{"export"},
 		},
+		{
+			Name:        SessionImportCommand,
+			Description: "open exported transcript (/import <file.json> [replay [speed]])",
+			Keybindings: parseBindings("none"),
+			Trigger:     []string{"import"},
+		},
 		{
 			Name:        SessionNewCommand,
 			Description: "new session",
@@ -337,6 +363,21 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Description: "insert newline",
 			Keybindings: parseBindings("shift+enter", "ctrl+j"),
 		},
+		{
+			Name:        InputUndoCommand,
+			Description: "undo edit",
+			Keybindings: parseBindings("alt+z"),
+		},
+		{
+			Name:        InputRedoCommand,
+			Description: "redo edit",
+			Keybindings: parseBindings("alt+y"),
+		},
+		{
+			Name:        InputHistorySearchCommand,
+			Description: "search prompt history",
+			Keybindings: parseBindings("ctrl+r"),
+		},
 		{
 			Name:        MessagesPageUpCommand,
 			Description: "page up",
@@ -357,7 +398,16 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Description: "half page down",
 			Keybindings: parseBindings("ctrl+alt+d"),
 		},
-
+		{
+			Name:        MessagesPreviousCommand,
+			Description: "focus previous block",
+			Keybindings: parseBindings("ctrl+up"),
+		},
+		{
+			Name:        MessagesNextCommand,
+			Description: "focus next block",
+			Keybindings: parseBindings("ctrl+down"),
+		},
 		{
 			Name:        MessagesFirstCommand,
 			Description: "first message",
@@ -369,6 +419,12 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Keybindings: parseBindings("ctrl+alt+g"),
 		},
 
+		{
+			Name:        MessagesSearchCommand,
+			Description: "search conversation",
+			Keybindings: parseBindings("<leader>f"),
+			Trigger:     []string{"search", "find"},
+		},
 		{
 			Name:        MessagesCopyCommand,
 			Description: "copy message",
@@ -386,6 +442,18 @@ func LoadFromConfig(config *opencode.Config, customCommands []opencode.Command)
 			Keybindings: parseBindings("<leader>r"),
 			Trigger:     []string{"redo"},
 		},
+		{
+			Name:        PermissionListCommand,
+			Description: "review permission requests and policy history",
+			Keybindings: parseBindings("<leader>p"),
+			Trigger:     []string{"permissions"},
+		},
+		{
+			Name:        AppLogsCommand,
+			Description: "show tui logs",
+			Keybindings: parseBindings("<leader>o"),
+			Trigger:     []string{"logs"},
+		},
 		{
 			Name:        AppExitCommand,
 			Description: "exit the app",
@@ -30,19 +30,12 @@ func (cg *agentsContextGroup) GetChildEntries(
 
 	query = strings.TrimSpace(query)
 
-	agents, err := cg.app.Client.Agent.List(
-		context.Background(),
-		opencode.AgentListParams{},
-	)
+	agents, err := cg.app.Backend.Agents(context.Background())
 	if err != nil {
 		slog.Error("Failed to get agent list", "error", err)
 		return items, err
 	}
-	if agents == nil {
-		return items, nil
-	}
-
-	for _, agent := range *agents {
+	for _, agent := range agents {
 		if query != "" && !strings.Contains(strings.ToLower(agent.Name), strings.ToLower(query)) {
 			continue
 		}
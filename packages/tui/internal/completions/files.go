@@ -7,7 +7,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/sst/opencode-api-go"
 	"github.com/sst/opencode/internal/app"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
@@ -29,35 +28,32 @@ func (cg *filesContextGroup) GetEmptyMessage() string {
 func (cg *filesContextGroup) getGitFiles() []CompletionSuggestion {
 	items := make([]CompletionSuggestion, 0)
 
-	status, _ := cg.app.Client.File.Status(context.Background(), opencode.FileStatusParams{})
-	if status != nil {
-		files := *status
-		sort.Slice(files, func(i, j int) bool {
-			return files[i].Added+files[i].Removed > files[j].Added+files[j].Removed
-		})
-
-		for _, file := range files {
-			displayFunc := func(s styles.Style) string {
-				t := theme.CurrentTheme()
-				green := s.Foreground(t.Success()).Render
-				red := s.Foreground(t.Error()).Render
-				display := file.Path
-				if file.Added > 0 {
-					display += green(" +" + strconv.Itoa(int(file.Added)))
-				}
-				if file.Removed > 0 {
-					display += red(" -" + strconv.Itoa(int(file.Removed)))
-				}
-				return display
+	files, _ := cg.app.Backend.FileStatus(context.Background())
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Added+files[i].Removed > files[j].Added+files[j].Removed
+	})
+
+	for _, file := range files {
+		displayFunc := func(s styles.Style) string {
+			t := theme.CurrentTheme()
+			green := s.Foreground(t.Success()).Render
+			red := s.Foreground(t.Error()).Render
+			display := file.Path
+			if file.Added > 0 {
+				display += green(" +" + strconv.Itoa(int(file.Added)))
 			}
-			item := CompletionSuggestion{
-				Display:    displayFunc,
-				Value:      file.Path,
-				ProviderID: cg.GetId(),
-				RawData:    file,
+			if file.Removed > 0 {
+				display += red(" -" + strconv.Itoa(int(file.Removed)))
 			}
-			items = append(items, item)
+			return display
 		}
+		item := CompletionSuggestion{
+			Display:    displayFunc,
+			Value:      file.Path,
+			ProviderID: cg.GetId(),
+			RawData:    file,
+		}
+		items = append(items, item)
 	}
 
 	return items
@@ -73,19 +69,12 @@ func (cg *filesContextGroup) GetChildEntries(
 		items = append(items, cg.gitFiles...)
 	}
 
-	files, err := cg.app.Client.Find.Files(
-		context.Background(),
-		opencode.FindFilesParams{Query: opencode.F(query)},
-	)
+	files, err := cg.app.Backend.FindFiles(context.Background(), query)
 	if err != nil {
 		slog.Error("Failed to get completion items", "error", err)
 		return items, err
 	}
-	if files == nil {
-		return items, nil
-	}
-
-	for _, file := range *files {
+	for _, file := range files {
 		exists := false
 		for _, existing := range cg.gitFiles {
 			if existing.Value == file {
@@ -6,7 +6,6 @@ import (
 	"log/slog"
 	"strings"
 
-	"github.com/sst/opencode-api-go"
 	"github.com/sst/opencode/internal/app"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
@@ -65,19 +64,12 @@ func (cg *symbolsContextGroup) GetChildEntries(
 		return items, nil
 	}
 
-	symbols, err := cg.app.Client.Find.Symbols(
-		context.Background(),
-		opencode.FindSymbolsParams{Query: opencode.F(query)},
-	)
+	symbols, err := cg.app.Backend.FindSymbols(context.Background(), query)
 	if err != nil {
 		slog.Error("Failed to get symbol completion items", "error", err)
 		return items, err
 	}
-	if symbols == nil {
-		return items, nil
-	}
-
-	for _, sym := range *symbols {
+	for _, sym := range symbols {
 		parts := strings.Split(sym.Name, ".")
 		lastPart := parts[len(parts)-1]
 		start := int(sym.Location.Range.Start.Line)
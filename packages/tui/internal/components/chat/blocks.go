@@ -0,0 +1,202 @@
+package chat
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/toast"
+)
+
+// blockState is a block's own display choice, overriding the global tool
+// details toggle.
+type blockState int
+
+const (
+	blockDefault blockState = iota
+	blockExpanded
+	blockCollapsed
+)
+
+// messageBlock is one rendered block of the conversation.
+type messageBlock struct {
+	content string
+	// partID is empty for blocks that can't be focused, such as errors
+	partID string
+	// copy is the text copied from the focused block
+	copy  string
+	tool  bool
+	state blockState
+	// folded lists the tool calls summarized under a text block while tool
+	// details are hidden
+	folded []string
+	// start and end are the block's lines in the viewport, end exclusive
+	start, end int
+}
+
+func (m *messagesComponent) setBlocks(blocks []messageBlock) {
+	m.blocks = blocks
+	if m.focusedPart != "" && m.focusedIndex() < 0 {
+		m.focusedPart = ""
+	}
+}
+
+// resetBlocks forgets the display choices and the focus made in the session
+// being left.
+func (m *messagesComponent) resetBlocks() {
+	m.blockStates = make(map[string]blockState)
+	m.focusedPart = ""
+}
+
+func (m *messagesComponent) BlockFocused() bool {
+	return m.focusedPart != ""
+}
+
+func (m *messagesComponent) focusedIndex() int {
+	return slices.IndexFunc(m.blocks, func(block messageBlock) bool {
+		return block.partID != "" && block.partID == m.focusedPart
+	})
+}
+
+// FocusPreviousBlock moves the block cursor up, starting from the last block
+// on screen.
+func (m *messagesComponent) FocusPreviousBlock() (tea.Model, tea.Cmd) {
+	from := m.focusedIndex()
+	if from < 0 {
+		from = len(m.blocks)
+		for i, block := range m.blocks {
+			if block.start >= m.viewport.YOffset+m.viewport.Height() {
+				from = i
+				break
+			}
+		}
+	}
+	for i := from - 1; i >= 0; i-- {
+		if m.blocks[i].partID != "" {
+			return m, m.focusBlock(i)
+		}
+	}
+	return m, nil
+}
+
+// FocusNextBlock moves the block cursor down, starting from the first block
+// on screen.
+func (m *messagesComponent) FocusNextBlock() (tea.Model, tea.Cmd) {
+	from := m.focusedIndex()
+	if from < 0 {
+		from = len(m.blocks) - 1
+		for i, block := range m.blocks {
+			if block.end > m.viewport.YOffset {
+				from = i - 1
+				break
+			}
+		}
+	}
+	for i := from + 1; i < len(m.blocks); i++ {
+		if m.blocks[i].partID != "" {
+			return m, m.focusBlock(i)
+		}
+	}
+	return m, nil
+}
+
+// focusBlock moves the cursor to a block, scrolling it into view: its top
+// when it doesn't fit, otherwise as little as needed.
+func (m *messagesComponent) focusBlock(i int) tea.Cmd {
+	block := m.blocks[i]
+	m.focusedPart = block.partID
+	height := m.viewport.Height()
+	switch {
+	case block.start < m.viewport.YOffset || block.end-block.start > height:
+		m.viewport.SetYOffset(block.start)
+	case block.end > m.viewport.YOffset+height:
+		m.viewport.SetYOffset(block.end - height)
+	}
+	m.tail = m.viewport.AtBottom()
+	return m.renderView()
+}
+
+// UpdateFocusedBlock handles a key press while a block is focused, reporting
+// whether the key was used. Keys that aren't return focus to the editor.
+func (m *messagesComponent) UpdateFocusedBlock(msg tea.KeyPressMsg) (tea.Model, tea.Cmd, bool) {
+	index := m.focusedIndex()
+	if index < 0 {
+		m.focusedPart = ""
+		return m, nil, false
+	}
+	block := m.blocks[index]
+
+	switch msg.String() {
+	case "up":
+		updated, cmd := m.FocusPreviousBlock()
+		return updated, cmd, true
+	case "down":
+		updated, cmd := m.FocusNextBlock()
+		return updated, cmd, true
+	case "enter", "space":
+		return m, m.toggleBlock(block), true
+	case "y":
+		if block.copy == "" {
+			return m, toast.NewInfoToast("Nothing to copy in this block"), true
+		}
+		return m, tea.Batch(
+			app.SetClipboard(block.copy),
+			toast.NewSuccessToast("Block copied to clipboard"),
+		), true
+	case "esc":
+		m.focusedPart = ""
+		return m, m.renderView(), true
+	}
+
+	m.focusedPart = ""
+	return m, m.renderView(), false
+}
+
+// toggleBlock collapses a tool block to its title or expands it to its full
+// output. On a text block it unfolds the tool calls summarized under it.
+func (m *messagesComponent) toggleBlock(block messageBlock) tea.Cmd {
+	switch {
+	case block.tool && block.state == blockCollapsed:
+		m.blockStates[block.partID] = blockExpanded
+	case block.tool:
+		m.blockStates[block.partID] = blockCollapsed
+	case len(block.folded) > 0:
+		for _, id := range block.folded {
+			m.blockStates[id] = blockExpanded
+		}
+	default:
+		return nil
+	}
+	return m.renderView()
+}
+
+// toolCopyText is what copying a tool block puts on the clipboard: the
+// command and its output for bash, the diff for edits, the output otherwise.
+func toolCopyText(part opencode.ToolPart) string {
+	input, _ := part.State.Input.(map[string]any)
+	metadata, _ := part.State.Metadata.(map[string]any)
+	switch part.Tool {
+	case "bash":
+		if command, ok := input["command"].(string); ok {
+			output := part.State.Output
+			if value, ok := metadata["output"]; ok && value != nil {
+				output = fmt.Sprintf("%s", value)
+			}
+			return "$ " + command + "\n" + output
+		}
+	case "edit":
+		if diff, ok := metadata["diff"].(string); ok {
+			return diff
+		}
+	case "write":
+		if content, ok := input["content"].(string); ok {
+			return content
+		}
+	}
+	if part.State.Output != "" {
+		return part.State.Output
+	}
+	return part.State.Error
+}
@@ -0,0 +1,173 @@
+package chat
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+)
+
+// testBlocks are laid out over 34 lines, the fourth taller than the 10 line
+// viewport of newTestMessages.
+var testBlocks = []messageBlock{
+	{partID: "text1", start: 0, end: 4},
+	{start: 4, end: 6}, // an error, which can't be focused
+	{partID: "tool1", tool: true, start: 6, end: 10},
+	{partID: "text2", start: 10, end: 30},
+	{partID: "tool2", tool: true, start: 30, end: 34},
+}
+
+func newTestMessages(offset int, focused string) *messagesComponent {
+	m := NewMessagesComponent(&app.App{State: app.NewState()}).(*messagesComponent)
+	m.viewport.SetWidth(80)
+	m.viewport.SetHeight(10)
+	m.viewport.SetContent(strings.Repeat("line\n", 33) + "line")
+	m.viewport.SetYOffset(offset)
+	m.setBlocks(testBlocks)
+	m.focusedPart = focused
+	m.tail = m.viewport.AtBottom()
+	return m
+}
+
+func TestFocusBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   int
+		focused  string
+		next     bool
+		want     string
+		wantYOff int
+	}{
+		{"next from the top", 0, "", true, "text1", 0},
+		{"next skips errors", 0, "text1", true, "tool1", 0},
+		{"next starts on screen", 12, "", true, "text2", 10},
+		{"next shows the top of a tall block", 0, "tool1", true, "text2", 10},
+		{"next scrolls to the end of a block", 10, "text2", true, "tool2", 24},
+		{"next stops at the last block", 24, "tool2", true, "tool2", 24},
+		{"previous from the top", 0, "", false, "tool1", 0},
+		{"previous from the bottom", 24, "", false, "tool2", 24},
+		{"previous scrolls up", 24, "tool2", false, "text2", 10},
+		{"previous skips errors", 0, "tool1", false, "text1", 0},
+		{"previous stops at the first block", 0, "text1", false, "text1", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMessages(tt.offset, tt.focused)
+			if tt.next {
+				m.FocusNextBlock()
+			} else {
+				m.FocusPreviousBlock()
+			}
+			if m.focusedPart != tt.want {
+				t.Errorf("focused %q, want %q", m.focusedPart, tt.want)
+			}
+			if m.viewport.YOffset != tt.wantYOff {
+				t.Errorf("scrolled to %d, want %d", m.viewport.YOffset, tt.wantYOff)
+			}
+			if m.tail != m.viewport.AtBottom() {
+				t.Errorf("tail is %v at offset %d", m.tail, m.viewport.YOffset)
+			}
+		})
+	}
+}
+
+func TestToggleBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		block messageBlock
+		want  map[string]blockState
+	}{
+		{"tool collapses", messageBlock{partID: "tool", tool: true}, map[string]blockState{"tool": blockCollapsed}},
+		{"expanded tool collapses", messageBlock{partID: "tool", tool: true, state: blockExpanded}, map[string]blockState{"tool": blockCollapsed}},
+		{"collapsed tool expands", messageBlock{partID: "tool", tool: true, state: blockCollapsed}, map[string]blockState{"tool": blockExpanded}},
+		{"text unfolds its tools", messageBlock{partID: "text", folded: []string{"a", "b"}}, map[string]blockState{"a": blockExpanded, "b": blockExpanded}},
+		{"plain text", messageBlock{partID: "text"}, map[string]blockState{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMessages(0, "")
+			cmd := m.toggleBlock(tt.block)
+			if !maps.Equal(m.blockStates, tt.want) {
+				t.Errorf("block states are %v, want %v", m.blockStates, tt.want)
+			}
+			if (cmd == nil) != (len(tt.want) == 0) {
+				t.Errorf("unexpected render command %v", cmd)
+			}
+		})
+	}
+}
+
+func TestSessionChangeResetsBlocks(t *testing.T) {
+	for _, msg := range []any{app.SessionLoadedMsg{}, app.SessionClearedMsg{}} {
+		m := newTestMessages(0, "tool1")
+		m.toggleBlock(testBlocks[2])
+		m.Update(msg)
+		if len(m.blockStates) != 0 || m.focusedPart != "" {
+			t.Errorf("%T kept block states %v and focus %q", msg, m.blockStates, m.focusedPart)
+		}
+	}
+}
+
+func TestToolCopyText(t *testing.T) {
+	tool := func(name string, input, metadata map[string]any, output, err string) opencode.ToolPart {
+		return opencode.ToolPart{
+			Tool: name,
+			State: opencode.ToolPartState{
+				Input:    input,
+				Metadata: metadata,
+				Output:   output,
+				Error:    err,
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		part opencode.ToolPart
+		want string
+	}{
+		{
+			"bash with streamed output",
+			tool("bash", map[string]any{"command": "ls"}, map[string]any{"output": "a\nb\n"}, "truncated", ""),
+			"$ ls\na\nb\n",
+		},
+		{
+			"bash without metadata",
+			tool("bash", map[string]any{"command": "ls"}, nil, "a\n", ""),
+			"$ ls\na\n",
+		},
+		{
+			"bash without a command",
+			tool("bash", nil, nil, "a\n", ""),
+			"a\n",
+		},
+		{
+			"edit",
+			tool("edit", map[string]any{"filePath": "main.go"}, map[string]any{"diff": "-a\n+b\n"}, "edited", ""),
+			"-a\n+b\n",
+		},
+		{
+			"edit without a diff",
+			tool("edit", map[string]any{"filePath": "main.go"}, nil, "", "file not found"),
+			"file not found",
+		},
+		{
+			"write",
+			tool("write", map[string]any{"filePath": "main.go", "content": "package main\n"}, nil, "", ""),
+			"package main\n",
+		},
+		{
+			"other tools",
+			tool("read", map[string]any{"filePath": "main.go"}, nil, "package main", ""),
+			"package main",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toolCopyText(tt.part); got != tt.want {
+				t.Errorf("toolCopyText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -43,11 +44,16 @@ type EditorComponent interface {
 	Clear() (tea.Model, tea.Cmd)
 	Paste() (tea.Model, tea.Cmd)
 	Newline() (tea.Model, tea.Cmd)
+	Undo() (tea.Model, tea.Cmd)
+	Redo() (tea.Model, tea.Cmd)
 	SetValue(value string)
 	SetValueWithAttachments(value string)
 	SetInterruptKeyInDebounce(inDebounce bool)
 	SetExitKeyInDebounce(inDebounce bool)
 	RestoreFromHistory(index int)
+	Prompt() app.Prompt
+	LoadDraft(prompt app.Prompt)
+	WantsKey(msg tea.KeyPressMsg) bool
 }
 
 type editorComponent struct {
@@ -84,14 +90,14 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "up", "ctrl+p":
 			// Only navigate history if cursor is at the first line and column (for arrow keys)
 			// or allow ctrl+p from anywhere
-			if (msg.String() == "ctrl+p" || (m.textarea.Line() == 0 && m.textarea.CursorColumn() == 0)) && len(m.app.State.MessageHistory) > 0 {
+			if (msg.String() == "ctrl+p" || (m.textarea.Line() == 0 && m.textarea.CursorColumn() == 0)) && len(m.history()) > 0 {
 				if m.historyIndex == -1 {
 					// Save current text before entering history
 					m.currentText = m.textarea.Value()
 					m.textarea.MoveToBegin()
 				}
 				// Move up in history (older messages)
-				if m.historyIndex < len(m.app.State.MessageHistory)-1 {
+				if m.historyIndex < len(m.history())-1 {
 					m.historyIndex++
 					m.RestoreFromHistory(m.historyIndex)
 					m.textarea.MoveToBegin()
@@ -106,7 +112,6 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.historyIndex--
 				if m.historyIndex == -1 {
 					// Restore current text
-					m.textarea.Reset()
 					m.textarea.SetValue(m.currentText)
 					m.currentText = ""
 				} else {
@@ -165,8 +170,7 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if _, err := os.Stat(statPath); err == nil {
 				attachment := m.createAttachmentFromPath(filePath)
 				if attachment != nil {
-					m.textarea.InsertAttachment(attachment)
-					m.textarea.InsertString(" ")
+					m.insertAttachment(attachment)
 					return m, nil
 				}
 			}
@@ -207,8 +211,7 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		m.textarea.InsertAttachment(attachment)
-		m.textarea.InsertString(" ")
+		m.insertAttachment(attachment)
 	case tea.ClipboardMsg:
 		text := string(msg)
 		// Check if the pasted text is long and should be summarized
@@ -221,7 +224,16 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.textarea = updateTextareaStyles(m.textarea)
 		m.spinner = createSpinner()
 		return m, tea.Batch(m.textarea.Focus(), m.spinner.Tick)
+	case dialog.PromptSelectedMsg:
+		m.RestoreFromPrompt(msg.Prompt)
+		m.textarea.MoveToEnd()
+		m.historyIndex = -1
+		m.currentText = ""
+		return m, nil
 	case dialog.CompletionSelectedMsg:
+		// Replacing the typed reference is undone at once
+		m.textarea.BeginChange()
+		defer m.textarea.EndChange()
 		switch msg.Item.ProviderID {
 		case "commands":
 			command := msg.Item.RawData.(commands.Command)
@@ -254,8 +266,7 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// The cursor is now at `atIndex` after the replacement.
 			filePath := msg.Item.Value
 			attachment := m.createAttachmentFromPath(filePath)
-			m.textarea.InsertAttachment(attachment)
-			m.textarea.InsertString(" ")
+			m.insertAttachment(attachment)
 			return m, nil
 		case "symbols":
 			atIndex := m.textarea.LastRuneIndex('@')
@@ -294,8 +305,7 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					},
 				},
 			}
-			m.textarea.InsertAttachment(attachment)
-			m.textarea.InsertString(" ")
+			m.insertAttachment(attachment)
 			return m, nil
 		case "agents":
 			atIndex := m.textarea.LastRuneIndex('@')
@@ -318,8 +328,7 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				},
 			}
 
-			m.textarea.InsertAttachment(attachment)
-			m.textarea.InsertString(" ")
+			m.insertAttachment(attachment)
 			return m, nil
 
 		default:
@@ -425,6 +434,12 @@ func (m *editorComponent) Cursor() *tea.Cursor {
 	return m.textarea.Cursor()
 }
 
+// WantsKey reports whether the editor's Vim mode takes a key ahead of the
+// application's own bindings.
+func (m *editorComponent) WantsKey(msg tea.KeyPressMsg) bool {
+	return m.textarea.WantsKey(msg)
+}
+
 func (m *editorComponent) View() string {
 	width := m.width
 	if m.app.Session.ID == "" {
@@ -476,12 +491,16 @@ func (m *editorComponent) Submit() (tea.Model, tea.Cmd) {
 
 	switch value {
 	case "exit", "quit", "q", ":q":
+		// Leave nothing behind to be kept as a draft
+		m.textarea.Reset()
 		return m, tea.Quit
 	}
 
 	if len(value) > 0 && value[len(value)-1] == '\\' {
 		// If the last character is a backslash, remove it and add a newline
 		backslashCol := m.textarea.CurrentRowLength() - 1
+		m.textarea.BeginChange()
+		defer m.textarea.EndChange()
 		m.textarea.ReplaceRange(backslashCol, backslashCol+1, "")
 		m.textarea.InsertString("\n")
 		return m, nil
@@ -516,12 +535,30 @@ func (m *editorComponent) Submit() (tea.Model, tea.Cmd) {
 
 			return m, tea.Batch(cmds...)
 		}
+
+		// Built-in commands that take arguments, e.g. "/export json out.json"
+		trigger, args, hasArgs := strings.Cut(expandedValue, " ")
+		if builtin, ok := m.app.Commands.FindByTrigger(trigger); ok && hasArgs && !builtin.Custom {
+			builtin.Args = strings.TrimSpace(args)
+			cmds = append(cmds, util.CmdHandler(commands.ExecuteCommandMsg(builtin)))
+
+			updated, cmd := m.Clear()
+			m = updated.(*editorComponent)
+			cmds = append(cmds, cmd)
+
+			return m, tea.Batch(cmds...)
+		}
+	}
+
+	if m.app.Transcript != "" {
+		return m, toast.NewWarningToast("This transcript is read-only. Start a new session to chat.")
 	}
 
 	attachments := m.textarea.GetAttachments()
 
 	prompt := app.Prompt{Text: value, Attachments: attachments}
-	m.app.State.AddPromptToHistory(prompt)
+	m.app.State.SetDraft(m.app.Project.Worktree, m.app.Session.ID, app.Prompt{})
+	m.app.State.AddPromptToHistory(m.app.Project.Worktree, prompt)
 	cmds = append(cmds, m.app.SaveState())
 
 	updated, cmd := m.Clear()
@@ -533,7 +570,11 @@ func (m *editorComponent) Submit() (tea.Model, tea.Cmd) {
 }
 
 func (m *editorComponent) SubmitBash() (tea.Model, tea.Cmd) {
+	if m.app.Transcript != "" {
+		return m, toast.NewWarningToast("This transcript is read-only. Start a new session to chat.")
+	}
 	command := m.textarea.Value()
+	m.app.State.SetDraft(m.app.Project.Worktree, m.app.Session.ID, app.Prompt{})
 	var cmds []tea.Cmd
 	updated, cmd := m.Clear()
 	m = updated.(*editorComponent)
@@ -569,8 +610,7 @@ func (m *editorComponent) Paste() (tea.Model, tea.Cmd) {
 				Data: imageBytes,
 			},
 		}
-		m.textarea.InsertAttachment(attachment)
-		m.textarea.InsertString(" ")
+		m.insertAttachment(attachment)
 		return m, nil
 	}
 
@@ -587,7 +627,7 @@ func (m *editorComponent) Paste() (tea.Model, tea.Cmd) {
 	}
 
 	// fallback to reading the clipboard using OSC52
-	return m, tea.ReadClipboard
+	return m, tea.Raw(clipboard.OSC52Read())
 }
 
 func (m *editorComponent) Newline() (tea.Model, tea.Cmd) {
@@ -595,6 +635,16 @@ func (m *editorComponent) Newline() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m *editorComponent) Undo() (tea.Model, tea.Cmd) {
+	m.textarea.Undo()
+	return m, nil
+}
+
+func (m *editorComponent) Redo() (tea.Model, tea.Cmd) {
+	m.textarea.Redo()
+	return m, nil
+}
+
 func (m *editorComponent) SetInterruptKeyInDebounce(inDebounce bool) {
 	m.interruptKeyInDebounce = inDebounce
 }
@@ -604,6 +654,8 @@ func (m *editorComponent) SetValue(value string) {
 }
 
 func (m *editorComponent) SetValueWithAttachments(value string) {
+	m.textarea.BeginChange()
+	defer m.textarea.EndChange()
 	m.textarea.Reset()
 
 	i := 0
@@ -695,8 +747,15 @@ func (m *editorComponent) handleLongPaste(text string) {
 		},
 	}
 
-	m.textarea.InsertAttachment(attachment)
+	m.insertAttachment(attachment)
+}
+
+// insertAttachment inserts an attachment followed by a space, as one undo step.
+func (m *editorComponent) insertAttachment(att *attachment.Attachment) {
+	m.textarea.BeginChange()
+	m.textarea.InsertAttachment(att)
 	m.textarea.InsertString(" ")
+	m.textarea.EndChange()
 }
 
 func updateTextareaStyles(ta textarea.Model) textarea.Model {
@@ -727,10 +786,34 @@ func updateTextareaStyles(ta textarea.Model) textarea.Model {
 		Foreground(t.Text()).
 		Background(t.Secondary()).
 		Lipgloss()
+	ta.Styles.Selection = styles.NewStyle().
+		Foreground(t.BackgroundElement()).
+		Background(t.Primary()).
+		Lipgloss()
 	ta.Styles.Cursor.Color = t.Primary()
 	return ta
 }
 
+// vimPromptWidth fits the longest mode name and a space on either side.
+const vimPromptWidth = 8
+
+// vimPrompt shows the Vim mode at the start of the input.
+func (m *editorComponent) vimPrompt(line int) string {
+	if line > 0 {
+		return ""
+	}
+	t := theme.CurrentTheme()
+	mode := m.textarea.Mode()
+	style := styles.NewStyle().Foreground(t.TextMuted()).Background(t.BackgroundElement())
+	switch mode {
+	case textarea.ModeNormal:
+		style = style.Foreground(t.Primary()).Bold(true)
+	case textarea.ModeVisual, textarea.ModeVisualLine:
+		style = style.Foreground(t.Accent()).Bold(true)
+	}
+	return style.Render(mode.String() + " ")
+}
+
 func createSpinner() spinner.Model {
 	t := theme.CurrentTheme()
 	return spinner.New(
@@ -763,41 +846,74 @@ func NewEditorComponent(app *app.App) EditorComponent {
 		historyIndex:           -1,
 		pasteCounter:           0,
 	}
+	if app.VimMode {
+		m.textarea.SetVimMode(true)
+		m.textarea.SetPromptFunc(vimPromptWidth, m.vimPrompt)
+	}
 
 	return m
 }
 
 func (m *editorComponent) RestoreFromPrompt(prompt app.Prompt) {
+	m.textarea.BeginChange()
+	defer m.textarea.EndChange()
 	m.textarea.Reset()
-	m.textarea.SetValue(prompt.Text)
-
-	// Sort attachments by start index in reverse order (process from end to beginning)
-	// This prevents index shifting issues
-	attachmentsCopy := make([]*attachment.Attachment, len(prompt.Attachments))
-	copy(attachmentsCopy, prompt.Attachments)
 
-	for i := 0; i < len(attachmentsCopy)-1; i++ {
-		for j := i + 1; j < len(attachmentsCopy); j++ {
-			if attachmentsCopy[i].StartIndex < attachmentsCopy[j].StartIndex {
-				attachmentsCopy[i], attachmentsCopy[j] = attachmentsCopy[j], attachmentsCopy[i]
-			}
+	attachments := slices.Clone(prompt.Attachments)
+	slices.SortFunc(attachments, func(a, b *attachment.Attachment) int {
+		return a.StartIndex - b.StartIndex
+	})
+
+	// Attachment positions count runes, except that an attachment counts the
+	// bytes of its display text, and span lines
+	text := []rune(prompt.Text)
+	pos, offset := 0, 0
+	for _, att := range attachments {
+		start := att.StartIndex - offset
+		if start < pos || start > len(text) {
+			continue
 		}
+		m.textarea.InsertString(string(text[pos:start]))
+		m.textarea.InsertAttachment(att)
+		displayRunes := utf8.RuneCountInString(att.Display)
+		pos = min(start+displayRunes, len(text))
+		offset += len(att.Display) - displayRunes
 	}
+	m.textarea.InsertString(string(text[pos:]))
+}
 
-	for _, att := range attachmentsCopy {
-		m.textarea.SetCursorColumn(att.StartIndex)
-		m.textarea.ReplaceRange(att.StartIndex, att.EndIndex, "")
-		m.textarea.InsertAttachment(att)
+// Prompt returns the content of the editor with its attachments.
+func (m *editorComponent) Prompt() app.Prompt {
+	return app.Prompt{
+		Text:        m.textarea.Value(),
+		Attachments: m.textarea.GetAttachments(),
 	}
 }
 
+// LoadDraft replaces the content of the editor with the draft of the session
+// being opened. The undo history belongs to the session being left, so it
+// starts over.
+func (m *editorComponent) LoadDraft(prompt app.Prompt) {
+	m.RestoreFromPrompt(prompt)
+	m.textarea.MoveToEnd()
+	m.textarea.ClearHistory()
+	m.historyIndex = -1
+	m.currentText = ""
+	m.reverted = false
+}
+
 // RestoreFromHistory restores a message from history at the given index
 func (m *editorComponent) RestoreFromHistory(index int) {
-	if index < 0 || index >= len(m.app.State.MessageHistory) {
+	history := m.history()
+	if index < 0 || index >= len(history) {
 		return
 	}
-	entry := m.app.State.MessageHistory[index]
-	m.RestoreFromPrompt(entry)
+	m.RestoreFromPrompt(history[index])
+}
+
+// history returns the prompts sent in the current project, newest first.
+func (m *editorComponent) history() []app.Prompt {
+	return m.app.State.PromptHistory(m.app.Project.Worktree)
 }
 
 func getMediaTypeFromExtension(ext string) string {
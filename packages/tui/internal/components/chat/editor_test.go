@@ -0,0 +1,101 @@
+package chat
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/attachment"
+	"github.com/sst/opencode/internal/theme"
+)
+
+func TestMain(m *testing.M) {
+	if err := theme.LoadThemesFromJSON(); err != nil {
+		panic(err)
+	}
+	if err := theme.SetTheme("opencode"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestEditor() *editorComponent {
+	return NewEditorComponent(&app.App{}).(*editorComponent)
+}
+
+func fileAttachment(display string) *attachment.Attachment {
+	return &attachment.Attachment{
+		ID:       display,
+		Type:     "file",
+		Display:  display,
+		Filename: strings.TrimPrefix(display, "@"),
+		Source:   &attachment.FileSource{Path: strings.TrimPrefix(display, "@"), Mime: "text/plain"},
+	}
+}
+
+func TestRestoreFromPrompt(t *testing.T) {
+	tests := []struct {
+		name string
+		// parts are typed text and attachments, in order
+		parts []any
+	}{
+		{"plain", []any{"hello world"}},
+		{"multibyte", []any{"héllo 世界 🎉"}},
+		{"attachment first", []any{fileAttachment("@main.go"), " explain"}},
+		{"attachment after multibyte", []any{"日本語を読む ", fileAttachment("@main.go"), " と 🎉"}},
+		{"multibyte display", []any{"voir ", fileAttachment("@über/ñ.go"), " ok"}},
+		{"adjacent attachments", []any{"ü", fileAttachment("@α.go"), fileAttachment("@β.go"), "ß"}},
+		{"multiline", []any{
+			"first line ✓\n",
+			fileAttachment("@a.go"),
+			" and ",
+			fileAttachment("@straße.go"),
+			"\n🎉 last",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newTestEditor()
+			var text strings.Builder
+			for _, part := range tt.parts {
+				switch part := part.(type) {
+				case string:
+					source.textarea.InsertString(part)
+					text.WriteString(part)
+				case *attachment.Attachment:
+					source.textarea.InsertAttachment(part)
+					text.WriteString(part.Display)
+				}
+			}
+			want := source.Prompt()
+			if want.Text != text.String() {
+				t.Fatalf("editor holds %q, typed %q", want.Text, text.String())
+			}
+
+			editor := newTestEditor()
+			editor.RestoreFromPrompt(want)
+			got := editor.Prompt()
+			if got.Text != want.Text {
+				t.Errorf("restored text %q, want %q", got.Text, want.Text)
+			}
+			if len(got.Attachments) != len(want.Attachments) {
+				t.Fatalf("restored %d attachments, want %d", len(got.Attachments), len(want.Attachments))
+			}
+			for i, att := range got.Attachments {
+				w := want.Attachments[i]
+				if att.Display != w.Display || att.StartIndex != w.StartIndex || att.EndIndex != w.EndIndex {
+					t.Errorf("attachment %d is %s at %d-%d, want %s at %d-%d",
+						i, att.Display, att.StartIndex, att.EndIndex, w.Display, w.StartIndex, w.EndIndex)
+				}
+			}
+
+			// Restoring what was restored changes nothing
+			again := newTestEditor()
+			again.RestoreFromPrompt(got)
+			if again.Prompt().Text != want.Text {
+				t.Errorf("restoring twice gave %q", again.Prompt().Text)
+			}
+		})
+	}
+}
@@ -453,11 +453,14 @@ func renderText(
 	return ""
 }
 
+// renderToolDetails renders a tool call as its own block. Long output is cut
+// to a few lines unless expanded is set.
 func renderToolDetails(
 	app *app.App,
 	toolCall opencode.ToolPart,
 	permission opencode.Permission,
 	width int,
+	expanded bool,
 ) string {
 	measure := util.Measure("chat.renderToolDetails")
 	defer measure("tool", toolCall.Tool)
@@ -476,6 +479,13 @@ func renderToolDetails(
 		result = &toolCall.State.Output
 	}
 
+	truncate := func(content string, height int) string {
+		if expanded {
+			return content
+		}
+		return util.TruncateHeight(content, height)
+	}
+
 	toolInputMap := make(map[string]any)
 	if toolCall.State.Input != nil {
 		value := toolCall.State.Input
@@ -518,7 +528,15 @@ func renderToolDetails(
 		) + muted(
 			" reject",
 		)
-
+		if queued := len(app.Permissions); queued > 1 {
+			if keybind := app.Keybind(commands.PermissionListCommand); keybind != "" {
+				permissionContent += text(
+					"   "+keybind,
+				) + muted(
+					fmt.Sprintf(" review all %d", queued),
+				)
+			}
+		}
 	}
 
 	if permission.Metadata != nil {
@@ -541,7 +559,11 @@ func renderToolDetails(
 			if preview != nil && toolInputMap["filePath"] != nil {
 				filename := toolInputMap["filePath"].(string)
 				body = preview.(string)
-				body = util.RenderFile(filename, body, width, util.WithTruncate(6))
+				if expanded {
+					body = util.RenderFile(filename, body, width)
+				} else {
+					body = util.RenderFile(filename, body, width, util.WithTruncate(6))
+				}
 			}
 		case "edit":
 			if filename, ok := toolInputMap["filePath"].(string); ok {
@@ -631,7 +653,7 @@ func renderToolDetails(
 		case "webfetch":
 			if format, ok := toolInputMap["format"].(string); ok && result != nil {
 				body = *result
-				body = util.TruncateHeight(body, 10)
+				body = truncate(body, 10)
 				if format == "html" || format == "markdown" {
 					body = util.ToMarkdown(body, width, backgroundColor)
 				}
@@ -697,7 +719,7 @@ func renderToolDetails(
 				result = &empty
 			}
 			body = *result
-			body = util.TruncateHeight(body, 10)
+			body = truncate(body, 10)
 			body = defaultStyle(body)
 		}
 	}
@@ -723,7 +745,7 @@ func renderToolDetails(
 
 	if body == "" && error == "" && result != nil {
 		body = *result
-		body = util.TruncateHeight(body, 10)
+		body = truncate(body, 10)
 		body = defaultStyle(body)
 	}
 
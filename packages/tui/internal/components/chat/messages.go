@@ -19,6 +19,7 @@ import (
 	"github.com/sst/opencode/internal/components/diff"
 	"github.com/sst/opencode/internal/components/toast"
 	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/replay"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
 	"github.com/sst/opencode/internal/util"
@@ -40,6 +41,12 @@ type MessagesComponent interface {
 	UndoLastMessage() (tea.Model, tea.Cmd)
 	RedoLastMessage() (tea.Model, tea.Cmd)
 	ScrollToMessage(messageID string) (tea.Model, tea.Cmd)
+	Search() (tea.Model, tea.Cmd)
+	Searching() bool
+	FocusPreviousBlock() (tea.Model, tea.Cmd)
+	FocusNextBlock() (tea.Model, tea.Cmd)
+	BlockFocused() bool
+	UpdateFocusedBlock(msg tea.KeyPressMsg) (tea.Model, tea.Cmd, bool)
 }
 
 type messagesComponent struct {
@@ -60,6 +67,10 @@ type messagesComponent struct {
 	selection          *selection
 	messagePositions   map[string]int // map message ID to line position
 	animating          bool
+	search             *conversationSearch
+	blocks             []messageBlock
+	blockStates        map[string]blockState // per part ID, overriding the global toggles
+	focusedPart        string
 }
 
 type selection struct {
@@ -109,6 +120,10 @@ func (m *messagesComponent) Init() tea.Cmd {
 func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		if m.search != nil {
+			return m.updateSearch(msg)
+		}
 	case shimmerTickMsg:
 		if !m.app.HasAnimatingWork() {
 			m.animating = false
@@ -182,6 +197,8 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.loading = true
 		return m, m.renderView()
 	case ToggleToolDetailsMsg:
+		// the global toggle applies to every block again
+		m.blockStates = make(map[string]blockState)
 		m.showToolDetails = !m.showToolDetails
 		m.app.State.ShowToolDetails = &m.showToolDetails
 		return m, tea.Batch(m.renderView(), m.app.SaveState())
@@ -190,10 +207,13 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.app.State.ShowThinkingBlocks = &m.showThinkingBlocks
 		return m, tea.Batch(m.renderView(), m.app.SaveState())
 	case app.SessionLoadedMsg:
+		m.resetBlocks()
 		m.tail = true
 		m.loading = true
 		return m, m.renderView()
 	case app.SessionClearedMsg:
+		m.closeSearch()
+		m.resetBlocks()
 		m.cache.Clear()
 		m.tail = true
 		m.loading = true
@@ -219,6 +239,7 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if currentParent != targetParent {
 			m.cache.Clear()
 		}
+		m.closeSearch()
 
 		m.viewport.GotoBottom()
 	case app.MessageRevertedMsg:
@@ -228,6 +249,11 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.renderView()
 		}
 
+	case replay.StepMsg:
+		if m.app.Transcript != "" {
+			m.tail = true
+			cmds = append(cmds, m.renderView())
+		}
 	case opencode.EventListResponseEventSessionUpdated:
 		if msg.Properties.Info.ID == m.app.Session.ID {
 			cmds = append(cmds, m.renderView())
@@ -268,6 +294,7 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.clipboard = msg.clipboard
 		m.loading = false
 		m.messagePositions = msg.messagePositions
+		m.setBlocks(msg.blocks)
 		m.tail = m.viewport.AtBottom()
 
 		// Preserve scroll across reflow
@@ -282,6 +309,9 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		m.header = msg.header
+		if m.search != nil {
+			m.refreshSearch(false)
+		}
 		if m.dirty {
 			cmds = append(cmds, m.renderView())
 		}
@@ -308,6 +338,7 @@ type renderCompleteMsg struct {
 	partCount        int
 	lineCount        int
 	messagePositions map[string]int
+	blocks           []messageBlock
 }
 
 func (m *messagesComponent) renderView() tea.Cmd {
@@ -323,6 +354,8 @@ func (m *messagesComponent) renderView() tea.Cmd {
 
 	viewport := m.viewport
 	tail := m.tail
+	searchBarHeight := m.searchBarHeight()
+	focusedPart := m.focusedPart
 
 	return func() tea.Msg {
 		header := m.renderHeader()
@@ -330,7 +363,7 @@ func (m *messagesComponent) renderView() tea.Cmd {
 		defer measure()
 
 		t := theme.CurrentTheme()
-		blocks := make([]string, 0)
+		blocks := make([]messageBlock, 0)
 		partCount := 0
 		lineCount := 0
 		messagePositions := make(map[string]int) // Track message ID to line position
@@ -469,12 +502,18 @@ func (m *messagesComponent) renderView() tea.Cmd {
 						if content != "" {
 							partCount++
 							lineCount += lipgloss.Height(content) + 1
-							blocks = append(blocks, content)
+							blocks = append(blocks, messageBlock{
+								content: content,
+								partID:  part.ID,
+								copy:    part.Text,
+							})
 						}
 					}
 				}
 
 			case opencode.AssistantMessage:
+				messagePositions[casted.ID] = lineCount
+
 				if casted.ID == m.app.Session.Revert.MessageID {
 					reverted = true
 					revertedMessageCount = 1
@@ -514,7 +553,10 @@ func (m *messagesComponent) renderView() tea.Cmd {
 								// if we hit another text part, we're done.
 								remaining = false
 							case opencode.ToolPart:
-								toolCallParts = append(toolCallParts, part)
+								// tools with a block state of their own are rendered separately
+								if _, ok := m.blockStates[part.ID]; !ok {
+									toolCallParts = append(toolCallParts, part)
+								}
 								if part.State.Status != opencode.ToolPartStateStatusCompleted && part.State.Status != opencode.ToolPartStateStatusError {
 									// i don't think there's a case where a tool call isn't in result state
 									// and the message time is 0, but just in case
@@ -564,7 +606,17 @@ func (m *messagesComponent) renderView() tea.Cmd {
 						if content != "" {
 							partCount++
 							lineCount += lipgloss.Height(content) + 1
-							blocks = append(blocks, content)
+							block := messageBlock{
+								content: content,
+								partID:  part.ID,
+								copy:    part.Text,
+							}
+							if !m.showToolDetails {
+								for _, toolCall := range toolCallParts {
+									block.folded = append(block.folded, toolCall.ID)
+								}
+							}
+							blocks = append(blocks, block)
 							hasContent = true
 						}
 					case opencode.ToolPart:
@@ -578,19 +630,28 @@ func (m *messagesComponent) renderView() tea.Cmd {
 							permission = m.app.CurrentPermission
 						}
 
-						if !m.showToolDetails && permission.ID == "" {
+						state, hasState := m.blockStates[part.ID]
+						if !m.showToolDetails && permission.ID == "" && !hasState {
 							if !hasTextPart {
 								orphanedToolCalls = append(orphanedToolCalls, part)
 							}
 							continue
 						}
 
-						if part.State.Status == opencode.ToolPartStateStatusCompleted || part.State.Status == opencode.ToolPartStateStatusError {
+						// a pending permission is never collapsed out of sight
+						if permission.ID != "" && state == blockCollapsed {
+							state = blockDefault
+						}
+
+						if state == blockCollapsed {
+							content = renderContentBlock(m.app, renderToolTitle(part, width), width)
+						} else if part.State.Status == opencode.ToolPartStateStatusCompleted || part.State.Status == opencode.ToolPartStateStatusError {
 							key := m.cache.GenerateKey(casted.ID,
 								part.ID,
 								m.showToolDetails,
 								width,
 								permission.ID,
+								state,
 							)
 							content, cached = m.cache.Get(key)
 							if !cached {
@@ -599,6 +660,7 @@ func (m *messagesComponent) renderView() tea.Cmd {
 									part,
 									permission,
 									width,
+									state == blockExpanded,
 								)
 								m.cache.Set(key, content)
 							}
@@ -609,12 +671,19 @@ func (m *messagesComponent) renderView() tea.Cmd {
 								part,
 								permission,
 								width,
+								state == blockExpanded,
 							)
 						}
 						if content != "" {
 							partCount++
 							lineCount += lipgloss.Height(content) + 1
-							blocks = append(blocks, content)
+							blocks = append(blocks, messageBlock{
+								content: content,
+								partID:  part.ID,
+								copy:    toolCopyText(part),
+								tool:    true,
+								state:   state,
+							})
 							hasContent = true
 						}
 					case opencode.ReasoningPart:
@@ -643,7 +712,11 @@ func (m *messagesComponent) renderView() tea.Cmd {
 							)
 							partCount++
 							lineCount += lipgloss.Height(content) + 1
-							blocks = append(blocks, content)
+							blocks = append(blocks, messageBlock{
+								content: content,
+								partID:  part.ID,
+								copy:    part.Text,
+							})
 							hasContent = true
 						}
 					}
@@ -678,7 +751,7 @@ func (m *messagesComponent) renderView() tea.Cmd {
 					)
 					partCount++
 					lineCount += lipgloss.Height(content) + 1
-					blocks = append(blocks, content)
+					blocks = append(blocks, messageBlock{content: content})
 				}
 			}
 
@@ -690,7 +763,7 @@ func (m *messagesComponent) renderView() tea.Cmd {
 					width,
 					WithBorderColor(t.Error()),
 				)
-				blocks = append(blocks, error)
+				blocks = append(blocks, messageBlock{content: error})
 				lineCount += lipgloss.Height(error) + 1
 			}
 		}
@@ -760,7 +833,7 @@ func (m *messagesComponent) renderView() tea.Cmd {
 				width,
 				WithBorderColor(t.BackgroundPanel()),
 			)
-			blocks = append(blocks, content)
+			blocks = append(blocks, messageBlock{content: content})
 		}
 
 		if m.app.CurrentPermission.ID != "" &&
@@ -782,11 +855,12 @@ func (m *messagesComponent) renderView() tea.Cmd {
 								toolPart,
 								m.app.CurrentPermission,
 								width,
+								false,
 							)
 							if content != "" {
 								partCount++
 								lineCount += lipgloss.Height(content) + 1
-								blocks = append(blocks, content)
+								blocks = append(blocks, messageBlock{content: content})
 							}
 						}
 					}
@@ -800,9 +874,21 @@ func (m *messagesComponent) renderView() tea.Cmd {
 		if m.selection != nil {
 			selection = m.selection.coords(lipgloss.Height(header) + 1)
 		}
-		for _, block := range blocks {
-			lines := strings.Split(block, "\n")
+		focusMarker := styles.NewStyle().
+			Foreground(t.Primary()).
+			Background(t.Background()).
+			Render("┃")
+		for i := range blocks {
+			block := &blocks[i]
+			lines := strings.Split(block.content, "\n")
+			// the content starts with an empty line
+			block.start = len(final) + 1
+			block.end = block.start + len(lines)
+			focused := block.partID != "" && block.partID == focusedPart
 			for index, line := range lines {
+				if focused {
+					line = focusMarker + ansi.Cut(line, 1, ansi.StringWidth(line))
+				}
 				if selection == nil || index == 0 || index == len(lines)-1 {
 					final = append(final, line)
 					continue
@@ -840,7 +926,7 @@ func (m *messagesComponent) renderView() tea.Cmd {
 			final = append(final, "")
 		}
 		content := "\n" + strings.Join(final, "\n")
-		viewport.SetHeight(m.height - lipgloss.Height(header))
+		viewport.SetHeight(m.height - lipgloss.Height(header) - searchBarHeight)
 		viewport.SetContent(content)
 		if tail {
 			viewport.GotoBottom()
@@ -853,6 +939,7 @@ func (m *messagesComponent) renderView() tea.Cmd {
 			partCount:        partCount,
 			lineCount:        lineCount,
 			messagePositions: messagePositions,
+			blocks:           blocks,
 		}
 	}
 }
@@ -880,7 +967,10 @@ func (m *messagesComponent) renderHeader() string {
 	sessionInfo := ""
 	tokens := float64(0)
 	cost := float64(0)
-	contextWindow := m.app.Model.Limit.Context
+	contextWindow := float64(0)
+	if m.app.Model != nil {
+		contextWindow = m.app.Model.Limit.Context
+	}
 
 	for _, message := range m.app.Messages {
 		if assistant, ok := message.Info.(opencode.AssistantMessage); ok {
@@ -1052,6 +1142,11 @@ func (m *messagesComponent) View() string {
 	}
 
 	viewport := m.viewport.View()
+	if m.search != nil {
+		// keep the search bar at the bottom when the conversation is short
+		padding := max(0, m.viewport.Height()-lipgloss.Height(viewport))
+		viewport += strings.Repeat("\n", padding) + "\n" + m.renderSearchBar()
+	}
 	return styles.NewStyle().
 		Background(bgColor).
 		Render(m.header + "\n" + viewport)
@@ -1313,5 +1408,6 @@ func NewMessagesComponent(app *app.App) MessagesComponent {
 		cache:              NewPartCache(),
 		tail:               true,
 		messagePositions:   make(map[string]int),
+		blockStates:        make(map[string]blockState),
 	}
 }
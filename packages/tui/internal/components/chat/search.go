@@ -0,0 +1,359 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/search"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// conversationSearch is the state of the search bar under the messages.
+type conversationSearch struct {
+	query search.Query
+	// typing is set while the query is edited; otherwise n and N navigate
+	typing  bool
+	pattern *regexp.Regexp
+	err     error
+	hits    []search.Hit
+	current int
+}
+
+// Search opens the search bar, or focuses its query when already open.
+func (m *messagesComponent) Search() (tea.Model, tea.Cmd) {
+	if m.search == nil {
+		m.search = &conversationSearch{}
+		m.resizeViewport()
+	}
+	m.search.typing = true
+	return m, nil
+}
+
+func (m *messagesComponent) Searching() bool {
+	return m.search != nil
+}
+
+func (m *messagesComponent) closeSearch() {
+	if m.search == nil {
+		return
+	}
+	m.search = nil
+	m.viewport.ClearHighlights()
+	m.resizeViewport()
+}
+
+func (m *messagesComponent) searchBarHeight() int {
+	if m.search == nil {
+		return 0
+	}
+	return 1
+}
+
+func (m *messagesComponent) resizeViewport() {
+	m.viewport.SetHeight(m.height - lipgloss.Height(m.header) - m.searchBarHeight())
+}
+
+func (m *messagesComponent) updateSearch(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	s := m.search
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.closeSearch()
+		return m, nil
+	case "alt+r":
+		s.query.Regex = !s.query.Regex
+		m.refreshSearch(true)
+		return m, nil
+	case "alt+c":
+		s.query.CaseSensitive = !s.query.CaseSensitive
+		m.refreshSearch(true)
+		return m, nil
+	case "down", "ctrl+n":
+		m.moveSearch(1)
+		return m, nil
+	case "up", "ctrl+p":
+		m.moveSearch(-1)
+		return m, nil
+	}
+
+	if !s.typing {
+		switch msg.String() {
+		case "n", "enter":
+			m.moveSearch(1)
+		case "N", "shift+n":
+			m.moveSearch(-1)
+		case "/":
+			s.typing = true
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "enter":
+		if s.query.Text == "" {
+			m.closeSearch()
+			return m, nil
+		}
+		s.typing = false
+	case "backspace":
+		if s.query.Text != "" {
+			runes := []rune(s.query.Text)
+			s.query.Text = string(runes[:len(runes)-1])
+			m.refreshSearch(true)
+		}
+	case "ctrl+u":
+		s.query.Text = ""
+		m.refreshSearch(true)
+	default:
+		if msg.Text != "" {
+			s.query.Text += msg.Text
+			m.refreshSearch(true)
+		}
+	}
+	return m, nil
+}
+
+// refreshSearch matches the query against the conversation again. With jump
+// set the hit nearest to the scroll position becomes current and is
+// scrolled to; otherwise the current hit is kept and nothing moves, as
+// when a streaming message re-renders.
+func (m *messagesComponent) refreshSearch(jump bool) {
+	s := m.search
+	var previous *search.Hit
+	if s.current < len(s.hits) {
+		previous = &s.hits[s.current]
+	}
+
+	s.pattern, s.err = s.query.Compile()
+	s.hits = nil
+	if s.pattern != nil {
+		s.hits = search.Hits(s.pattern, m.searchDocuments())
+	}
+
+	if jump {
+		s.current = m.nearestHit()
+		m.showSearchHit()
+		return
+	}
+	if previous != nil {
+		if i := slices.Index(s.hits, *previous); i >= 0 {
+			s.current = i
+		}
+	}
+	s.current = min(s.current, max(0, len(s.hits)-1))
+	m.highlightSearch(false)
+}
+
+func (m *messagesComponent) moveSearch(delta int) {
+	s := m.search
+	if len(s.hits) == 0 {
+		return
+	}
+	s.current = (s.current + delta + len(s.hits)) % len(s.hits)
+	m.showSearchHit()
+}
+
+// nearestHit is the first hit in a message starting at or below the top of
+// the viewport, wrapping around to the first hit.
+func (m *messagesComponent) nearestHit() int {
+	for i, hit := range m.search.hits {
+		if m.messagePositions[hit.ID] >= m.viewport.YOffset {
+			return i
+		}
+	}
+	return 0
+}
+
+func (m *messagesComponent) showSearchHit() {
+	if len(m.search.hits) > 0 {
+		m.ScrollToMessage(m.search.hits[m.search.current].ID)
+	}
+	m.highlightSearch(true)
+}
+
+// highlightSearch highlights every match in the rendered conversation and
+// focuses the one for the current hit, scrolling to it when asked.
+func (m *messagesComponent) highlightSearch(scroll bool) {
+	s := m.search
+	t := theme.CurrentTheme()
+	m.viewport.HighlightStyle = styles.NewStyle().
+		Background(t.Warning()).
+		Foreground(t.BackgroundPanel()).
+		Lipgloss()
+	m.viewport.SelectedHighlightStyle = styles.NewStyle().
+		Background(t.Accent()).
+		Foreground(t.BackgroundPanel()).
+		Lipgloss()
+
+	content := ansi.Strip(m.viewport.GetContent())
+	matches := search.Matches(s.pattern, content)
+	selected := m.renderedHit(content, matches)
+	if scroll {
+		m.viewport.ReplaceHighlights(matches, -1)
+		m.viewport.SelectHighlight(selected)
+	} else {
+		m.viewport.ReplaceHighlights(matches, selected)
+	}
+}
+
+// renderedHit finds the rendered match for the current hit: the match with
+// the same position within the lines of the hit's message. Hits in text
+// that isn't rendered, such as hidden tool output, fall back to the
+// message's first match, or to none.
+func (m *messagesComponent) renderedHit(content string, matches [][]int) int {
+	s := m.search
+	if len(s.hits) == 0 {
+		return -1
+	}
+	hit := s.hits[s.current]
+	start, ok := m.messagePositions[hit.ID]
+	if !ok {
+		return -1
+	}
+	end := math.MaxInt
+	for _, position := range m.messagePositions {
+		if position > start && position < end {
+			end = position
+		}
+	}
+
+	first := -1
+	occurrence := 0
+	for i, line := range search.Lines(content, matches) {
+		// rendered content starts with an empty line that positions don't count
+		if line <= start || line > end {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if occurrence == hit.Occurrence {
+			return i
+		}
+		occurrence++
+	}
+	return first
+}
+
+// searchDocuments collects the searchable text of each visible message.
+func (m *messagesComponent) searchDocuments() []search.Document {
+	var documents []search.Document
+	for _, message := range m.app.Messages {
+		var id string
+		switch info := message.Info.(type) {
+		case opencode.UserMessage:
+			id = info.ID
+		case opencode.AssistantMessage:
+			id = info.ID
+		default:
+			continue
+		}
+		// reverted messages are not shown
+		if id == m.app.Session.Revert.MessageID {
+			break
+		}
+
+		document := search.Document{ID: id}
+		for _, part := range message.Parts {
+			switch part := part.(type) {
+			case opencode.TextPart:
+				if !part.Synthetic {
+					document.Texts = append(document.Texts, part.Text)
+				}
+			case opencode.ReasoningPart:
+				if m.showThinkingBlocks {
+					document.Texts = append(document.Texts, part.Text)
+				}
+			case opencode.ToolPart:
+				document.Texts = append(document.Texts, toolInputText(part.State.Input)...)
+				document.Texts = append(document.Texts, part.State.Output, part.State.Error)
+			}
+		}
+		documents = append(documents, document)
+	}
+	return documents
+}
+
+// toolInputText is the searchable text of a tool call's arguments: strings
+// as they are, anything else as JSON.
+func toolInputText(input any) []string {
+	values, ok := input.(map[string]any)
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	texts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value, ok := values[key].(string); ok {
+			texts = append(texts, value)
+			continue
+		}
+		if data, err := json.Marshal(values[key]); err == nil {
+			texts = append(texts, string(data))
+		}
+	}
+	return texts
+}
+
+func (m *messagesComponent) renderSearchBar() string {
+	s := m.search
+	t := theme.CurrentTheme()
+	bg := t.BackgroundPanel()
+	base := styles.NewStyle().Foreground(t.Text()).Background(bg).Render
+	muted := styles.NewStyle().Foreground(t.TextMuted()).Background(bg).Render
+
+	query := base(s.query.Text)
+	if s.typing {
+		query += styles.NewStyle().Background(t.Text()).Render(" ")
+	}
+	left := styles.NewStyle().Foreground(t.Primary()).Background(bg).Render("/ ") + query
+
+	toggle := func(on bool, label string) string {
+		if on {
+			return styles.NewStyle().Foreground(t.Primary()).Background(bg).Bold(true).Render(label)
+		}
+		return muted(label)
+	}
+	var count string
+	switch {
+	case s.err != nil:
+		count = styles.NewStyle().Foreground(t.Error()).Background(bg).Render("invalid pattern")
+	case s.query.Text == "":
+		count = ""
+	case len(s.hits) == 0:
+		count = muted("no matches")
+	default:
+		count = base(fmt.Sprintf("%d/%d", s.current+1, len(s.hits)))
+	}
+	hint := "n/N next/prev"
+	if s.typing {
+		hint = "enter done"
+	}
+	right := strings.Join([]string{
+		count,
+		toggle(s.query.Regex, ".*") + muted(" alt+r"),
+		toggle(s.query.CaseSensitive, "Aa") + muted(" alt+c"),
+		muted(hint + " · esc close"),
+	}, muted("  "))
+
+	width := m.width - 2
+	gap := max(1, width-lipgloss.Width(left)-lipgloss.Width(right))
+	return styles.NewStyle().
+		Background(bg).
+		Width(m.width).
+		Padding(0, 1).
+		Render(left + muted(strings.Repeat(" ", gap)) + right)
+}
@@ -0,0 +1,204 @@
+package dialog
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+const (
+	numVisiblePrompts   = 8
+	historyDialogWidth  = 72
+	historyPreviewLines = 6
+)
+
+// PromptHistoryDialog interface for the reverse search over sent prompts
+type PromptHistoryDialog interface {
+	layout.Modal
+}
+
+// PromptSelectedMsg is sent when a prompt from history should be restored
+// into the editor
+type PromptSelectedMsg struct {
+	Prompt app.Prompt
+}
+
+// promptItem is a prompt from history, shown on a single line
+type promptItem struct {
+	prompt app.Prompt
+}
+
+func (p promptItem) Render(selected bool, width int, baseStyle styles.Style) string {
+	t := theme.CurrentTheme()
+
+	text := strings.Join(strings.Fields(p.prompt.Text), " ")
+	truncatedStr := truncate.StringWithTail(text, uint(width-1), "...")
+
+	var itemStyle styles.Style
+	if selected {
+		itemStyle = baseStyle.
+			Background(t.Primary()).
+			Foreground(t.BackgroundElement()).
+			Width(width).
+			PaddingLeft(1)
+	} else {
+		itemStyle = baseStyle.
+			Foreground(t.Text()).
+			PaddingLeft(1)
+	}
+
+	return itemStyle.Render(truncatedStr)
+}
+
+func (p promptItem) Selectable() bool {
+	return true
+}
+
+type promptHistoryDialog struct {
+	prompts      []app.Prompt
+	modal        *modal.Modal
+	searchDialog *SearchDialog
+}
+
+func (h *promptHistoryDialog) Init() tea.Cmd {
+	return h.searchDialog.Init()
+}
+
+func (h *promptHistoryDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h.searchDialog.SetHeight(msg.Height)
+	case tea.KeyPressMsg:
+		// As in a shell, pressing ctrl+r again steps to the next older match
+		if msg.String() == "ctrl+r" {
+			updatedDialog, cmd := h.searchDialog.Update(tea.KeyPressMsg{Code: tea.KeyDown})
+			h.searchDialog = updatedDialog.(*SearchDialog)
+			return h, cmd
+		}
+	case SearchSelectionMsg:
+		if item, ok := msg.Item.(promptItem); ok {
+			return h, tea.Sequence(
+				util.CmdHandler(modal.CloseModalMsg{}),
+				util.CmdHandler(PromptSelectedMsg{Prompt: item.prompt}),
+			)
+		}
+		return h, util.CmdHandler(modal.CloseModalMsg{})
+	case SearchCancelledMsg:
+		return h, util.CmdHandler(modal.CloseModalMsg{})
+	case SearchQueryChangedMsg:
+		h.searchDialog.SetItems(h.buildResults(msg.Query))
+		return h, nil
+	}
+
+	updatedDialog, cmd := h.searchDialog.Update(msg)
+	h.searchDialog = updatedDialog.(*SearchDialog)
+	return h, cmd
+}
+
+// buildResults lists the prompts matching the query. Prompts that contain the
+// query as typed come before those that only match fuzzily, and within each
+// group newer prompts come first, which suits history better than ranking by
+// edit distance as that favours short prompts. A prompt sent more than once
+// is listed once.
+func (h *promptHistoryDialog) buildResults(query string) []list.Item {
+	var exact, fuzzyMatches []list.Item
+	seen := make(map[string]bool)
+	lowerQuery := strings.ToLower(query)
+
+	for _, prompt := range h.prompts {
+		if seen[prompt.Text] {
+			continue
+		}
+		switch {
+		case strings.Contains(strings.ToLower(prompt.Text), lowerQuery):
+			exact = append(exact, promptItem{prompt: prompt})
+		case fuzzy.MatchFold(query, prompt.Text):
+			fuzzyMatches = append(fuzzyMatches, promptItem{prompt: prompt})
+		default:
+			continue
+		}
+		seen[prompt.Text] = true
+	}
+
+	return append(exact, fuzzyMatches...)
+}
+
+func (h *promptHistoryDialog) View() string {
+	view := h.searchDialog.View()
+
+	var preview string
+	if item, idx := h.searchDialog.GetSelectedItem(); idx != -1 {
+		if prompt, ok := item.(promptItem); ok {
+			preview = h.renderPreview(prompt.prompt)
+		}
+	}
+	// Keep the dialog the same height as the selection moves
+	return view + "\n\n" + lipgloss.PlaceVertical(historyPreviewLines+2, lipgloss.Top, preview)
+}
+
+// renderPreview shows the whole of a prompt, wrapped, and the attachments it
+// was sent with.
+func (h *promptHistoryDialog) renderPreview(prompt app.Prompt) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.NewStyle().Background(t.BackgroundPanel())
+	mutedStyle := baseStyle.Foreground(t.TextMuted())
+
+	text := baseStyle.
+		Foreground(t.Text()).
+		Width(historyDialogWidth).
+		PaddingLeft(1).
+		Render(prompt.Text)
+	lines := strings.Split(text, "\n")
+	if len(lines) > historyPreviewLines {
+		lines = lines[:historyPreviewLines]
+		lines[len(lines)-1] = mutedStyle.PaddingLeft(1).Render("...")
+	}
+
+	if len(prompt.Attachments) > 0 {
+		attachmentStyle := baseStyle.Foreground(t.Accent())
+		chips := make([]string, 0, len(prompt.Attachments))
+		for _, att := range prompt.Attachments {
+			chips = append(chips, attachmentStyle.Render(att.Display))
+		}
+		lines = append(lines, "", mutedStyle.PaddingLeft(1).Render("attachments ")+
+			strings.Join(chips, mutedStyle.Render(" ")))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (h *promptHistoryDialog) Render(background string) string {
+	return h.modal.Render(h.View(), background)
+}
+
+func (h *promptHistoryDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewPromptHistoryDialog searches the prompts sent in the current project
+func NewPromptHistoryDialog(app *app.App) PromptHistoryDialog {
+	dialog := &promptHistoryDialog{
+		prompts: app.State.PromptHistory(app.Project.Worktree),
+	}
+
+	dialog.searchDialog = NewSearchDialog("Search prompt history...", numVisiblePrompts)
+	dialog.searchDialog.SetWidth(historyDialogWidth)
+	dialog.searchDialog.SetItems(dialog.buildResults(""))
+
+	dialog.modal = modal.New(
+		modal.WithTitle("Prompt History"),
+		modal.WithMaxWidth(historyDialogWidth+4),
+	)
+
+	return dialog
+}
@@ -0,0 +1,277 @@
+package dialog
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/logbuffer"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+const (
+	numVisibleLogs     = 16
+	logRefreshInterval = 500 * time.Millisecond
+)
+
+// logLevels are the minimum levels tab cycles through
+var logLevels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// LogDialog interface for the log viewer dialog
+type LogDialog interface {
+	layout.Modal
+}
+
+type logsRefreshMsg struct{}
+
+type logItem struct {
+	record   logbuffer.Record
+	expanded bool
+}
+
+func (l logItem) Render(
+	selected bool,
+	width int,
+	baseStyle styles.Style,
+) string {
+	t := theme.CurrentTheme()
+	style := baseStyle.Background(t.BackgroundPanel()).Foreground(t.Text())
+	mutedStyle := baseStyle.Background(t.BackgroundPanel()).Foreground(t.TextMuted())
+	levelStyle := mutedStyle
+	switch {
+	case l.record.Level >= slog.LevelError:
+		levelStyle = levelStyle.Foreground(t.Error())
+	case l.record.Level >= slog.LevelWarn:
+		levelStyle = levelStyle.Foreground(t.Warning())
+	case l.record.Level >= slog.LevelInfo:
+		levelStyle = levelStyle.Foreground(t.Info())
+	}
+	if selected {
+		style = baseStyle.Background(t.Primary()).Foreground(t.BackgroundElement())
+		mutedStyle = style
+		levelStyle = style
+	}
+
+	prefix := l.record.Time.Format("15:04:05.000") + " "
+	level := fmt.Sprintf("%-5s ", l.record.Level.String())
+	available := max(width-len(prefix)-len(level)-2, 8)
+
+	line := l.record.Message
+	if !l.expanded {
+		for _, attr := range l.record.Attrs {
+			line += " " + attr.Key + "=" + attr.Value
+		}
+	}
+	line = truncate.StringWithTail(strings.ReplaceAll(line, "\n", " "), uint(available), "…")
+	text := mutedStyle.Render(prefix) + levelStyle.Render(level) + style.Render(line)
+
+	if l.expanded {
+		indent := strings.Repeat(" ", len(prefix)+len(level))
+		for _, attr := range l.record.Attrs {
+			value := truncate.StringWithTail(
+				strings.ReplaceAll(attr.Value, "\n", "⏎"),
+				uint(max(available-len(attr.Key)-2, 8)),
+				"…",
+			)
+			text += "\n" + mutedStyle.Render(indent+attr.Key+": ") + style.Render(value)
+		}
+	}
+
+	return style.Width(width).PaddingLeft(1).Render(text)
+}
+
+func (l logItem) Selectable() bool {
+	return true
+}
+
+type logDialog struct {
+	width        int
+	height       int
+	modal        *modal.Modal
+	app          *app.App
+	searchDialog *SearchDialog
+	level        int // index into logLevels
+	expanded     map[uint64]bool
+	seq          uint64
+	records      []logbuffer.Record // the filtered records shown, oldest first
+}
+
+func (l *logDialog) Init() tea.Cmd {
+	return tea.Batch(l.searchDialog.Init(), l.tick())
+}
+
+func (l *logDialog) tick() tea.Cmd {
+	return tea.Tick(logRefreshInterval, func(time.Time) tea.Msg {
+		return logsRefreshMsg{}
+	})
+}
+
+func (l *logDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		l.width = msg.Width
+		l.height = msg.Height
+		l.searchDialog.SetWidth(l.contentWidth())
+		l.searchDialog.SetHeight(msg.Height)
+	case logsRefreshMsg:
+		if l.app.Logs != nil && l.app.Logs.Seq() != l.seq {
+			l.refresh(false)
+		}
+		return l, l.tick()
+	case SearchQueryChangedMsg:
+		l.refresh(true)
+		return l, nil
+	case SearchSelectionMsg:
+		if item, ok := msg.Item.(logItem); ok {
+			l.expanded[item.record.Seq] = !l.expanded[item.record.Seq]
+			l.refresh(false)
+		}
+		return l, nil
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "tab":
+			l.level = (l.level + 1) % len(logLevels)
+			l.refresh(true)
+			return l, nil
+		case "shift+tab":
+			l.level = (l.level + len(logLevels) - 1) % len(logLevels)
+			l.refresh(true)
+			return l, nil
+		case "ctrl+y":
+			return l, l.copySelected()
+		case "ctrl+a":
+			return l, l.copyAll()
+		}
+	}
+
+	updatedDialog, cmd := l.searchDialog.Update(msg)
+	l.searchDialog = updatedDialog.(*SearchDialog)
+	return l, cmd
+}
+
+// refresh reloads the records from the ring. The selection stays on the same
+// record, or follows the newest record when it was already there.
+func (l *logDialog) refresh(reset bool) {
+	if l.app.Logs == nil {
+		return
+	}
+	selected, idx := l.searchDialog.list.GetSelectedItem()
+	following := reset || idx == -1 || idx == len(l.records)-1
+
+	l.seq = l.app.Logs.Seq()
+	query := l.searchDialog.GetQuery()
+	minLevel := logLevels[l.level]
+	l.records = l.records[:0]
+	items := []list.Item{}
+	keep := -1
+	for _, record := range l.app.Logs.Records() {
+		if record.Level < minLevel || !record.Matches(query) {
+			continue
+		}
+		if item, ok := selected.(logItem); ok && item.record.Seq == record.Seq {
+			keep = len(items)
+		}
+		l.records = append(l.records, record)
+		items = append(items, logItem{record: record, expanded: l.expanded[record.Seq]})
+	}
+	l.searchDialog.SetItems(items)
+	if following || keep == -1 {
+		l.searchDialog.list.SetSelectedIndex(len(items) - 1)
+	} else {
+		l.searchDialog.list.SetSelectedIndex(keep)
+	}
+	l.modal.SetTitle(fmt.Sprintf("Logs (%s+, %d)", minLevel.String(), len(items)))
+}
+
+func (l *logDialog) copySelected() tea.Cmd {
+	item, idx := l.searchDialog.list.GetSelectedItem()
+	record, ok := item.(logItem)
+	if idx == -1 || !ok {
+		return nil
+	}
+	return tea.Batch(
+		app.SetClipboard(formatLogRecord(record.record)),
+		toast.NewSuccessToast("Copied log record"),
+	)
+}
+
+func (l *logDialog) copyAll() tea.Cmd {
+	if len(l.records) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(l.records))
+	for _, record := range l.records {
+		lines = append(lines, record.String())
+	}
+	return tea.Batch(
+		app.SetClipboard(strings.Join(lines, "\n")),
+		toast.NewSuccessToast(fmt.Sprintf("Copied %d log records", len(lines))),
+	)
+}
+
+// formatLogRecord writes a record with one attribute per line.
+func formatLogRecord(record logbuffer.Record) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", record.Time.Format(time.RFC3339Nano), record.Level, record.Message)
+	for _, attr := range record.Attrs {
+		fmt.Fprintf(&b, "\n  %s: %s", attr.Key, attr.Value)
+	}
+	return b.String()
+}
+
+func (l *logDialog) contentWidth() int {
+	return layout.Current.Container.Width - 12
+}
+
+func (l *logDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+	keyStyle := base.Foreground(t.Text()).Bold(true).Render
+	mutedStyle := base.Foreground(t.TextMuted()).Render
+
+	help := keyStyle("tab") + mutedStyle(" level   ") +
+		keyStyle("enter") + mutedStyle(" attributes   ") +
+		keyStyle("ctrl+y") + mutedStyle(" copy   ") +
+		keyStyle("ctrl+a") + mutedStyle(" copy all")
+	if l.app.LogHandler != nil {
+		if stats := l.app.LogHandler.Stats(); stats.Dropped > 0 || stats.Unsent > 0 {
+			help += mutedStyle(fmt.Sprintf("   %d dropped, %d not sent", stats.Dropped, stats.Unsent))
+		}
+	}
+
+	content := l.searchDialog.View() + "\n" +
+		base.Width(l.contentWidth()).PaddingLeft(1).PaddingTop(1).Render(help)
+	return l.modal.Render(content, background)
+}
+
+func (l *logDialog) Close() tea.Cmd {
+	return nil
+}
+
+// NewLogDialog creates a dialog showing the TUI's recent log records
+func NewLogDialog(app *app.App) LogDialog {
+	searchDialog := NewSearchDialog("Search logs...", numVisibleLogs)
+	searchDialog.list.SetEmptyMessage(" No log records")
+	dialog := &logDialog{
+		app:          app,
+		searchDialog: searchDialog,
+		expanded:     map[uint64]bool{},
+		level:        1,
+		modal: modal.New(
+			modal.WithTitle("Logs"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+	dialog.searchDialog.SetWidth(dialog.contentWidth())
+	dialog.refresh(true)
+	return dialog
+}
@@ -0,0 +1,424 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/muesli/reflow/truncate"
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/policy"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+const (
+	numVisiblePermissions = 8
+	maxPermissionDetails  = 12
+	maxPermissionHistory  = 15
+)
+
+// PermissionDialog interface for the permission review dialog
+type PermissionDialog interface {
+	layout.Modal
+}
+
+type permissionSessionsMsg []opencode.Session
+
+type permissionItem struct {
+	permission opencode.Permission
+	session    string
+	marked     bool
+}
+
+func (p permissionItem) Render(
+	selected bool,
+	width int,
+	baseStyle styles.Style,
+) string {
+	t := theme.CurrentTheme()
+	style := baseStyle.Background(t.BackgroundPanel()).Foreground(t.Text())
+	mutedStyle := baseStyle.Background(t.BackgroundPanel()).Foreground(t.TextMuted())
+	if selected {
+		style = baseStyle.Background(t.Primary()).Foreground(t.BackgroundElement())
+		mutedStyle = style
+	}
+
+	check := "[ ] "
+	if p.marked {
+		check = "[x] "
+	}
+	tool := fmt.Sprintf("%-8s ", p.permission.Type)
+	session := truncate.StringWithTail(p.session, 20, "...") + "  "
+	titleWidth := max(width-len(check)-len(tool)-len(session)-2, 8)
+	title := truncate.StringWithTail(firstLine(p.permission.Title), uint(titleWidth), "...")
+
+	return style.Width(width).PaddingLeft(1).Render(
+		style.Render(check+tool) + mutedStyle.Render(session) + style.Render(title),
+	)
+}
+
+func (p permissionItem) Selectable() bool {
+	return true
+}
+
+type permissionDialog struct {
+	width   int
+	height  int
+	modal   *modal.Modal
+	list    list.List[permissionItem]
+	app     *app.App
+	titles  map[string]string
+	marked  map[string]bool
+	confirm bool // "always" was pressed once and is waiting for confirmation
+	history bool // showing the policy's automatic decisions instead of the queue
+}
+
+func (p *permissionDialog) Init() tea.Cmd {
+	return func() tea.Msg {
+		sessions, err := p.app.ListSessions(context.Background())
+		if err != nil {
+			return nil
+		}
+		return permissionSessionsMsg(sessions)
+	}
+}
+
+func (p *permissionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height
+		p.list.SetMaxWidth(p.contentWidth())
+	case permissionSessionsMsg:
+		for _, session := range msg {
+			p.titles[session.ID] = sessionLabel(session, p.app.Session.ID)
+		}
+		p.refresh()
+	case opencode.EventListResponseEventPermissionUpdated,
+		opencode.EventListResponseEventPermissionReplied:
+		p.refresh()
+		if len(p.app.Permissions) == 0 && !p.history {
+			return p, util.CmdHandler(modal.CloseModalMsg{})
+		}
+	case tea.KeyPressMsg:
+		keyString := msg.String()
+		if keyString == "h" {
+			p.history = !p.history
+			p.confirm = false
+			p.refresh()
+			return p, nil
+		}
+		if p.history {
+			return p, nil
+		}
+		if p.confirm {
+			p.confirm = false
+			if keyString == "a" {
+				return p, p.respond(opencode.SessionPermissionRespondParamsResponseAlways)
+			}
+			return p, nil
+		}
+		switch keyString {
+		case "space":
+			if item, idx := p.list.GetSelectedItem(); idx >= 0 {
+				p.marked[item.permission.ID] = !p.marked[item.permission.ID]
+				p.refresh()
+			}
+			return p, nil
+		case "*":
+			all := slices.ContainsFunc(p.app.Permissions, func(permission opencode.Permission) bool {
+				return !p.marked[permission.ID]
+			})
+			p.marked = map[string]bool{}
+			if all {
+				for _, permission := range p.app.Permissions {
+					p.marked[permission.ID] = true
+				}
+			}
+			p.refresh()
+			return p, nil
+		case "enter", "y":
+			return p, p.respond(opencode.SessionPermissionRespondParamsResponseOnce)
+		case "a":
+			if len(p.targets()) > 0 {
+				p.confirm = true
+			}
+			return p, nil
+		case "r", "n":
+			return p, p.respond(opencode.SessionPermissionRespondParamsResponseReject)
+		}
+	}
+
+	listModel, cmd := p.list.Update(msg)
+	p.list = listModel.(list.List[permissionItem])
+	return p, cmd
+}
+
+// targets are the marked requests, or the highlighted one when none are
+// marked.
+func (p *permissionDialog) targets() []opencode.Permission {
+	var targets []opencode.Permission
+	for _, permission := range p.app.Permissions {
+		if p.marked[permission.ID] {
+			targets = append(targets, permission)
+		}
+	}
+	if len(targets) == 0 {
+		if item, idx := p.list.GetSelectedItem(); idx >= 0 {
+			targets = append(targets, item.permission)
+		}
+	}
+	return targets
+}
+
+func (p *permissionDialog) respond(response opencode.SessionPermissionRespondParamsResponse) tea.Cmd {
+	targets := p.targets()
+	if len(targets) == 0 {
+		return nil
+	}
+	cmd := p.app.RespondToPermissions(targets, response)
+	for _, permission := range targets {
+		delete(p.marked, permission.ID)
+	}
+	p.refresh()
+	if len(p.app.Permissions) == 0 && !p.history {
+		return tea.Batch(cmd, util.CmdHandler(modal.CloseModalMsg{}))
+	}
+	return cmd
+}
+
+// refresh rebuilds the list from the app's queue, keeping the highlighted
+// request selected when it is still there.
+func (p *permissionDialog) refresh() {
+	current, idx := p.list.GetSelectedItem()
+	items := make([]permissionItem, 0, len(p.app.Permissions))
+	selected := 0
+	for i, permission := range p.app.Permissions {
+		if idx >= 0 && permission.ID == current.permission.ID {
+			selected = i
+		}
+		items = append(items, permissionItem{
+			permission: permission,
+			session:    p.sessionTitle(permission.SessionID),
+			marked:     p.marked[permission.ID],
+		})
+	}
+	for id := range p.marked {
+		if !slices.ContainsFunc(p.app.Permissions, func(permission opencode.Permission) bool {
+			return permission.ID == id
+		}) {
+			delete(p.marked, id)
+		}
+	}
+	p.list.SetItems(items)
+	p.list.SetSelectedIndex(selected)
+	if p.history {
+		p.modal.SetTitle("Permission Policy History")
+	} else {
+		p.modal.SetTitle(fmt.Sprintf("Permission Requests (%d)", len(items)))
+	}
+}
+
+func (p *permissionDialog) sessionTitle(sessionID string) string {
+	if title, ok := p.titles[sessionID]; ok {
+		return title
+	}
+	if sessionID == p.app.Session.ID {
+		return p.app.Session.Title
+	}
+	return sessionID
+}
+
+func (p *permissionDialog) contentWidth() int {
+	return layout.Current.Container.Width - 12
+}
+
+func (p *permissionDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	width := p.contentWidth()
+	base := styles.NewStyle().Background(t.BackgroundPanel())
+	keyStyle := base.Foreground(t.Text()).Bold(true).Render
+	mutedStyle := base.Foreground(t.TextMuted()).Render
+	textStyle := base.Foreground(t.Text()).Render
+	block := base.Width(width).PaddingLeft(1)
+
+	if p.history {
+		help := keyStyle("h") + mutedStyle(" back to requests")
+		if p.app.PermissionAudit != nil && p.app.PermissionAudit.Path() != "" {
+			help += mutedStyle("   full log: " + p.app.PermissionAudit.Path())
+		}
+		return p.modal.Render(strings.Join([]string{
+			block.Render(strings.Join(p.historyLines(textStyle, mutedStyle, width-2), "\n")),
+			block.PaddingTop(1).Render(help),
+		}, "\n"), background)
+	}
+
+	sections := []string{p.list.View()}
+
+	if item, idx := p.list.GetSelectedItem(); idx >= 0 {
+		sections = append(sections, block.PaddingTop(1).Render(
+			strings.Join(permissionDetails(item, textStyle, mutedStyle, width-2), "\n"),
+		))
+	}
+
+	var help string
+	if p.confirm {
+		var scopes []string
+		for _, permission := range p.targets() {
+			scopes = append(scopes, "• "+app.PermissionScope(permission))
+		}
+		help = base.Foreground(t.Warning()).Render("Always allow:") + "\n" +
+			textStyle(strings.Join(scopes, "\n")) + "\n\n" +
+			keyStyle("a") + mutedStyle(" confirm   ") + keyStyle("any key") + mutedStyle(" cancel")
+	} else {
+		help = keyStyle("space") + mutedStyle(" mark   ") +
+			keyStyle("*") + mutedStyle(" mark all   ") +
+			keyStyle("enter") + mutedStyle(" accept   ") +
+			keyStyle("a") + mutedStyle(" accept always   ") +
+			keyStyle("r") + mutedStyle(" reject   ") +
+			keyStyle("h") + mutedStyle(" policy history")
+	}
+	sections = append(sections, block.PaddingTop(1).Render(help))
+
+	return p.modal.Render(strings.Join(sections, "\n"), background)
+}
+
+// historyLines lists the most recent requests answered by the permission
+// policy, newest first.
+func (p *permissionDialog) historyLines(textStyle, mutedStyle func(string) string, width int) []string {
+	var entries []policy.Decision
+	if p.app.PermissionAudit != nil {
+		entries = p.app.PermissionAudit.Entries()
+	}
+	if len(entries) == 0 {
+		return []string{mutedStyle("No requests have been answered by the permission policy")}
+	}
+	lines := make([]string, 0, maxPermissionHistory)
+	for i, entry := range entries {
+		if i == maxPermissionHistory {
+			lines = append(lines, mutedStyle(fmt.Sprintf("… %d older", len(entries)-i)))
+			break
+		}
+		prefix := fmt.Sprintf("%s  %-5s  %-8s ", entry.Time.Format("01-02 15:04"), entry.Action, entry.Tool)
+		title := truncate.StringWithTail(firstLine(entry.Title), uint(max(width-len(prefix), 8)), "...")
+		lines = append(lines, mutedStyle(prefix)+textStyle(title))
+		rule := "  " + strings.Repeat(" ", len("01-02 15:04")) + "by " + entry.Rule
+		lines = append(lines, mutedStyle(truncate.StringWithTail(rule, uint(width), "...")))
+	}
+	return lines
+}
+
+func (p *permissionDialog) Close() tea.Cmd {
+	return nil
+}
+
+// permissionDetails lists what a request will do: the tool, the session that
+// asked, what "always" would allow, and the request's metadata. Commands and
+// diffs are shown on their own lines, other metadata as key: value.
+func permissionDetails(
+	item permissionItem,
+	textStyle, mutedStyle func(string) string,
+	width int,
+) []string {
+	permission := item.permission
+	field := func(name, value string) string {
+		return mutedStyle(fmt.Sprintf("%-9s", name)) +
+			textStyle(truncate.StringWithTail(value, uint(max(width-9, 8)), "..."))
+	}
+	lines := []string{
+		field("tool", permission.Type),
+		field("session", item.session),
+		field("title", firstLine(permission.Title)),
+		field("always", app.PermissionScope(permission)),
+	}
+
+	metadata := permission.Metadata
+	if command, ok := metadata["command"].(string); ok && command != "" {
+		lines = append(lines, "", textStyle("$ "+command))
+	}
+	if diff, ok := metadata["diff"].(string); ok && diff != "" {
+		lines = append(lines, "")
+		diffLines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+		for i, line := range diffLines {
+			if i == maxPermissionDetails {
+				lines = append(lines, mutedStyle(fmt.Sprintf("… %d more lines", len(diffLines)-i)))
+				break
+			}
+			lines = append(lines, textStyle(truncate.String(line, uint(width))))
+		}
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		if key != "command" && key != "diff" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		lines = append(lines, "")
+	}
+	for _, key := range keys {
+		lines = append(lines, field(key, firstLine(fmt.Sprint(metadata[key]))))
+	}
+	return lines
+}
+
+func sessionLabel(session opencode.Session, currentID string) string {
+	title := session.Title
+	if title == "" {
+		title = session.ID
+	}
+	if session.ID != currentID && session.ParentID == currentID {
+		title += " (subagent)"
+	}
+	return title
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// NewPermissionDialog creates a dialog reviewing the queued permission
+// requests
+func NewPermissionDialog(app *app.App) PermissionDialog {
+	listComponent := list.NewListComponent(
+		list.WithMaxVisibleHeight[permissionItem](numVisiblePermissions),
+		list.WithFallbackMessage[permissionItem]("No pending permission requests"),
+		list.WithAlphaNumericKeys[permissionItem](true),
+		list.WithRenderFunc(
+			func(item permissionItem, selected bool, width int, baseStyle styles.Style) string {
+				return item.Render(selected, width, baseStyle)
+			},
+		),
+		list.WithSelectableFunc(func(item permissionItem) bool {
+			return true
+		}),
+	)
+
+	dialog := &permissionDialog{
+		list:   listComponent,
+		app:    app,
+		titles: map[string]string{},
+		marked: map[string]bool{},
+		modal: modal.New(
+			modal.WithTitle("Permission Requests"),
+			modal.WithMaxWidth(layout.Current.Container.Width-8),
+		),
+	}
+	dialog.list.SetMaxWidth(dialog.contentWidth())
+	// with nothing waiting, open on what the policy has decided
+	dialog.history = len(app.Permissions) == 0
+	dialog.refresh()
+	return dialog
+}
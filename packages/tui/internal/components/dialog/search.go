@@ -253,3 +253,9 @@ func (s *SearchDialog) Blur() {
 	s.focused = false
 	s.textInput.Blur()
 }
+
+// GetSelectedItem returns the highlighted item and its index, which is -1
+// when the list is empty
+func (s *SearchDialog) GetSelectedItem() (list.Item, int) {
+	return s.list.GetSelectedItem()
+}
@@ -30,6 +30,7 @@ type sessionItem struct {
 	title              string
 	isDeleteConfirming bool
 	isCurrentSession   bool
+	hasDraft           bool
 }
 
 func (s sessionItem) Render(
@@ -46,6 +47,9 @@ func (s sessionItem) Render(
 	} else {
 		if s.isCurrentSession {
 			text = "● " + s.title
+		} else if s.hasDraft {
+			// Something was typed in the session but not sent
+			text = "✎ " + s.title
 		} else {
 			text = s.title
 		}
@@ -193,6 +197,7 @@ func (s *sessionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if s.deleteConfirmation == idx {
 						// Second press - actually delete the session
 						sessionToDelete := s.sessions[idx]
+						s.app.State.SetDraft(s.app.Project.Worktree, sessionToDelete.ID, app.Prompt{})
 						return s, tea.Sequence(
 							func() tea.Msg {
 								s.sessions = slices.Delete(s.sessions, idx, idx+1)
@@ -315,6 +320,7 @@ func (s *sessionDialog) updateListItems() {
 			title:              sess.Title,
 			isDeleteConfirming: s.deleteConfirmation == i,
 			isCurrentSession:   s.app.Session != nil && s.app.Session.ID == sess.ID,
+			hasDraft:           s.app.State.HasDraft(sess.ID),
 		}
 		items = append(items, item)
 	}
@@ -366,6 +372,7 @@ func NewSessionDialog(app *app.App) SessionDialog {
 			title:              sess.Title,
 			isDeleteConfirming: false,
 			isCurrentSession:   app.Session != nil && app.Session.ID == sess.ID,
+			hasDraft:           app.State.HasDraft(sess.ID),
 		})
 	}
 
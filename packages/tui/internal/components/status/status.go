@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,10 +38,11 @@ type statusComponent struct {
 	watcher    *fsnotify.Watcher
 	done       chan struct{}
 	lastUpdate time.Time
+	stream     app.EventStreamStatusMsg
 }
 
 func (m *statusComponent) Init() tea.Cmd {
-	return m.startGitWatcher()
+	return tea.Batch(m.startGitWatcher(), m.app.WatchEventStream())
 }
 
 func (m *statusComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -54,6 +56,9 @@ func (m *statusComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// Continue watching for changes (persistent watcher)
 		return m, m.watchForGitChanges()
+	case app.EventStreamStatusMsg:
+		m.stream = msg
+		return m, m.app.WatchEventStream()
 	}
 	return m, nil
 }
@@ -81,6 +86,22 @@ func (m *statusComponent) logo() string {
 		Render(content)
 }
 
+func (m *statusComponent) connection() string {
+	if m.stream.State != app.EventStreamReconnecting {
+		return ""
+	}
+	t := theme.CurrentTheme()
+	text := "reconnecting"
+	if m.width > 60 && m.stream.Attempt > 1 {
+		text += " (" + strconv.Itoa(m.stream.Attempt) + ")"
+	}
+	return styles.NewStyle().
+		Foreground(t.Warning()).
+		Background(t.BackgroundPanel()).
+		Padding(0, 1).
+		Render(text)
+}
+
 func (m *statusComponent) collapsePath(path string, maxWidth int) string {
 	if lipgloss.Width(path) <= maxWidth {
 		return path
@@ -116,7 +137,7 @@ func (m *statusComponent) collapsePath(path string, maxWidth int) string {
 
 func (m *statusComponent) View() string {
 	t := theme.CurrentTheme()
-	logo := m.logo()
+	logo := m.logo() + m.connection()
 	logoWidth := lipgloss.Width(logo)
 
 	var modeBackground compat.AdaptiveColor
@@ -0,0 +1,191 @@
+package textarea
+
+import (
+	"slices"
+	"unicode"
+)
+
+// maxHistory is the number of undo steps kept.
+const maxHistory = 100
+
+// snapshot is a copy of the value and cursor taken before a change.
+type snapshot struct {
+	value    [][]any
+	row, col int
+}
+
+// editKind tells how an edit groups with the ones before it.
+type editKind int
+
+const (
+	// editNone is a key press that leaves the value alone, and the run of an
+	// undo step that nothing more joins.
+	editNone editKind = iota
+	// editTyping and editDeleting are single keystrokes, which a run of
+	// continues the same undo step.
+	editTyping
+	editDeleting
+	// editOther always starts an undo step of its own.
+	editOther
+)
+
+// history holds the undo and redo stacks of the textarea.
+type history struct {
+	undo []snapshot
+	redo []snapshot
+	// run is the kind of edit the last undo step may still grow with, and
+	// row and col are where that edit left the cursor.
+	run      editKind
+	row, col int
+	// depth counts the edits in progress, so that nested ones join the
+	// outermost, and opened records whether it took a checkpoint.
+	depth  int
+	opened bool
+}
+
+// BeginChange starts grouping edits into one undo step, which lasts until the
+// matching EndChange. Calls nest.
+func (m *Model) BeginChange() {
+	m.beginEdit(editOther)
+}
+
+// EndChange ends a group started with BeginChange.
+func (m *Model) EndChange() {
+	m.endEdit()
+}
+
+// ClearHistory forgets every undo and redo step.
+func (m *Model) ClearHistory() {
+	m.history = history{}
+	if m.vim.enabled && m.vim.mode == ModeInsert {
+		m.checkpoint()
+	}
+}
+
+// beginEdit starts an edit of the given kind. Typing and deleting continue the
+// last undo step when they pick up where it left the cursor, so undo takes a
+// run of keystrokes back at once. In Vim insert mode the whole insert is one
+// step already and only other edits start one.
+func (m *Model) beginEdit(kind editKind) {
+	h := &m.history
+	h.depth++
+	if h.depth > 1 {
+		return
+	}
+	h.opened = false
+	if m.vim.enabled && m.vim.mode == ModeInsert && kind != editOther {
+		return
+	}
+	if kind == editOther || kind != h.run || m.row != h.row || m.col != h.col {
+		m.checkpoint()
+		h.opened = true
+	}
+	h.run = kind
+}
+
+// endEdit finishes the edit started by the matching beginEdit.
+func (m *Model) endEdit() {
+	h := &m.history
+	h.depth--
+	if h.depth > 0 {
+		return
+	}
+	if h.opened {
+		h.opened = false
+		if m.dropUnchangedCheckpoint() {
+			h.run = editNone
+		}
+		if m.vim.enabled && m.vim.mode == ModeInsert {
+			// typing after the edit is an insert of its own
+			m.checkpoint()
+		}
+	}
+	h.row, h.col = m.row, m.col
+}
+
+// startsWord reports whether typing text at the cursor begins a new word, which
+// splits a run of typing into one undo step per word.
+func (m *Model) startsWord(text string) bool {
+	runes := []rune(text)
+	return len(runes) > 0 && !unicode.IsSpace(runes[0]) &&
+		m.col > 0 && isSpaceAt(m.value[m.row], m.col-1)
+}
+
+// snapshot copies the value deeply, as rows may share backing arrays with
+// slices that later edits append to. Attachments are never modified in place
+// and are shared, so restoring a snapshot brings back the very attachments it
+// held.
+func (m *Model) snapshot() snapshot {
+	value := make([][]any, len(m.value))
+	for i, row := range m.value {
+		value[i] = copyInterfaceSlice(row)
+	}
+	return snapshot{value: value, row: m.row, col: m.col}
+}
+
+func (m *Model) restore(s snapshot) {
+	m.value = make([][]any, len(s.value), max(len(s.value), maxLines))
+	for i, row := range s.value {
+		m.value[i] = copyInterfaceSlice(row)
+	}
+	m.row = clamp(s.row, 0, len(m.value)-1)
+	m.SetCursorColumn(s.col)
+}
+
+// checkpoint records the current state as an undo step, starting a change.
+func (m *Model) checkpoint() {
+	m.history.undo = append(m.history.undo, m.snapshot())
+	if len(m.history.undo) > maxHistory {
+		m.history.undo = slices.Delete(m.history.undo, 0, len(m.history.undo)-maxHistory)
+	}
+	m.history.redo = nil
+	m.history.run = editNone
+}
+
+// dropUnchangedCheckpoint forgets the last undo step when the change it
+// started left the value as it was, so that undo never seems to do nothing.
+// It reports whether it did.
+func (m *Model) dropUnchangedCheckpoint() bool {
+	undo := m.history.undo
+	if len(undo) > 0 && equalValues(undo[len(undo)-1].value, m.value) {
+		m.history.undo = undo[:len(undo)-1]
+		return true
+	}
+	return false
+}
+
+// Undo restores the state before the last change, reporting whether there
+// was one.
+func (m *Model) Undo() bool {
+	if m.vim.enabled && m.vim.mode == ModeInsert {
+		m.dropUnchangedCheckpoint()
+	}
+	if len(m.history.undo) == 0 {
+		return false
+	}
+	last := m.history.undo[len(m.history.undo)-1]
+	m.history.undo = m.history.undo[:len(m.history.undo)-1]
+	m.history.redo = append(m.history.redo, m.snapshot())
+	m.history.run = editNone
+	m.restore(last)
+	return true
+}
+
+// Redo reapplies the last undone change, reporting whether there was one.
+func (m *Model) Redo() bool {
+	if len(m.history.redo) == 0 {
+		return false
+	}
+	next := m.history.redo[len(m.history.redo)-1]
+	m.history.redo = m.history.redo[:len(m.history.redo)-1]
+	m.history.undo = append(m.history.undo, m.snapshot())
+	m.history.run = editNone
+	m.restore(next)
+	return true
+}
+
+func equalValues(a, b [][]any) bool {
+	return slices.EqualFunc(a, b, func(x, y []any) bool {
+		return slices.Equal(x, y)
+	})
+}
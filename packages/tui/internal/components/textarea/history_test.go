@@ -0,0 +1,103 @@
+package textarea
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sst/opencode/internal/attachment"
+)
+
+func focused() Model {
+	m := New()
+	m.SetWidth(80)
+	m.Focus()
+	return m
+}
+
+// undoAll undoes every step, returning the value after each.
+func undoAll(m *Model) []string {
+	var values []string
+	for m.Undo() {
+		values = append(values, m.Value())
+	}
+	return values
+}
+
+func TestHistoryRuns(t *testing.T) {
+	tests := []struct {
+		keys string
+		want []string
+	}{
+		// typing is undone a word at a time
+		{"hello world", []string{"hello ", ""}},
+		{"abc<bs><bs>", []string{"abc", ""}},
+		{"ab<bs>c", []string{"a", "ab", ""}},
+		// moving the cursor ends a run
+		{"ab<left>c", []string{"ab", ""}},
+		{"a<enter>b", []string{"a\n", "a", ""}},
+	}
+	for _, test := range tests {
+		m := focused()
+		m = feed(m, test.keys)
+		if got := undoAll(&m); !slices.Equal(got, test.want) {
+			t.Errorf("%q: undo went through %q, want %q", test.keys, got, test.want)
+		}
+	}
+}
+
+func TestHistoryRedo(t *testing.T) {
+	m := feed(focused(), "one two")
+	m.Undo()
+	m.Undo()
+	if !m.Redo() || m.Value() != "one " {
+		t.Fatalf("redo gave %q", m.Value())
+	}
+	m = feed(m, "x")
+	if m.Redo() {
+		t.Error("typing did not drop what could be redone")
+	}
+	if m.Undo(); m.Value() != "one " {
+		t.Errorf("undo after typing gave %q", m.Value())
+	}
+}
+
+func TestHistoryReset(t *testing.T) {
+	m := focused()
+	m.InsertString("see ")
+	m.InsertAttachment(&attachment.Attachment{ID: "1", Display: "@main.go"})
+	m.InsertString(" now")
+	m.Reset()
+	if !m.Undo() {
+		t.Fatal("clearing the input could not be undone")
+	}
+	attachments := m.GetAttachments()
+	if got := m.Value(); got != "see @main.go now" || len(attachments) != 1 || attachments[0].ID != "1" {
+		t.Errorf("undo brought back %q with %v", got, attachments)
+	}
+	m.Redo()
+	if m.Value() != "" {
+		t.Errorf("redo gave %q", m.Value())
+	}
+}
+
+func TestHistoryChanges(t *testing.T) {
+	m := focused()
+	m.SetValue("draft")
+	m.SetValue("other")
+	if got := undoAll(&m); !slices.Equal(got, []string{"draft", ""}) {
+		t.Errorf("SetValue steps: %q", got)
+	}
+
+	m = feed(focused(), "see @ma")
+	m.BeginChange()
+	m.ReplaceRange(4, 7, "")
+	m.InsertAttachment(&attachment.Attachment{ID: "1", Display: "@main.go"})
+	m.InsertString(" ")
+	m.EndChange()
+	if got := m.Value(); got != "see @main.go " {
+		t.Fatalf("got %q", got)
+	}
+	if m.Undo(); m.Value() != "see @ma" {
+		t.Errorf("a grouped change took more than one undo: %q", m.Value())
+	}
+}
@@ -102,8 +102,9 @@ func (m Model) isAttachmentAtCursor() (*attachment.Attachment, int, int) {
 	}
 
 	// Check if the cursor is immediately after an attachment. This is a common
-	// state, for example, after just inserting one.
-	if col > 0 && col <= len(row) {
+	// state, for example, after just inserting one. Outside Vim's insert mode
+	// the cursor is always on the item it selects.
+	if col > 0 && col <= len(row) && m.vim.mode == ModeInsert {
 		if att, ok := row[col-1].(*attachment.Attachment); ok {
 			return att, col - 1, col - 1
 		}
@@ -112,21 +113,31 @@ func (m Model) isAttachmentAtCursor() (*attachment.Attachment, int, int) {
 	return nil, -1, -1
 }
 
-// renderLineWithAttachments renders a line with proper attachment highlighting
+// renderLineWithAttachments renders a line with proper attachment highlighting.
+// The items start at column start of the given row, which places the visual
+// selection.
 func (m Model) renderLineWithAttachments(
 	items []any,
+	row, start int,
 	style lipgloss.Style,
 ) string {
 	var s strings.Builder
 	currentAttachment, _, _ := m.isAttachmentAtCursor()
 
-	for _, item := range items {
+	for i, item := range items {
+		selected := m.inSelection(row, start+i)
 		switch val := item.(type) {
 		case rune:
-			s.WriteString(style.Render(string(val)))
+			if selected {
+				s.WriteString(m.Styles.Selection.Render(string(val)))
+			} else {
+				s.WriteString(style.Render(string(val)))
+			}
 		case *attachment.Attachment:
 			// Check if this is the attachment the cursor is currently on
-			if currentAttachment != nil && currentAttachment.ID == val.ID {
+			if selected {
+				s.WriteString(m.Styles.Selection.Render(val.Display))
+			} else if currentAttachment != nil && currentAttachment.ID == val.ID {
 				// Cursor is on this attachment, highlight it
 				s.WriteString(m.Styles.SelectedAttachment.Render(val.Display))
 			} else {
@@ -362,6 +373,8 @@ type Styles struct {
 	Cursor             CursorStyle
 	Attachment         lipgloss.Style
 	SelectedAttachment lipgloss.Style
+	// Selection styles text selected in Vim's visual mode.
+	Selection lipgloss.Style
 }
 
 // StyleState that will be applied to the text area.
@@ -522,6 +535,12 @@ type Model struct {
 
 	// rune sanitizer for input.
 	rsan Sanitizer
+
+	// history holds the undo and redo steps.
+	history history
+
+	// vim is the state of Vim mode, when enabled with [SetVimMode].
+	vim vimState
 }
 
 // New creates a new model with default settings.
@@ -596,6 +615,7 @@ func DefaultStyles(isDark bool) Styles {
 	s.SelectedAttachment = lipgloss.NewStyle().
 		Background(lipgloss.Color("11")).
 		Foreground(lipgloss.Color("0"))
+	s.Selection = lipgloss.NewStyle().Reverse(true)
 	s.Cursor = CursorStyle{
 		Color: lipgloss.Color("7"),
 		Shape: tea.CursorBlock,
@@ -638,8 +658,10 @@ func (m *Model) updateVirtualCursorStyle() {
 
 // SetValue sets the value of the text input.
 func (m *Model) SetValue(s string) {
+	m.BeginChange()
 	m.Reset()
 	m.InsertString(s)
+	m.EndChange()
 }
 
 // InsertString inserts a string at the cursor position.
@@ -654,6 +676,9 @@ func (m *Model) InsertRune(r rune) {
 
 // InsertAttachment inserts an attachment at the cursor position.
 func (m *Model) InsertAttachment(att *attachment.Attachment) {
+	m.beginEdit(editOther)
+	defer m.endEdit()
+
 	if m.CharLimit > 0 {
 		availSpace := m.CharLimit - m.Length()
 		// If the char limit's been reached, cancel.
@@ -695,6 +720,9 @@ func (m *Model) removeAttachmentAtCursor() bool {
 // ReplaceRange replaces text from startCol to endCol on the current row with the given string.
 // This preserves attachments outside the replaced range.
 func (m *Model) ReplaceRange(startCol, endCol int, replacement string) {
+	m.beginEdit(editOther)
+	defer m.endEdit()
+
 	if m.row >= len(m.value) || startCol < 0 || endCol < startCol {
 		return
 	}
@@ -765,6 +793,9 @@ func (m Model) GetAttachments() []*attachment.Attachment {
 
 // InsertRunesFromUserInput inserts runes at the current cursor position.
 func (m *Model) InsertRunesFromUserInput(runes []rune) {
+	m.beginEdit(editOther)
+	defer m.endEdit()
+
 	// Clean up any special characters in the input provided by the
 	// clipboard. This avoids bugs due to e.g. tab characters and
 	// whatnot.
@@ -924,6 +955,9 @@ func (m Model) LastRuneIndex(r rune) int {
 }
 
 func (m *Model) Newline() {
+	m.beginEdit(editOther)
+	defer m.endEdit()
+
 	if m.MaxHeight > 0 && len(m.value) >= m.MaxHeight {
 		return
 	}
@@ -1177,12 +1211,17 @@ func (m *Model) Blur() {
 	m.virtualCursor.Blur()
 }
 
-// Reset sets the input to its default state with no input.
+// Reset sets the input to its default state with no input. Like any other
+// change it can be undone.
 func (m *Model) Reset() {
+	m.beginEdit(editOther)
+	defer m.endEdit()
+
 	m.value = make([][]any, minHeight, maxLines)
 	m.col = 0
 	m.row = 0
 	m.SetCursorColumn(0)
+	m.resetVim()
 }
 
 // san initializes or retrieves the rune sanitizer.
@@ -1583,6 +1622,16 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
+		if m.vim.enabled && m.updateVim(msg) {
+			break
+		}
+		kind := m.classify(msg)
+		if kind == editTyping && m.startsWord(msg.Text) {
+			m.history.run = editNone
+		}
+		if kind != editNone {
+			m.beginEdit(kind)
+		}
 		switch {
 		case key.Matches(msg, m.KeyMap.DeleteAfterCursor):
 			m.col = clamp(m.col, 0, len(m.value[m.row]))
@@ -1675,6 +1724,9 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		default:
 			m.InsertRunesFromUserInput([]rune(msg.Text))
 		}
+		if kind != editNone {
+			m.endEdit()
+		}
 
 	case pasteMsg:
 		m.InsertRunesFromUserInput([]rune(msg))
@@ -1695,6 +1747,26 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// classify tells how a key press that Update handles changes the value.
+func (m Model) classify(msg tea.KeyPressMsg) editKind {
+	switch {
+	case key.Matches(msg, m.KeyMap.DeleteCharacterBackward, m.KeyMap.DeleteCharacterForward,
+		m.KeyMap.DeleteWordBackward, m.KeyMap.DeleteWordForward):
+		return editDeleting
+	case key.Matches(msg, m.KeyMap.DeleteAfterCursor, m.KeyMap.DeleteBeforeCursor,
+		m.KeyMap.InsertNewline, m.KeyMap.LowercaseWordForward, m.KeyMap.UppercaseWordForward,
+		m.KeyMap.CapitalizeWordForward, m.KeyMap.TransposeCharacterBackward):
+		return editOther
+	case key.Matches(msg, m.KeyMap.LineEnd, m.KeyMap.LineStart, m.KeyMap.CharacterForward,
+		m.KeyMap.LineNext, m.KeyMap.WordForward, m.KeyMap.CharacterBackward,
+		m.KeyMap.LinePrevious, m.KeyMap.WordBackward, m.KeyMap.InputBegin, m.KeyMap.InputEnd):
+		return editNone
+	case msg.Text != "":
+		return editTyping
+	}
+	return editNone
+}
+
 // View renders the text area in its current state.
 func (m Model) View() string {
 	m.updateVirtualCursorStyle()
@@ -1722,6 +1794,7 @@ func (m Model) View() string {
 			style = styles.computedText()
 		}
 
+		offset := 0
 		for wl, wrappedLine := range wrappedLines {
 			prompt := m.promptView(displayLine)
 			prompt = styles.computedPrompt().Render(prompt)
@@ -1765,6 +1838,7 @@ func (m Model) View() string {
 				s.WriteString(
 					m.renderLineWithAttachments(
 						wrappedLine[:lineInfo.ColumnOffset],
+						l, offset,
 						style,
 					),
 				)
@@ -1786,19 +1860,24 @@ func (m Model) View() string {
 					}
 
 					// Render the part of the line after the cursor
-					s.WriteString(m.renderLineWithAttachments(wrappedLine[lineInfo.ColumnOffset+1:], style))
+					s.WriteString(m.renderLineWithAttachments(
+						wrappedLine[lineInfo.ColumnOffset+1:],
+						l, offset+lineInfo.ColumnOffset+1,
+						style,
+					))
 				} else {
 					// Cursor is at the end of the line
 					m.virtualCursor.SetChar(" ")
 					s.WriteString(style.Render(m.virtualCursor.View()))
 				}
 			} else {
-				s.WriteString(m.renderLineWithAttachments(wrappedLine, style))
+				s.WriteString(m.renderLineWithAttachments(wrappedLine, l, offset, style))
 			}
 
 			s.WriteString(style.Render(strings.Repeat(" ", max(0, padding))))
 			s.WriteRune('\n')
 			newLines++
+			offset += len(wrappedLine)
 		}
 	}
 
@@ -1984,6 +2063,14 @@ func (m Model) Cursor() *tea.Cursor {
 	c.Blink = m.Styles.Cursor.Blink
 	c.Color = m.Styles.Cursor.Color
 	c.Shape = m.Styles.Cursor.Shape
+	if m.vim.enabled {
+		// a bar between characters where text goes in, a block on the
+		// character commands apply to
+		c.Shape = tea.CursorBlock
+		if m.vim.mode == ModeInsert {
+			c.Shape = tea.CursorBar
+		}
+	}
 	return c
 }
 
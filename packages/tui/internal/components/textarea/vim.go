@@ -0,0 +1,1427 @@
+package textarea
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/google/uuid"
+	"github.com/sst/opencode/internal/attachment"
+)
+
+// Mode is the editing mode of the textarea when Vim mode is enabled.
+type Mode int
+
+const (
+	ModeInsert Mode = iota
+	ModeNormal
+	ModeVisual
+	ModeVisualLine
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeNormal:
+		return "NORMAL"
+	case ModeVisual:
+		return "VISUAL"
+	case ModeVisualLine:
+		return "V-LINE"
+	}
+	return "INSERT"
+}
+
+// maxCount bounds counts so that a mistyped one can't stall the editor.
+const maxCount = 9999
+
+// vimState is the state of Vim mode.
+type vimState struct {
+	enabled bool
+	mode    Mode
+	// pending holds the keys of a command still being typed, such as `"a2d`
+	pending []string
+	// anchor is where the visual selection started
+	anchor pos
+	// want is the column vertical motions try to keep
+	want      int
+	registers map[string]register
+	// lastFind is the f, F, t or T that ; and , repeat
+	lastFind command
+	// change is the command being made, until it leaves insert mode
+	change *command
+	// insertKeys are the keys typed since insert mode was entered, and
+	// insertCount how many times to type them
+	insertKeys  []tea.KeyPressMsg
+	insertCount int
+	// lastChange is what . repeats
+	lastChange *recordedChange
+}
+
+type recordedChange struct {
+	command command
+	keys    []tea.KeyPressMsg
+}
+
+// pos is a position in the value: a row and an index into it.
+type pos struct{ row, col int }
+
+func (p pos) before(q pos) bool {
+	return p.row < q.row || p.row == q.row && p.col < q.col
+}
+
+// span is the text an operator applies to: from start up to end when
+// charwise, rows start.row through end.row when linewise.
+type span struct {
+	start, end pos
+	linewise   bool
+}
+
+func (s span) empty() bool {
+	return !s.linewise && s.start == s.end
+}
+
+// register holds yanked or deleted text, attachments included.
+type register struct {
+	rows     [][]any
+	linewise bool
+}
+
+// SetVimMode turns Vim keybindings on or off. The textarea starts out in
+// insert mode.
+func (m *Model) SetVimMode(enabled bool) {
+	m.vim = vimState{enabled: enabled, registers: m.vim.registers}
+	m.resetVim()
+}
+
+// VimEnabled reports whether Vim keybindings are on.
+func (m Model) VimEnabled() bool {
+	return m.vim.enabled
+}
+
+// Mode returns the current Vim mode, which is always insert mode when Vim
+// keybindings are off.
+func (m Model) Mode() Mode {
+	return m.vim.mode
+}
+
+// WantsKey reports whether Vim mode takes a key the application would
+// otherwise handle itself: printable keys outside insert mode, which are
+// commands rather than text, and esc while it leaves insert or visual mode
+// or cancels a pending command.
+func (m Model) WantsKey(msg tea.KeyPressMsg) bool {
+	if !m.vim.enabled || !m.focus {
+		return false
+	}
+	if msg.String() == "esc" {
+		return m.vim.mode != ModeNormal || len(m.vim.pending) > 0
+	}
+	if m.vim.mode == ModeInsert {
+		return false
+	}
+	return msg.Text != "" || len(m.vim.pending) > 0 || msg.String() == "ctrl+r"
+}
+
+// resetVim returns to insert mode with a fresh undo step, as after clearing
+// the input.
+func (m *Model) resetVim() {
+	v := &m.vim
+	v.mode = ModeInsert
+	v.pending = nil
+	v.change = nil
+	v.insertKeys = nil
+	v.insertCount = 1
+	if v.enabled && m.history.depth == 0 {
+		m.checkpoint()
+	}
+}
+
+// updateVim handles a key press in Vim mode, reporting whether it was used.
+// In insert mode only esc is, and other keys are recorded for repeating.
+func (m *Model) updateVim(msg tea.KeyPressMsg) bool {
+	v := &m.vim
+	if v.mode == ModeInsert {
+		if msg.String() == "esc" {
+			m.exitInsert()
+			return true
+		}
+		v.insertKeys = append(v.insertKeys, msg)
+		return false
+	}
+
+	key := msg.Text
+	if key == "" {
+		key = msg.String()
+	}
+	if key == "esc" {
+		v.pending = nil
+		if v.mode != ModeNormal {
+			m.exitVisual()
+		}
+		return true
+	}
+
+	v.pending = append(v.pending, key)
+	cmd, result := parseCommand(v.pending, v.mode != ModeNormal)
+	switch result {
+	case parsePending:
+		return true
+	case parseInvalid:
+		v.pending = nil
+		return true
+	}
+	v.pending = nil
+	if v.mode == ModeNormal {
+		m.runNormal(cmd)
+	} else {
+		m.runVisual(cmd)
+	}
+	return true
+}
+
+// command is a normal or visual mode command, such as `"a2dw`.
+type command struct {
+	register string
+	// count is zero when none was typed
+	count int
+	// op is the operator, d, c or y, applied over the motion or text object
+	// in key; dd, cc and yy repeat the operator as the key
+	op  string
+	key string
+	// arg is the character argument of f, t, r and the like
+	arg string
+}
+
+type parseResult int
+
+const (
+	parsePending parseResult = iota
+	parseDone
+	parseInvalid
+)
+
+var (
+	motionKeys = []string{
+		"h", "j", "k", "l", "left", "down", "up", "right", "backspace", " ",
+		"w", "W", "b", "B", "e", "E", "0", "^", "$", "home", "end",
+		"gg", "G", "f", "F", "t", "T", ";", ",",
+	}
+	normalKeys = []string{
+		"x", "X", "s", "S", "D", "C", "Y", "r", "~", "J", "p", "P",
+		"o", "O", "i", "a", "I", "A", "v", "V", "u", "ctrl+r", ".",
+	}
+	visualKeys = []string{
+		"o", "O", "d", "x", "X", "D", "c", "s", "C", "S", "R", "y", "Y",
+		"p", "P", "~", "u", "U", "J", "r", "v", "V",
+	}
+	// objectKeys follow i or a to name a text object
+	objectKeys = "wW\"'`()b[]{}B<>"
+)
+
+// aliases are the commands that stand for an operator and a motion.
+var aliases = map[string]command{
+	"x": {op: "d", key: "l"},
+	"X": {op: "d", key: "h"},
+	"D": {op: "d", key: "$"},
+	"C": {op: "c", key: "$"},
+	"s": {op: "c", key: "l"},
+	"S": {op: "c", key: "c"},
+	"Y": {op: "y", key: "y"},
+}
+
+// parseCommand parses the keys typed so far, reporting whether they make a
+// whole command, could still become one, or never will.
+func parseCommand(keys []string, visual bool) (command, parseResult) {
+	var cmd command
+	i := 0
+	if keys[0] == `"` {
+		if len(keys) < 2 {
+			return cmd, parsePending
+		}
+		if !isRegister(keys[1]) {
+			return cmd, parseInvalid
+		}
+		cmd.register, i = keys[1], 2
+	}
+	cmd.count, i = parseCount(keys, i)
+	if i == len(keys) {
+		return cmd, parsePending
+	}
+
+	if !visual && (keys[i] == "d" || keys[i] == "c" || keys[i] == "y") {
+		cmd.op = keys[i]
+		var count int
+		count, i = parseCount(keys, i+1)
+		cmd.count = multiplyCounts(cmd.count, count)
+		if i == len(keys) {
+			return cmd, parsePending
+		}
+		if keys[i] == cmd.op {
+			cmd.key = keys[i]
+			return cmd, parseDone
+		}
+	}
+
+	key, rest := keys[i], keys[i+1:]
+	switch {
+	case key == "g":
+		if len(rest) == 0 {
+			return cmd, parsePending
+		}
+		if rest[0] != "g" {
+			return cmd, parseInvalid
+		}
+		cmd.key = "gg"
+	case key == "f" || key == "F" || key == "t" || key == "T" || key == "r" && cmd.op == "":
+		if len(rest) == 0 {
+			return cmd, parsePending
+		}
+		if utf8.RuneCountInString(rest[0]) != 1 {
+			return cmd, parseInvalid
+		}
+		cmd.key, cmd.arg = key, rest[0]
+	case (key == "i" || key == "a") && (cmd.op != "" || visual):
+		if len(rest) == 0 {
+			return cmd, parsePending
+		}
+		if len(rest[0]) != 1 || !strings.Contains(objectKeys, rest[0]) {
+			return cmd, parseInvalid
+		}
+		cmd.key = key + rest[0]
+	default:
+		actions := normalKeys
+		if visual {
+			actions = visualKeys
+		}
+		if !slices.Contains(motionKeys, key) && (cmd.op != "" || !slices.Contains(actions, key)) {
+			return cmd, parseInvalid
+		}
+		cmd.key = key
+	}
+	return cmd, parseDone
+}
+
+func parseCount(keys []string, i int) (int, int) {
+	count := 0
+	for ; i < len(keys); i++ {
+		key := keys[i]
+		// a leading 0 is the motion to the start of the line
+		if len(key) != 1 || key[0] < '0' || key[0] > '9' || key == "0" && count == 0 {
+			break
+		}
+		count = min(count*10+int(key[0]-'0'), maxCount)
+	}
+	return count, i
+}
+
+func multiplyCounts(a, b int) int {
+	if a == 0 {
+		return b
+	}
+	if b == 0 {
+		return a
+	}
+	return min(a*b, maxCount)
+}
+
+func isRegister(key string) bool {
+	if len(key) != 1 {
+		return false
+	}
+	c := key[0]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.ContainsRune(`"-_`, rune(c))
+}
+
+func isObject(key string) bool {
+	return len(key) == 2 && (key[0] == 'i' || key[0] == 'a')
+}
+
+// isChange reports whether a normal mode command modifies the text, making
+// it an undo step and what . repeats.
+func isChange(cmd command) bool {
+	if cmd.op != "" {
+		return cmd.op != "y"
+	}
+	return strings.Contains("xXsSDCr~JpPoOiaIA", cmd.key) && len(cmd.key) == 1
+}
+
+// runNormal executes a command typed in normal mode.
+func (m *Model) runNormal(cmd command) {
+	v := &m.vim
+	if cmd.key == "." && cmd.op == "" {
+		m.repeatChange(cmd.count)
+		return
+	}
+	if isChange(cmd) {
+		m.checkpoint()
+		v.change = &cmd
+		v.insertKeys = nil
+	}
+	m.execute(cmd)
+	if v.change != nil && v.mode != ModeInsert {
+		m.finishChange()
+	}
+
+	switch {
+	case cmd.op == "" && slices.Contains([]string{"j", "k", "up", "down"}, cmd.key):
+	case cmd.op == "" && (cmd.key == "$" || cmd.key == "end"):
+		v.want = math.MaxInt
+	default:
+		v.want = m.col
+	}
+	m.clampCursor()
+}
+
+func (m *Model) execute(cmd command) {
+	v := &m.vim
+	n := max(1, cmd.count)
+	if cmd.op != "" {
+		if s, ok := m.motionSpan(cmd); ok {
+			m.operate(cmd.op, s, cmd.register)
+		}
+		return
+	}
+	if alias, ok := aliases[cmd.key]; ok {
+		alias.register, alias.count = cmd.register, cmd.count
+		m.execute(alias)
+		return
+	}
+
+	switch cmd.key {
+	case "r":
+		m.replaceChars(cmd.arg, n)
+	case "~":
+		row := m.value[m.row]
+		end := min(len(row), m.col+n)
+		m.mapCase(span{pos{m.row, m.col}, pos{m.row, end}, false}, swapCase)
+		m.SetCursorColumn(end)
+	case "J":
+		m.join(m.row, max(1, n-1))
+	case "p", "P":
+		p := m.cursor()
+		r := m.register(cmd.register)
+		if cmd.key == "p" {
+			if r.linewise {
+				p.row++
+			} else if len(m.value[p.row]) > 0 {
+				p.col++
+			}
+		}
+		m.putAt(p, r, n)
+	case "i", "a", "I", "A":
+		switch cmd.key {
+		case "a":
+			m.SetCursorColumn(m.col + 1)
+		case "I":
+			m.SetCursorColumn(firstNonBlank(m.value[m.row]))
+		case "A":
+			m.CursorEnd()
+		}
+		m.enterInsert(n)
+	case "o", "O":
+		m.openLine(cmd.key == "o")
+		m.enterInsert(n)
+	case "v", "V":
+		v.anchor = m.cursor()
+		v.mode = ModeVisual
+		if cmd.key == "V" {
+			v.mode = ModeVisualLine
+		}
+	case "u":
+		for range n {
+			if !m.Undo() {
+				break
+			}
+		}
+	case "ctrl+r":
+		for range n {
+			if !m.Redo() {
+				break
+			}
+		}
+	default:
+		if target, _, ok := m.motion(cmd); ok {
+			m.row = target.row
+			m.SetCursorColumn(target.col)
+		}
+	}
+}
+
+// runVisual executes a command typed in visual mode, which applies to the
+// selection.
+func (m *Model) runVisual(cmd command) {
+	v := &m.vim
+	n := max(1, cmd.count)
+	if isObject(cmd.key) {
+		if s, ok := m.textObject(cmd.key, n); ok && !s.empty() {
+			v.anchor = s.start
+			if s.linewise {
+				v.mode = ModeVisualLine
+				m.row = s.end.row
+			} else {
+				end, _ := m.prev(s.end)
+				m.row = end.row
+				m.SetCursorColumn(end.col)
+			}
+		}
+		return
+	}
+
+	s := m.visualSpan()
+	if strings.Contains("XDYCSR", cmd.key) {
+		s = span{pos{s.start.row, 0}, pos{m.visualEndRow(), 0}, true}
+	}
+	changes := strings.Contains("dxXDcsCSRpP~uUJr", cmd.key)
+	if changes {
+		m.checkpoint()
+	}
+
+	switch cmd.key {
+	case "o", "O":
+		anchor := v.anchor
+		v.anchor = m.cursor()
+		m.row = anchor.row
+		m.SetCursorColumn(anchor.col)
+		return
+	case "v", "V":
+		mode := ModeVisual
+		if cmd.key == "V" {
+			mode = ModeVisualLine
+		}
+		if v.mode == mode {
+			m.exitVisual()
+		} else {
+			v.mode = mode
+		}
+		return
+	case "y", "Y":
+		m.operate("y", s, cmd.register)
+	case "d", "x", "X", "D":
+		m.operate("d", s, cmd.register)
+	case "c", "s", "C", "S", "R":
+		m.operate("c", s, cmd.register)
+		return
+	case "p", "P":
+		m.replaceSelection(s, m.register(cmd.register), cmd.key == "p")
+	case "~":
+		m.mapCase(s, swapCase)
+		m.setCursor(s.start)
+	case "u":
+		m.mapCase(s, unicode.ToLower)
+		m.setCursor(s.start)
+	case "U":
+		m.mapCase(s, unicode.ToUpper)
+		m.setCursor(s.start)
+	case "J":
+		m.join(s.start.row, max(1, m.visualEndRow()-s.start.row))
+	case "r":
+		r, _ := utf8.DecodeRuneInString(cmd.arg)
+		m.mapItems(s, func(any) any { return r })
+		m.setCursor(s.start)
+	default:
+		if target, _, ok := m.motion(cmd); ok {
+			m.row = target.row
+			m.SetCursorColumn(target.col)
+			if cmd.key == "$" || cmd.key == "end" {
+				v.want = math.MaxInt
+			} else if !slices.Contains([]string{"j", "k", "up", "down"}, cmd.key) {
+				v.want = m.col
+			}
+			m.clampCursor()
+		}
+		return
+	}
+
+	if changes {
+		m.dropUnchangedCheckpoint()
+	}
+	m.exitVisual()
+}
+
+func (m *Model) exitVisual() {
+	m.vim.mode = ModeNormal
+	m.clampCursor()
+}
+
+// visualSpan is the selected text: from the anchor to the cursor, both
+// included.
+func (m *Model) visualSpan() span {
+	start, end := m.clampPos(m.vim.anchor), m.cursor()
+	if end.before(start) {
+		start, end = end, start
+	}
+	if m.vim.mode == ModeVisualLine {
+		return span{pos{start.row, 0}, pos{end.row, 0}, true}
+	}
+	return span{start, m.after(end), false}
+}
+
+func (m *Model) visualEndRow() int {
+	return max(m.clampPos(m.vim.anchor).row, m.row)
+}
+
+// inSelection reports whether the item at row and col is in the visual
+// selection.
+func (m Model) inSelection(row, col int) bool {
+	if !m.vim.enabled || m.vim.mode != ModeVisual && m.vim.mode != ModeVisualLine {
+		return false
+	}
+	s := m.visualSpan()
+	if s.linewise {
+		return row >= s.start.row && row <= s.end.row
+	}
+	p := pos{row, col}
+	return !p.before(s.start) && p.before(s.end)
+}
+
+func (m *Model) enterInsert(count int) {
+	m.vim.mode = ModeInsert
+	m.vim.insertKeys = nil
+	m.vim.insertCount = count
+}
+
+// exitInsert returns to normal mode, typing the inserted text again when the
+// insert was given a count, and moves the cursor back onto the last
+// character as Vim does.
+func (m *Model) exitInsert() {
+	v := &m.vim
+	keys := v.insertKeys
+	if change := v.change; change != nil && change.op == "" {
+		for range v.insertCount - 1 {
+			if change.key == "o" || change.key == "O" {
+				m.openLine(change.key == "o")
+			}
+			m.replayKeys(keys)
+		}
+	}
+	v.insertKeys = keys
+	v.mode = ModeNormal
+	m.finishChange()
+	m.SetCursorColumn(m.col - 1)
+	v.want = m.col
+}
+
+// finishChange completes the change being made: it becomes what . repeats,
+// and an undo step when it modified anything.
+func (m *Model) finishChange() {
+	v := &m.vim
+	if v.change != nil {
+		v.lastChange = &recordedChange{command: *v.change, keys: v.insertKeys}
+		v.change = nil
+	}
+	v.insertKeys = nil
+	v.insertCount = 1
+	m.dropUnchangedCheckpoint()
+}
+
+// repeatChange is the . command. A count replaces the one the change was
+// made with.
+func (m *Model) repeatChange(count int) {
+	last := m.vim.lastChange
+	if last == nil {
+		return
+	}
+	cmd := last.command
+	if count > 0 {
+		cmd.count = count
+	}
+	m.runNormal(cmd)
+	if m.vim.mode == ModeInsert {
+		m.replayKeys(last.keys)
+		m.exitInsert()
+	}
+}
+
+func (m *Model) replayKeys(keys []tea.KeyPressMsg) {
+	for _, key := range keys {
+		*m, _ = m.Update(key)
+	}
+}
+
+func (m *Model) cursor() pos {
+	return pos{m.row, m.col}
+}
+
+func (m *Model) setCursor(p pos) {
+	m.row = clamp(p.row, 0, len(m.value)-1)
+	m.SetCursorColumn(p.col)
+}
+
+func (m *Model) clampPos(p pos) pos {
+	p.row = clamp(p.row, 0, len(m.value)-1)
+	p.col = clamp(p.col, 0, len(m.value[p.row]))
+	return p
+}
+
+// clampCursor keeps the cursor on a character outside insert mode, where
+// there is no position past the end of the line.
+func (m *Model) clampCursor() {
+	m.row = clamp(m.row, 0, len(m.value)-1)
+	if m.vim.mode != ModeInsert {
+		m.SetCursorColumn(min(m.col, len(m.value[m.row])-1))
+	}
+}
+
+// next returns the position after p, where the end of each row stands for
+// its line break.
+func (m *Model) next(p pos) (pos, bool) {
+	if p.col < len(m.value[p.row]) {
+		return pos{p.row, p.col + 1}, true
+	}
+	if p.row < len(m.value)-1 {
+		return pos{p.row + 1, 0}, true
+	}
+	return p, false
+}
+
+func (m *Model) prev(p pos) (pos, bool) {
+	if p.col > 0 {
+		return pos{p.row, p.col - 1}, true
+	}
+	if p.row > 0 {
+		return pos{p.row - 1, len(m.value[p.row-1])}, true
+	}
+	return p, false
+}
+
+// after is the end of a span that includes the item at p, which at the end
+// of a row is its line break.
+func (m *Model) after(p pos) pos {
+	next, _ := m.next(p)
+	return next
+}
+
+func (m *Model) runeAt(p pos) rune {
+	return getRuneAt(m.value[p.row], p.col)
+}
+
+// charClass groups the items that make up a word.
+type charClass int
+
+const (
+	classBlank charClass = iota
+	classPunct
+	classWord
+	// classAttachment is the class of an attachment, which is a word of its
+	// own
+	classAttachment
+)
+
+func (m *Model) classAt(p pos, big bool) charClass {
+	row := m.value[p.row]
+	if p.col >= len(row) {
+		return classBlank
+	}
+	switch item := row[p.col].(type) {
+	case *attachment.Attachment:
+		return classAttachment
+	case rune:
+		switch {
+		case unicode.IsSpace(item):
+			return classBlank
+		case big, item == '_', unicode.IsLetter(item), unicode.IsDigit(item):
+			return classWord
+		}
+	}
+	return classPunct
+}
+
+// nextWordStart is the w motion: the start of the next word, or an empty
+// line.
+func (m *Model) nextWordStart(p pos, big bool) pos {
+	start := p
+	if c := m.classAt(p, big); c != classBlank {
+		for {
+			next, ok := m.next(p)
+			if !ok {
+				return p
+			}
+			p = next
+			if c == classAttachment || m.classAt(p, big) != c {
+				break
+			}
+		}
+	}
+	for m.classAt(p, big) == classBlank {
+		if p != start && p.col == 0 && len(m.value[p.row]) == 0 {
+			return p
+		}
+		next, ok := m.next(p)
+		if !ok {
+			return p
+		}
+		p = next
+	}
+	return p
+}
+
+// prevWordStart is the b motion: the start of the word before p, or an empty
+// line.
+func (m *Model) prevWordStart(p pos, big bool) pos {
+	p, ok := m.prev(p)
+	if !ok {
+		return p
+	}
+	for m.classAt(p, big) == classBlank {
+		if p.col == 0 && len(m.value[p.row]) == 0 {
+			return p
+		}
+		if p, ok = m.prev(p); !ok {
+			return p
+		}
+	}
+	c := m.classAt(p, big)
+	for c != classAttachment {
+		prev, ok := m.prev(p)
+		if !ok || m.classAt(prev, big) != c {
+			break
+		}
+		p = prev
+	}
+	return p
+}
+
+// wordEnd is the e motion: the end of the word after p.
+func (m *Model) wordEnd(p pos, big bool) pos {
+	p, ok := m.next(p)
+	if !ok {
+		return p
+	}
+	for m.classAt(p, big) == classBlank {
+		if p, ok = m.next(p); !ok {
+			return p
+		}
+	}
+	c := m.classAt(p, big)
+	for c != classAttachment {
+		next, ok := m.next(p)
+		if !ok || m.classAt(next, big) != c {
+			break
+		}
+		p = next
+	}
+	return p
+}
+
+func (m *Model) atWordEnd(p pos, big bool) bool {
+	c := m.classAt(p, big)
+	return c != classBlank && (c == classAttachment || m.classAt(pos{p.row, p.col + 1}, big) != c)
+}
+
+func firstNonBlank(items []any) int {
+	for i := range items {
+		if !isSpaceAt(items, i) {
+			return i
+		}
+	}
+	return len(items)
+}
+
+// motionKind is how an operator treats the text a motion moves over.
+type motionKind int
+
+const (
+	exclusive motionKind = iota
+	inclusive
+	linewise
+)
+
+// motion returns where a motion moves the cursor and how an operator treats
+// the text moved over, or false when the motion fails, as f does when the
+// character isn't on the line.
+func (m *Model) motion(cmd command) (pos, motionKind, bool) {
+	p := m.cursor()
+	n := max(1, cmd.count)
+	row := m.value[p.row]
+	switch cmd.key {
+	case "h", "left", "backspace":
+		return pos{p.row, max(0, p.col-n)}, exclusive, true
+	case "l", "right", " ":
+		return pos{p.row, min(len(row), p.col+n)}, exclusive, true
+	case "j", "down":
+		target := min(len(m.value)-1, p.row+n)
+		return pos{target, m.wantColumn(target)}, linewise, target != p.row
+	case "k", "up":
+		target := max(0, p.row-n)
+		return pos{target, m.wantColumn(target)}, linewise, target != p.row
+	case "0", "home":
+		return pos{p.row, 0}, exclusive, true
+	case "^":
+		return pos{p.row, firstNonBlank(row)}, exclusive, true
+	case "$", "end":
+		target := min(len(m.value)-1, p.row+n-1)
+		if len(m.value[target]) == 0 {
+			return pos{target, 0}, exclusive, true
+		}
+		return pos{target, len(m.value[target]) - 1}, inclusive, true
+	case "w", "W":
+		for range n {
+			p = m.nextWordStart(p, cmd.key == "W")
+		}
+		return p, exclusive, true
+	case "b", "B":
+		for range n {
+			p = m.prevWordStart(p, cmd.key == "B")
+		}
+		return p, exclusive, true
+	case "e", "E":
+		for range n {
+			p = m.wordEnd(p, cmd.key == "E")
+		}
+		return p, inclusive, true
+	case "gg", "G":
+		target := 0
+		if cmd.key == "G" {
+			target = len(m.value) - 1
+		}
+		if cmd.count > 0 {
+			target = min(cmd.count, len(m.value)) - 1
+		}
+		return pos{target, firstNonBlank(m.value[target])}, linewise, true
+	case "f", "F", "t", "T":
+		m.vim.lastFind = cmd
+		return m.find(p, cmd.key, cmd.arg, n, false)
+	case ";", ",":
+		last := m.vim.lastFind
+		if last.key == "" {
+			return p, exclusive, false
+		}
+		key := last.key
+		if cmd.key == "," {
+			key = map[string]string{"f": "F", "F": "f", "t": "T", "T": "t"}[key]
+		}
+		return m.find(p, key, last.arg, n, true)
+	}
+	return p, exclusive, false
+}
+
+// wantColumn is where a vertical motion lands on a row.
+func (m *Model) wantColumn(row int) int {
+	return min(m.vim.want, max(0, len(m.value[row])-1))
+}
+
+// find looks for the nth occurrence of a character on the cursor's row, as
+// f, F, t and T do. Repeating t or T skips a match right next to the cursor,
+// which it would otherwise stop in front of again.
+func (m *Model) find(p pos, key, char string, n int, repeat bool) (pos, motionKind, bool) {
+	row := m.value[p.row]
+	r, _ := utf8.DecodeRuneInString(char)
+	forward := key == "f" || key == "t"
+	till := key == "t" || key == "T"
+	step := 1
+	if !forward {
+		step = -1
+	}
+	col := p.col
+	if till && repeat {
+		col += step
+	}
+	for n > 0 {
+		col += step
+		if col < 0 || col >= len(row) {
+			return p, exclusive, false
+		}
+		if getRuneAt(row, col) == r {
+			n--
+		}
+	}
+	if till {
+		col -= step
+	}
+	if forward {
+		return pos{p.row, col}, inclusive, true
+	}
+	return pos{p.row, col}, exclusive, true
+}
+
+// motionSpan is the text an operator command applies to.
+func (m *Model) motionSpan(cmd command) (span, bool) {
+	n := max(1, cmd.count)
+	if isObject(cmd.key) {
+		return m.textObject(cmd.key, n)
+	}
+	cur := m.cursor()
+	if cmd.key == cmd.op {
+		last := min(len(m.value)-1, cur.row+n-1)
+		return span{pos{cur.row, 0}, pos{last, 0}, true}, true
+	}
+
+	// cw on a word changes to its end, like ce, without eating the blanks
+	// after it
+	big := cmd.key == "W"
+	if cmd.op == "c" && (cmd.key == "w" || big) && m.classAt(cur, big) != classBlank {
+		end := cur
+		for i := range n {
+			if i > 0 || !m.atWordEnd(end, big) {
+				end = m.wordEnd(end, big)
+			}
+		}
+		return span{cur, m.after(end), false}, true
+	}
+
+	target, kind, ok := m.motion(cmd)
+	if !ok {
+		return span{}, false
+	}
+	start, end := cur, target
+	if end.before(start) {
+		start, end = end, start
+	}
+	switch kind {
+	case linewise:
+		return span{pos{start.row, 0}, pos{end.row, 0}, true}, true
+	case inclusive:
+		end = m.after(end)
+	case exclusive:
+		// an exclusive motion that ends at the start of a later row stops at
+		// the end of the row before, so dw on a row's last word keeps the
+		// line break
+		if end.col == 0 && end.row > start.row {
+			end = pos{end.row - 1, len(m.value[end.row-1])}
+		}
+	}
+	return span{start, end, false}, true
+}
+
+// textObject returns the span of a text object such as iw or a(, on or
+// around the cursor.
+func (m *Model) textObject(key string, n int) (span, bool) {
+	around := key[0] == 'a'
+	switch object := key[1:]; object {
+	case "w", "W":
+		return m.wordObject(around, object == "W", n)
+	case `"`, "'", "`":
+		return m.quoteObject(around, rune(object[0]))
+	case "(", ")", "b":
+		return m.bracketObject(around, '(', ')', n)
+	case "[", "]":
+		return m.bracketObject(around, '[', ']', n)
+	case "{", "}", "B":
+		return m.bracketObject(around, '{', '}', n)
+	case "<", ">":
+		return m.bracketObject(around, '<', '>', n)
+	}
+	return span{}, false
+}
+
+// wordObject is iw and aw, and their WORD forms. Counted, iw takes words and
+// the blanks between them alike, while aw takes a word with its blanks each
+// time.
+func (m *Model) wordObject(around, big bool, n int) (span, bool) {
+	row := m.value[m.row]
+	if len(row) == 0 {
+		return span{}, false
+	}
+	class := func(col int) charClass {
+		return m.classAt(pos{m.row, col}, big)
+	}
+	// runEnd is the end of the run of items of one class starting at col
+	runEnd := func(col int) int {
+		c := class(col)
+		end := col + 1
+		for c != classAttachment && end < len(row) && class(end) == c {
+			end++
+		}
+		return end
+	}
+
+	start := min(m.col, len(row)-1)
+	if c := class(start); c != classAttachment {
+		for start > 0 && class(start-1) == c {
+			start--
+		}
+	}
+	startBlank := class(start) == classBlank
+	end := start
+	for range n {
+		if end >= len(row) {
+			break
+		}
+		blank := class(end) == classBlank
+		end = runEnd(end)
+		if around && end < len(row) && (class(end) == classBlank) != blank {
+			end = runEnd(end)
+		}
+	}
+	// a word without blanks after it takes the blanks before it instead
+	if around && !startBlank && class(end-1) != classBlank {
+		for start > 0 && class(start-1) == classBlank {
+			start--
+		}
+	}
+	return span{pos{m.row, start}, pos{m.row, end}, false}, true
+}
+
+// quoteObject is i" and a" and the like: the first quoted string on the
+// cursor's row that contains the cursor or follows it.
+func (m *Model) quoteObject(around bool, quote rune) (span, bool) {
+	row := m.value[m.row]
+	var quotes []int
+	for i := range row {
+		if getRuneAt(row, i) == quote && (i == 0 || getRuneAt(row, i-1) != '\\') {
+			quotes = append(quotes, i)
+		}
+	}
+	for i := 0; i+1 < len(quotes); i += 2 {
+		open, close := quotes[i], quotes[i+1]
+		if m.col > close {
+			continue
+		}
+		if !around {
+			return span{pos{m.row, open + 1}, pos{m.row, close}, false}, true
+		}
+		start, end := open, close+1
+		for end < len(row) && isSpaceAt(row, end) {
+			end++
+		}
+		if end == close+1 {
+			for start > 0 && isSpaceAt(row, start-1) {
+				start--
+			}
+		}
+		return span{pos{m.row, start}, pos{m.row, end}, false}, true
+	}
+	return span{}, false
+}
+
+// bracketObject is i( and a( and the like, for the nth pair of brackets
+// around the cursor. Brackets may span rows; when the inside of a pair is
+// whole rows of its own, i( takes them linewise.
+func (m *Model) bracketObject(around bool, open, close rune, n int) (span, bool) {
+	cur := m.cursor()
+	start, depth := cur, 0
+	for {
+		switch r := m.runeAt(start); {
+		case r == close && start != cur:
+			depth++
+		case r == open && depth > 0:
+			depth--
+		case r == open:
+			n--
+		}
+		if n == 0 {
+			break
+		}
+		var ok bool
+		if start, ok = m.prev(start); !ok {
+			return span{}, false
+		}
+	}
+
+	end, depth := start, 0
+	for {
+		var ok bool
+		if end, ok = m.next(end); !ok {
+			return span{}, false
+		}
+		r := m.runeAt(end)
+		if r == open {
+			depth++
+		} else if r == close {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+	}
+
+	if around {
+		return span{start, m.after(end), false}, true
+	}
+	inner := span{pos{start.row, start.col + 1}, end, false}
+	if inner.start.col == len(m.value[start.row]) && start.row < end.row &&
+		firstNonBlank(m.value[end.row]) == end.col {
+		if end.row-start.row < 2 {
+			return span{inner.start, inner.start, false}, true
+		}
+		return span{pos{start.row + 1, 0}, pos{end.row - 1, 0}, true}, true
+	}
+	return inner, true
+}
+
+// operate applies an operator to a span: y yanks it, d deletes it and c
+// deletes it and enters insert mode. Empty spans leave the registers be.
+func (m *Model) operate(op string, s span, name string) {
+	if !s.empty() {
+		m.storeRegister(name, m.spanText(s), op == "y")
+	}
+	switch op {
+	case "y":
+		if s.linewise {
+			m.row = s.start.row
+			m.SetCursorColumn(m.col)
+		} else {
+			m.setCursor(s.start)
+		}
+	case "d":
+		m.deleteSpan(s)
+	case "c":
+		if s.linewise {
+			m.replaceRows(s.start.row, s.end.row, [][]any{{}})
+			m.setCursor(pos{s.start.row, 0})
+		} else {
+			m.deleteSpan(s)
+		}
+		m.enterInsert(1)
+	}
+}
+
+func (m *Model) spanText(s span) register {
+	if s.linewise {
+		var rows [][]any
+		for _, row := range m.value[s.start.row : s.end.row+1] {
+			rows = append(rows, copyInterfaceSlice(row))
+		}
+		return register{rows: rows, linewise: true}
+	}
+	if s.start.row == s.end.row {
+		row := m.value[s.start.row]
+		return register{rows: [][]any{copyInterfaceSlice(row[s.start.col:s.end.col])}}
+	}
+	rows := [][]any{copyInterfaceSlice(m.value[s.start.row][s.start.col:])}
+	for _, row := range m.value[s.start.row+1 : s.end.row] {
+		rows = append(rows, copyInterfaceSlice(row))
+	}
+	rows = append(rows, copyInterfaceSlice(m.value[s.end.row][:s.end.col]))
+	return register{rows: rows}
+}
+
+func (m *Model) deleteSpan(s span) {
+	if s.linewise {
+		m.replaceRows(s.start.row, s.end.row, nil)
+		m.row = min(s.start.row, len(m.value)-1)
+		m.SetCursorColumn(firstNonBlank(m.value[m.row]))
+		return
+	}
+	row := slices.Concat(m.value[s.start.row][:s.start.col], m.value[s.end.row][s.end.col:])
+	m.replaceRows(s.start.row, s.end.row, [][]any{row})
+	m.setCursor(s.start)
+}
+
+// replaceRows replaces rows first through last, keeping at least one row.
+func (m *Model) replaceRows(first, last int, rows [][]any) {
+	m.value = slices.Replace(m.value, first, last+1, rows...)
+	if len(m.value) == 0 {
+		m.value = append(m.value, []any{})
+	}
+}
+
+// putAt inserts a register's text count times: as whole rows before row
+// p.row when linewise, otherwise at p. The cursor goes to the first row put,
+// or to the last character of text put within a row.
+func (m *Model) putAt(p pos, r register, count int) {
+	if len(r.rows) == 0 {
+		return
+	}
+	if r.linewise {
+		var rows [][]any
+		for range count {
+			for _, row := range r.rows {
+				rows = append(rows, cloneItems(row))
+			}
+		}
+		p.row = min(p.row, len(m.value))
+		m.value = slices.Insert(m.value, p.row, rows...)
+		m.setCursor(pos{p.row, firstNonBlank(m.value[p.row])})
+		return
+	}
+
+	text := r
+	for range count - 1 {
+		text = appendRegister(text, r)
+	}
+	rows := make([][]any, len(text.rows))
+	for i, row := range text.rows {
+		rows[i] = cloneItems(row)
+	}
+	line := m.value[p.row]
+	last := len(rows) - 1
+	end := len(rows[last])
+	if last == 0 {
+		end += p.col
+	}
+	after := copyInterfaceSlice(line[p.col:])
+	rows[0] = slices.Concat(line[:p.col], rows[0])
+	rows[last] = append(rows[last], after...)
+	m.replaceRows(p.row, p.row, rows)
+	if last == 0 {
+		m.setCursor(pos{p.row, end - 1})
+	} else {
+		m.setCursor(p)
+	}
+}
+
+// replaceSelection is p in visual mode: the selection is replaced with the
+// register, and with p rather than P the unnamed register gets the text
+// replaced.
+func (m *Model) replaceSelection(s span, r register, keep bool) {
+	replaced := m.spanText(s)
+	whole := s.linewise && s.start.row == 0 && s.end.row == len(m.value)-1
+	m.deleteSpan(s)
+	switch {
+	case s.linewise && !r.linewise:
+		r = register{rows: r.rows, linewise: true}
+	case !s.linewise && r.linewise:
+		rows := append([][]any{{}}, r.rows...)
+		r = register{rows: append(rows, []any{})}
+	}
+	m.putAt(s.start, r, 1)
+	if whole {
+		// the empty row deleting everything left behind
+		m.value = m.value[:len(m.value)-1]
+	}
+	if keep {
+		m.storeRegister("", replaced, false)
+	}
+}
+
+// cloneItems copies items to put them, giving attachments new IDs so that
+// each copy is an attachment of its own.
+func cloneItems(items []any) []any {
+	items = copyInterfaceSlice(items)
+	for i, item := range items {
+		if att, ok := item.(*attachment.Attachment); ok {
+			clone := *att
+			clone.ID = uuid.NewString()
+			items[i] = &clone
+		}
+	}
+	return items
+}
+
+func (m *Model) register(name string) register {
+	switch {
+	case name == "":
+		name = `"`
+	case name >= "A" && name <= "Z":
+		name = strings.ToLower(name)
+	}
+	return m.vim.registers[name]
+}
+
+// storeRegister saves yanked or deleted text as Vim does: in the named
+// register when one was given, appending to it for an uppercase name, and
+// always in the unnamed one. Without a name yanks also go to "0, while
+// deletes shift through "1 to "9, or go to "- when within a row.
+func (m *Model) storeRegister(name string, r register, yank bool) {
+	if name == "_" {
+		return
+	}
+	v := &m.vim
+	if v.registers == nil {
+		v.registers = map[string]register{}
+	}
+	switch {
+	case name >= "A" && name <= "Z":
+		name = strings.ToLower(name)
+		r = appendRegister(v.registers[name], r)
+		v.registers[name] = r
+	case name != "" && name != `"`:
+		v.registers[name] = r
+	case yank:
+		v.registers["0"] = r
+	case r.linewise || len(r.rows) > 1:
+		for i := 9; i > 1; i-- {
+			v.registers[strconv.Itoa(i)] = v.registers[strconv.Itoa(i-1)]
+		}
+		v.registers["1"] = r
+	default:
+		v.registers["-"] = r
+	}
+	v.registers[`"`] = r
+}
+
+// appendRegister joins text to a register's, as whole rows when either is
+// linewise and otherwise continuing its last row.
+func appendRegister(r, text register) register {
+	if len(r.rows) == 0 {
+		return text
+	}
+	if r.linewise || text.linewise {
+		return register{rows: slices.Concat(r.rows, text.rows), linewise: true}
+	}
+	last := len(r.rows) - 1
+	rows := slices.Clone(r.rows[:last])
+	rows = append(rows, slices.Concat(r.rows[last], text.rows[0]))
+	return register{rows: append(rows, text.rows[1:]...)}
+}
+
+func (m *Model) replaceChars(char string, n int) {
+	row := m.value[m.row]
+	if m.col+n > len(row) {
+		return
+	}
+	r, _ := utf8.DecodeRuneInString(char)
+	m.mapItems(span{pos{m.row, m.col}, pos{m.row, m.col + n}, false}, func(any) any { return r })
+	m.SetCursorColumn(m.col + n - 1)
+}
+
+func (m *Model) mapCase(s span, fn func(rune) rune) {
+	m.mapItems(s, func(item any) any {
+		if r, ok := item.(rune); ok {
+			return fn(r)
+		}
+		return item
+	})
+}
+
+// mapItems replaces each item in a span, leaving line breaks alone.
+func (m *Model) mapItems(s span, fn func(any) any) {
+	for row := s.start.row; row <= s.end.row; row++ {
+		items := copyInterfaceSlice(m.value[row])
+		start, end := 0, len(items)
+		if !s.linewise {
+			if row == s.start.row {
+				start = s.start.col
+			}
+			if row == s.end.row {
+				end = s.end.col
+			}
+		}
+		for i := start; i < end; i++ {
+			items[i] = fn(items[i])
+		}
+		m.value[row] = items
+	}
+}
+
+func swapCase(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
+
+// join joins a row with the rows after it, times times, dropping the
+// leading blanks of each and separating them with a space.
+func (m *Model) join(row, times int) {
+	for range times {
+		if row >= len(m.value)-1 {
+			break
+		}
+		line, next := m.value[row], m.value[row+1]
+		next = next[firstNonBlank(next):]
+		col := len(line)
+		joined := slices.Clone(line)
+		if len(line) > 0 && len(next) > 0 && !isSpaceAt(line, len(line)-1) && getRuneAt(next, 0) != ')' {
+			joined = append(joined, ' ')
+		}
+		joined = append(joined, next...)
+		m.replaceRows(row, row+1, [][]any{joined})
+		m.setCursor(pos{row, col})
+	}
+}
+
+// openLine adds an empty row below or above the cursor's and moves to it.
+func (m *Model) openLine(below bool) {
+	row := m.row
+	if below {
+		row++
+	}
+	m.value = slices.Insert(m.value, row, []any{})
+	m.setCursor(pos{row, 0})
+}
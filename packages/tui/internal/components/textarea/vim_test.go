@@ -0,0 +1,296 @@
+package textarea
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/attachment"
+)
+
+// vimModel returns a focused textarea in normal mode holding value, with the
+// cursor at the start.
+func vimModel(value string) Model {
+	m := New()
+	m.SetWidth(80)
+	m.SetVimMode(true)
+	m.Focus()
+	m.SetValue(value)
+	m = feed(m, "<esc>")
+	m.MoveToBegin()
+	m.history = history{}
+	return m
+}
+
+// feed types keys into the textarea. Named keys are written in angle
+// brackets, as in <esc>.
+func feed(m Model, keys string) Model {
+	for keys != "" {
+		var msg tea.KeyPressMsg
+		if strings.HasPrefix(keys, "<") {
+			end := strings.Index(keys, ">")
+			switch name := keys[1:end]; name {
+			case "esc":
+				msg = tea.KeyPressMsg{Code: tea.KeyEscape}
+			case "ctrl+r":
+				msg = tea.KeyPressMsg{Code: 'r', Mod: tea.ModCtrl}
+			case "bs":
+				msg = tea.KeyPressMsg{Code: tea.KeyBackspace}
+			case "left":
+				msg = tea.KeyPressMsg{Code: tea.KeyLeft}
+			case "enter":
+				msg = tea.KeyPressMsg{Code: tea.KeyEnter}
+			}
+			keys = keys[end+1:]
+		} else {
+			r, size := utf8.DecodeRuneInString(keys)
+			msg = tea.KeyPressMsg{Code: r, Text: string(r)}
+			keys = keys[size:]
+		}
+		m, _ = m.Update(msg)
+	}
+	return m
+}
+
+func TestVimEdits(t *testing.T) {
+	tests := []struct {
+		value, keys string
+		want        string
+		col         int
+	}{
+		{"hello world foo", "dw", "world foo", 0},
+		{"hello world foo", "dw.", "foo", 0},
+		{"hello world foo", "d2w", "foo", 0},
+		{"hello world foo", "2dw", "foo", 0},
+		{"hello world", "wD", "hello ", 5},
+		{"hello world", "cwbye<esc>", "bye world", 2},
+		{"hello world", "ceX<esc>w.", "X X", 2},
+		{"a.b c", "dW", "c", 0},
+		{"a.b c", "dw", ".b c", 0},
+		{"hello", "$x", "hell", 3},
+		{"abc", "3x", "", 0},
+		{"abc", "x$p", "bca", 2},
+		{"one two", "ytwP", "one tone two", 4},
+		{"abc", "rx", "xbc", 0},
+		{"abc", "2r-", "--c", 1},
+		{"hello", "~~", "HEllo", 2},
+		{"one\ntwo\nthree", "jdd", "one\nthree", 0},
+		{"one\ntwo\nthree", "yyjp", "one\ntwo\none\nthree", 0},
+		{"one\ntwo\nthree", "dj", "three", 0},
+		{"one\ntwo\nthree", "Gdk", "one", 0},
+		{"one\n  two", "J", "one two", 3},
+		{"one\ntwo", "ccnew<esc>", "new\ntwo", 2},
+		{"one", "otwo<esc>", "one\ntwo", 2},
+		{"one", "Ozero<esc>", "zero\none", 3},
+		{"b", "Ia<esc>Ac<esc>", "abc", 2},
+		{"x", "3ia<esc>", "aaax", 2},
+		{"x", "ia<esc>3.", "aaaax", 2},
+		{"a,b,c", "f,x;.", "abc", 2},
+		{"a,b,c", "$F,D", "a,b", 2},
+		{"a,b,c", "dt,", ",b,c", 0},
+		{`say("hi there") ok`, `fhci"yo<esc>`, `say("yo") ok`, 6},
+		{`say("hi there") ok`, `fhda"`, `say() ok`, 4},
+		{"f(a, (b), c)", "fbdi(", "f(a, (), c)", 6},
+		{"f(a, (b), c)", "fb2di(", "f()", 2},
+		{"f(a, (b), c)", "fbda(", "f(a, , c)", 5},
+		{"x [1, 2] y", "f1di]", "x [] y", 3},
+		{"{\n  a\n}", "jdi{", "{\n}", 0},
+		{"one two three", "wdiw", "one  three", 4},
+		{"one two three", "wdaw", "one three", 4},
+		{"one two", "wdaw", "one", 2},
+		{"one two three", "wd2aw", "one", 2},
+	}
+	for _, test := range tests {
+		m := feed(vimModel(test.value), test.keys)
+		if got := m.Value(); got != test.want {
+			t.Errorf("%q on %q: got %q, want %q", test.keys, test.value, got, test.want)
+			continue
+		}
+		if m.Mode() != ModeNormal {
+			t.Errorf("%q on %q: ended in %s mode", test.keys, test.value, m.Mode())
+		}
+		if m.col != test.col {
+			t.Errorf("%q on %q: cursor at %d, want %d", test.keys, test.value, m.col, test.col)
+		}
+	}
+}
+
+func TestVimVisual(t *testing.T) {
+	tests := []struct {
+		value, keys, want string
+	}{
+		{"hello world", "vex", " world"},
+		{"hello world", "wvhhd", "hellorld"},
+		{"hello world", "veU", "HELLO world"},
+		{"hello world", "viwyA <esc>p", "hello world hello"},
+		{"hello world", "wvey0vep", "world world"},
+		{"one\ntwo\nthree", "jVd", "one\nthree"},
+		{"one\ntwo\nthree", "Vjd", "three"},
+		{"one\ntwo\nthree", "VjJ", "one two\nthree"},
+		{"one\ntwo", "VGd", ""},
+		{"one\ntwo", "yyjVp", "one\none"},
+		{"abc", "vlcX<esc>", "Xc"},
+		{"abc", "vlrx", "xxc"},
+		{"abc", "v<esc>x", "bc"},
+	}
+	for _, test := range tests {
+		m := feed(vimModel(test.value), test.keys)
+		if got := m.Value(); got != test.want {
+			t.Errorf("%q on %q: got %q, want %q", test.keys, test.value, got, test.want)
+		}
+	}
+}
+
+func TestVimUndoRedo(t *testing.T) {
+	m := vimModel("one")
+	m = feed(m, "A two three<esc>")
+	m = feed(m, "0dwx")
+	if got := m.Value(); got != "wo three" {
+		t.Fatalf("got %q", got)
+	}
+	// the insert is one step however many keys were typed in it
+	m = feed(m, "uu")
+	if got := m.Value(); got != "one two three" {
+		t.Fatalf("after uu got %q", got)
+	}
+	m = feed(m, "u")
+	if got := m.Value(); got != "one" {
+		t.Fatalf("after u got %q", got)
+	}
+	m = feed(m, "<ctrl+r><ctrl+r>")
+	if got := m.Value(); got != "two three" {
+		t.Fatalf("after redo got %q", got)
+	}
+	// a new change drops what could be redone
+	m = feed(m, "$x<ctrl+r>")
+	if got := m.Value(); got != "two thre" {
+		t.Fatalf("after x got %q", got)
+	}
+	// motions and yanks are not undo steps
+	m = feed(m, "0yiwu")
+	if got := m.Value(); got != "two three" {
+		t.Fatalf("after yank and undo got %q", got)
+	}
+}
+
+func TestVimRegisters(t *testing.T) {
+	m := feed(vimModel("a b c"), `"ayw"Ayw`)
+	if got := m.register("a").rows; len(got) != 1 || interfacesToString(got[0]) != "a a " {
+		t.Fatalf(`"a holds %q`, got)
+	}
+	m = feed(vimModel("one two"), `"_dwP`)
+	if got := m.Value(); got != "two" {
+		t.Errorf(`"_ still filled the unnamed register: %q`, got)
+	}
+	m = feed(vimModel("one\ntwo\nthree"), `yyddx"0P"1p`)
+	if got := m.Value(); got != "one\none\nwo\nthree" {
+		t.Errorf(`"0 and "1: got %q`, got)
+	}
+	if got := m.register("-").rows; len(got) != 1 || interfacesToString(got[0]) != "t" {
+		t.Errorf(`"- holds %q`, got)
+	}
+}
+
+func TestVimAttachments(t *testing.T) {
+	m := New()
+	m.SetWidth(80)
+	m.SetVimMode(true)
+	m.Focus()
+	m.InsertString("see ")
+	m.InsertAttachment(&attachment.Attachment{ID: "1", Display: "@main.go"})
+	m.InsertString(" now")
+	m = feed(m, "<esc>0w")
+	if m.col != 4 {
+		t.Fatalf("w stopped at %d, want the attachment at 4", m.col)
+	}
+	if att, _, _ := m.isAttachmentAtCursor(); att == nil || att.ID != "1" {
+		t.Fatal("expected the attachment under the cursor to be selected")
+	}
+	m = feed(m, "w")
+	if att, _, _ := m.isAttachmentAtCursor(); att != nil {
+		t.Error("the attachment before the cursor is selected in normal mode")
+	}
+
+	// an attachment is a word of its own that operators take whole
+	m = feed(m, "bdw")
+	if got := m.Value(); got != "see now" || len(m.GetAttachments()) != 0 {
+		t.Fatalf("dw left %q", got)
+	}
+	m = feed(m, "P")
+	attachments := m.GetAttachments()
+	if got := m.Value(); got != "see @main.go now" || len(attachments) != 1 {
+		t.Fatalf("P put %q", got)
+	}
+	if attachments[0].ID == "1" {
+		t.Error("a put attachment kept the ID of the one it was copied from")
+	}
+	m = feed(m, "uu")
+	if attachments := m.GetAttachments(); len(attachments) != 1 || attachments[0].ID != "1" {
+		t.Errorf("undo did not bring back the original attachment: %v", attachments)
+	}
+}
+
+func TestVimModes(t *testing.T) {
+	m := New()
+	m.SetVimMode(true)
+	m.Focus()
+	text := tea.KeyPressMsg{Code: 'j', Text: "j"}
+	esc := tea.KeyPressMsg{Code: tea.KeyEscape}
+	if m.Mode() != ModeInsert || m.WantsKey(text) || !m.WantsKey(esc) {
+		t.Fatal("expected to start in insert mode, taking only esc")
+	}
+	m = feed(m, "<esc>")
+	if m.Mode() != ModeNormal || !m.WantsKey(text) || m.WantsKey(esc) {
+		t.Fatal("expected normal mode to take text but not esc")
+	}
+	m = feed(m, "d")
+	if !m.WantsKey(esc) {
+		t.Error("expected esc to cancel a pending operator")
+	}
+	m = feed(m, "<esc>v")
+	if m.Mode() != ModeVisual || !m.WantsKey(esc) {
+		t.Error("expected visual mode to take esc")
+	}
+	m.Reset()
+	if m.Mode() != ModeInsert {
+		t.Error("expected clearing the input to return to insert mode")
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		keys   string
+		visual bool
+		want   command
+		result parseResult
+	}{
+		{"d", false, command{op: "d"}, parsePending},
+		{"2d3w", false, command{count: 6, op: "d", key: "w"}, parseDone},
+		{`"a2yy`, false, command{register: "a", count: 2, op: "y", key: "y"}, parseDone},
+		{"10G", false, command{count: 10, key: "G"}, parseDone},
+		{"0", false, command{key: "0"}, parseDone},
+		{"fx", false, command{key: "f", arg: "x"}, parseDone},
+		{"di", false, command{op: "d"}, parsePending},
+		{"ci(", false, command{op: "c", key: "i("}, parseDone},
+		{"iw", true, command{key: "iw"}, parseDone},
+		{"gx", false, command{}, parseInvalid},
+		{"dx", false, command{op: "d"}, parseInvalid},
+		{"U", false, command{}, parseInvalid},
+		{"U", true, command{key: "U"}, parseDone},
+	}
+	for _, test := range tests {
+		var keys []string
+		for _, r := range test.keys {
+			keys = append(keys, string(r))
+		}
+		got, result := parseCommand(keys, test.visual)
+		if result != test.result {
+			t.Errorf("%q: result %d, want %d", test.keys, result, test.result)
+			continue
+		}
+		if result == parseDone && got != test.want {
+			t.Errorf("%q: got %+v, want %+v", test.keys, got, test.want)
+		}
+	}
+}
@@ -19,6 +19,8 @@ type ShowToastMsg struct {
 	Title    *string
 	Color    compat.AdaptiveColor
 	Duration time.Duration
+	// Error is set on toasts from NewErrorToast, which report a failure.
+	Error bool
 }
 
 // DismissToastMsg is a message to dismiss a specific toast
@@ -189,6 +191,7 @@ type toastOptions struct {
 	title    *string
 	duration *time.Duration
 	color    *compat.AdaptiveColor
+	error    bool
 }
 
 type ToastOption func(*toastOptions)
@@ -229,6 +232,7 @@ func NewToast(message string, options ...ToastOption) tea.Cmd {
 			Title:    opts.title,
 			Duration: *opts.duration,
 			Color:    *opts.color,
+			Error:    opts.error,
 		}
 	}
 }
@@ -258,7 +262,9 @@ func NewWarningToast(message string, options ...ToastOption) tea.Cmd {
 }
 
 func NewErrorToast(message string, options ...ToastOption) tea.Cmd {
-	options = append(options, WithColor(theme.CurrentTheme().Error()))
+	options = append(options, WithColor(theme.CurrentTheme().Error()), func(t *toastOptions) {
+		t.error = true
+	})
 	return NewToast(
 		message,
 		options...,
@@ -0,0 +1,220 @@
+// Package connection resolves how the TUI reaches the opencode server. The
+// resulting Profile is built once at startup and shared by the API client and
+// the event stream so they always talk to the same host.
+package connection
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	EnvServer     = "OPENCODE_SERVER"
+	EnvAuth       = "OPENCODE_SERVER_AUTH"
+	EnvCA         = "OPENCODE_SERVER_CA"
+	EnvSocket     = "OPENCODE_SERVER_SOCKET"
+	EnvConfigFile = "OPENCODE_CONNECTION_CONFIG"
+)
+
+// Profile describes a connection to the opencode server.
+type Profile struct {
+	// Server is the base URL of the server. For unix sockets it is a
+	// placeholder http URL and Socket holds the socket path.
+	Server string `toml:"server"`
+	// Auth is sent as the Authorization header on every request.
+	Auth string `toml:"auth"`
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string `toml:"ca_file"`
+	// Socket is the path of a unix socket to dial instead of TCP.
+	Socket string `toml:"socket"`
+
+	// Source records where Server came from, for error messages.
+	Source string `toml:"-"`
+}
+
+// Flags holds values given on the command line. Empty fields are unset.
+type Flags struct {
+	Server string
+}
+
+// DefaultConfigPath returns the connection config file location.
+func DefaultConfigPath() string {
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "opencode", "connection.toml")
+}
+
+// Resolve builds a Profile from flags, then environment, then the config file
+// at configPath, in that order of precedence.
+func Resolve(flags Flags, configPath string) (*Profile, error) {
+	profile := &Profile{}
+	if configPath != "" {
+		if _, err := toml.DecodeFile(configPath, profile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read connection config %s: %w", configPath, err)
+		}
+		if profile.Server != "" {
+			profile.Source = configPath
+		}
+	}
+
+	override := func(target *string, value string) bool {
+		if value == "" {
+			return false
+		}
+		*target = value
+		return true
+	}
+	if override(&profile.Server, os.Getenv(EnvServer)) {
+		profile.Source = EnvServer
+	}
+	override(&profile.Auth, os.Getenv(EnvAuth))
+	override(&profile.CAFile, os.Getenv(EnvCA))
+	override(&profile.Socket, os.Getenv(EnvSocket))
+	if override(&profile.Server, flags.Server) {
+		profile.Source = "--server"
+	}
+	// A server URL given explicitly wins over a socket from the environment
+	// or config file
+	if (profile.Source == EnvServer || profile.Source == "--server") && !strings.HasPrefix(profile.Server, "unix://") {
+		profile.Socket = ""
+	}
+
+	if err := profile.normalize(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (p *Profile) normalize() error {
+	if after, ok := strings.CutPrefix(p.Server, "unix://"); ok {
+		p.Socket = after
+		p.Server = ""
+	}
+	if p.Socket != "" && p.Server == "" {
+		p.Server = "http://localhost"
+		if p.Source == "" {
+			p.Source = EnvSocket
+		}
+	}
+	if p.Server == "" {
+		return fmt.Errorf("no opencode server configured: pass --server or set %s", EnvServer)
+	}
+	u, err := url.Parse(p.Server)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid server URL %q from %s: expected http(s)://host[:port] or unix:///path", p.Server, p.Source)
+	}
+	p.Server = strings.TrimRight(p.Server, "/")
+	return nil
+}
+
+// String describes the endpoint for logs and errors.
+func (p *Profile) String() string {
+	if p == nil {
+		return ""
+	}
+	if p.Socket != "" {
+		return "unix://" + p.Socket
+	}
+	return p.Server
+}
+
+// HTTPClient returns a client that dials the socket when configured, trusts
+// the CA bundle and adds the auth header to every request. Streaming
+// responses must not time out, so no overall timeout is set.
+func (p *Profile) HTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if p.CAFile != "" {
+		pem, err := os.ReadFile(p.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", p.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", p.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	if p.Socket != "" {
+		socket := p.Socket
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+
+	var rt http.RoundTripper = transport
+	if p.Auth != "" {
+		rt = authTransport{base: transport, auth: p.Auth}
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+type authTransport struct {
+	base http.RoundTripper
+	auth string
+}
+
+func (t authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", t.auth)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Check verifies the server is reachable and accepts our credentials,
+// returning an error that says what to fix.
+func (p *Profile) Check(ctx context.Context, client *http.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Server+"/path", nil)
+	if err != nil {
+		return err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+		switch {
+		case errors.As(err, &certErr):
+			return fmt.Errorf("TLS verification failed for %s (from %s): %w; set %s to a CA bundle that signs it", p, p.Source, err, EnvCA)
+		case errors.Is(err, context.DeadlineExceeded):
+			return fmt.Errorf("opencode server at %s (from %s) did not respond within 5s", p, p.Source)
+		default:
+			return fmt.Errorf("cannot reach opencode server at %s (from %s): %w", p, p.Source, err)
+		}
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("opencode server at %s rejected our credentials (%s); check %s", p, res.Status, EnvAuth)
+	case res.StatusCode >= 400:
+		return fmt.Errorf("opencode server at %s is unhealthy: %s", p, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package connection
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolvePrecedence(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "connection.toml")
+	err := os.WriteFile(config, []byte("server = \"http://config:1\"\nauth = \"Bearer config\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvServer, "")
+	t.Setenv(EnvAuth, "")
+	t.Setenv(EnvCA, "")
+	t.Setenv(EnvSocket, "")
+
+	profile, err := Resolve(Flags{}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Server != "http://config:1" || profile.Auth != "Bearer config" || profile.Source != config {
+		t.Errorf("unexpected profile from config: %+v", profile)
+	}
+
+	t.Setenv(EnvServer, "http://env:2/")
+	profile, _ = Resolve(Flags{}, config)
+	if profile.Server != "http://env:2" || profile.Source != EnvServer {
+		t.Errorf("env should override config: %+v", profile)
+	}
+
+	profile, _ = Resolve(Flags{Server: "unix:///tmp/oc.sock"}, config)
+	if profile.Socket != "/tmp/oc.sock" || profile.Source != "--server" || profile.String() != "unix:///tmp/oc.sock" {
+		t.Errorf("flag should override env: %+v", profile)
+	}
+}
+
+func TestResolveServerOverridesSocket(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "connection.toml")
+	err := os.WriteFile(config, []byte("socket = \"/tmp/config.sock\"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvServer, "")
+	t.Setenv(EnvSocket, "/tmp/env.sock")
+
+	profile, err := Resolve(Flags{Server: "http://flag:3"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Socket != "" || profile.String() != "http://flag:3" {
+		t.Errorf("--server should override the socket: %+v", profile)
+	}
+
+	t.Setenv(EnvServer, "https://env:4")
+	profile, _ = Resolve(Flags{}, config)
+	if profile.Socket != "" || profile.String() != "https://env:4" {
+		t.Errorf("%s should override the socket: %+v", EnvServer, profile)
+	}
+
+	t.Setenv(EnvServer, "")
+	profile, _ = Resolve(Flags{}, config)
+	if profile.Socket != "/tmp/env.sock" || profile.String() != "unix:///tmp/env.sock" {
+		t.Errorf("the socket should be used without a server URL: %+v", profile)
+	}
+}
+
+func TestResolveRequiresServer(t *testing.T) {
+	t.Setenv(EnvServer, "")
+	t.Setenv(EnvSocket, "")
+	if _, err := Resolve(Flags{}, ""); err == nil || !strings.Contains(err.Error(), "--server") {
+		t.Errorf("expected missing server error, got %v", err)
+	}
+	if _, err := Resolve(Flags{Server: "localhost:4096"}, ""); err == nil {
+		t.Error("expected invalid URL error")
+	}
+}
+
+func TestHTTPClientAuthAndCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	profile := &Profile{Server: server.URL, Auth: "Bearer secret", Source: "test"}
+	client, err := profile.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.Check(context.Background(), client); err != nil {
+		t.Errorf("expected healthy server, got %v", err)
+	}
+
+	profile.Auth = "Bearer wrong"
+	client, _ = profile.HTTPClient()
+	if err := profile.Check(context.Background(), client); err == nil || !strings.Contains(err.Error(), EnvAuth) {
+		t.Errorf("expected credentials error, got %v", err)
+	}
+}
+
+func TestHTTPClientUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "oc.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skip("unix sockets unavailable:", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	profile, err := Resolve(Flags{Server: "unix://" + socket}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := profile.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := profile.Check(context.Background(), client); err != nil {
+		t.Errorf("expected socket server to be reachable, got %v", err)
+	}
+}
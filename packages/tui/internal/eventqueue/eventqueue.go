@@ -0,0 +1,207 @@
+// Package eventqueue provides a bounded FIFO that coalesces successive
+// updates to the same entity and applies backpressure instead of dropping.
+package eventqueue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+var ErrClosed = errors.New("event queue closed")
+
+// Stats are cumulative counters for a Queue.
+type Stats struct {
+	Received  int64
+	Coalesced int64
+	Dropped   int64
+	Delivered int64
+	Pending   int
+	Capacity  int
+	HighWater int
+}
+
+// Queue is safe for concurrent use. Items whose key function returns the same
+// non-empty key as an item still waiting in the queue replace that item, so
+// the queue only ever holds the latest state of each entity. The replacement
+// moves to the back of the queue: delivering it in the old item's place would
+// put it ahead of events pushed between the two, which may depend on it.
+type Queue[T any] struct {
+	mu       sync.Mutex
+	items    []T
+	keys     []string
+	index    map[string]int // key -> absolute position of the queued item
+	popped   int            // absolute position of items[0]
+	capacity int
+	key      func(T) string
+	closed   bool
+	changed  chan struct{}
+	stats    Stats
+}
+
+// New returns a queue holding at most capacity items. key may be nil, in
+// which case nothing is coalesced.
+func New[T any](capacity int, key func(T) string) *Queue[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if key == nil {
+		key = func(T) string { return "" }
+	}
+	return &Queue[T]{
+		index:    make(map[string]int),
+		capacity: capacity,
+		key:      key,
+		changed:  make(chan struct{}),
+	}
+}
+
+// notify wakes every waiter. Callers must hold q.mu.
+func (q *Queue[T]) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// Push enqueues item, blocking while the queue is full. The item is counted
+// as dropped only if ctx ends or the queue is closed before it fits.
+func (q *Queue[T]) Push(ctx context.Context, item T) error {
+	k := q.key(item)
+
+	q.mu.Lock()
+	q.stats.Received++
+	for {
+		if q.closed {
+			q.stats.Dropped++
+			q.mu.Unlock()
+			return ErrClosed
+		}
+		if k != "" {
+			if pos, ok := q.index[k]; ok {
+				q.remove(pos - q.popped)
+				q.append(k, item)
+				q.stats.Coalesced++
+				q.mu.Unlock()
+				return nil
+			}
+		}
+		if len(q.items) < q.capacity {
+			break
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			q.mu.Lock()
+			q.stats.Dropped++
+			q.mu.Unlock()
+			return ctx.Err()
+		case <-changed:
+		}
+		q.mu.Lock()
+	}
+
+	q.append(k, item)
+	q.stats.HighWater = max(q.stats.HighWater, len(q.items))
+	q.notify()
+	q.mu.Unlock()
+	return nil
+}
+
+// append adds item at the back. Callers must hold q.mu.
+func (q *Queue[T]) append(k string, item T) {
+	if k != "" {
+		q.index[k] = q.popped + len(q.items)
+	}
+	q.items = append(q.items, item)
+	q.keys = append(q.keys, k)
+}
+
+// remove deletes the item at i, moving those behind it forward. Callers must
+// hold q.mu.
+func (q *Queue[T]) remove(i int) {
+	if k := q.keys[i]; k != "" {
+		delete(q.index, k)
+	}
+	q.items = slices.Delete(q.items, i, i+1)
+	q.keys = slices.Delete(q.keys, i, i+1)
+	for j := i; j < len(q.keys); j++ {
+		if k := q.keys[j]; k != "" {
+			q.index[k] = q.popped + j
+		}
+	}
+}
+
+// take removes up to n items from the front. Callers must hold q.mu.
+func (q *Queue[T]) take(n int) []T {
+	n = min(n, len(q.items))
+	out := make([]T, n)
+	copy(out, q.items[:n])
+	for i := range n {
+		if k := q.keys[i]; k != "" && q.index[k] == q.popped+i {
+			delete(q.index, k)
+		}
+	}
+	var zero T
+	for i := range n {
+		q.items[i] = zero
+	}
+	q.items = q.items[n:]
+	q.keys = q.keys[n:]
+	q.popped += n
+	q.stats.Delivered += int64(n)
+	if n > 0 {
+		q.notify()
+	}
+	return out
+}
+
+// PopBatch blocks until at least one item is available and returns up to max
+// items. Once the queue is closed and empty it returns ErrClosed.
+func (q *Queue[T]) PopBatch(ctx context.Context, max int) ([]T, error) {
+	q.mu.Lock()
+	for len(q.items) == 0 {
+		if q.closed {
+			q.mu.Unlock()
+			return nil, ErrClosed
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+		q.mu.Lock()
+	}
+	defer q.mu.Unlock()
+	return q.take(max), nil
+}
+
+// Drain returns up to max queued items without blocking.
+func (q *Queue[T]) Drain(max int) []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.take(max)
+}
+
+// Close wakes all waiters. Pending items can still be popped; further pushes
+// fail with ErrClosed.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.notify()
+}
+
+func (q *Queue[T]) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := q.stats
+	stats.Pending = len(q.items)
+	stats.Capacity = q.capacity
+	return stats
+}
@@ -0,0 +1,105 @@
+package eventqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type update struct {
+	id    string
+	value int
+}
+
+func byID(u update) string { return u.id }
+
+func TestCoalescesQueuedUpdates(t *testing.T) {
+	q := New(10, byID)
+	ctx := context.Background()
+
+	for _, u := range []update{{"a", 1}, {"b", 1}, {"a", 2}, {"", 1}, {"", 2}, {"a", 3}} {
+		if err := q.Push(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := q.Drain(10)
+	// The latest update to a moves behind the events pushed after the first.
+	want := []update{{"b", 1}, {"", 1}, {"", 2}, {"a", 3}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("item %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// Once delivered, a key starts a fresh entry rather than coalescing.
+	q.Push(ctx, update{"a", 4})
+	q.Push(ctx, update{"b", 2})
+	q.Push(ctx, update{"a", 5})
+	if got := q.Drain(10); len(got) != 2 || got[0] != (update{"b", 2}) || got[1] != (update{"a", 5}) {
+		t.Errorf("unexpected items after drain: %v", got)
+	}
+
+	stats := q.Stats()
+	if stats.Received != 9 || stats.Coalesced != 3 || stats.Delivered != 6 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestPushAppliesBackpressure(t *testing.T) {
+	q := New(1, byID)
+	ctx := context.Background()
+	q.Push(ctx, update{"a", 1})
+
+	// Same key still coalesces while full.
+	if err := q.Push(ctx, update{"a", 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	pushed := make(chan error)
+	go func() { pushed <- q.Push(ctx, update{"b", 1}) }()
+
+	select {
+	case <-pushed:
+		t.Fatal("push into full queue did not block")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	batch, err := q.PopBatch(ctx, 10)
+	if err != nil || len(batch) != 1 || batch[0].value != 2 {
+		t.Fatalf("unexpected batch %v, %v", batch, err)
+	}
+	if err := <-pushed; err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := q.Push(timeout, update{"c", 1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+	if q.Stats().Dropped != 1 {
+		t.Errorf("expected one dropped item, got %+v", q.Stats())
+	}
+}
+
+func TestCloseDrainsThenFails(t *testing.T) {
+	q := New(4, byID)
+	ctx := context.Background()
+	q.Push(ctx, update{"a", 1})
+	q.Close()
+
+	if err := q.Push(ctx, update{"b", 1}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if batch, err := q.PopBatch(ctx, 10); err != nil || len(batch) != 1 {
+		t.Errorf("expected remaining item, got %v, %v", batch, err)
+	}
+	if _, err := q.PopBatch(ctx, 10); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package exporter writes a session transcript to a file. Formats are
+// pluggable: each registers an Exporter under a name and the /export command
+// and --export flag pick one by name or by file extension. JSON exports can
+// be read back with ReadFile.
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// Transcript is everything an exporter may render.
+type Transcript struct {
+	Session  opencode.Session
+	Messages []app.Message
+	Exported time.Time
+	// Theme and Dark select the colors used by formats that carry styling.
+	Theme theme.Theme
+	Dark  bool
+}
+
+type Exporter interface {
+	// Extensions lists the file extensions for this format, preferred first.
+	Extensions() []string
+	Export(w io.Writer, transcript Transcript) error
+}
+
+var (
+	exporters = map[string]Exporter{}
+	aliases   = map[string]string{}
+)
+
+// Register makes an exporter available under name and any aliases.
+func Register(name string, exporter Exporter, alias ...string) {
+	exporters[name] = exporter
+	for _, a := range alias {
+		aliases[a] = name
+	}
+}
+
+func init() {
+	Register("markdown", markdownExporter{}, "md")
+	Register("json", jsonExporter{})
+	Register("html", htmlExporter{}, "htm")
+}
+
+// Formats returns the registered format names, sorted.
+func Formats() []string {
+	var names []string
+	for name := range exporters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns the exporter registered under name or one of its aliases.
+func Get(name string) (Exporter, bool) {
+	name = strings.ToLower(name)
+	if target, ok := aliases[name]; ok {
+		name = target
+	}
+	exporter, ok := exporters[name]
+	return exporter, ok
+}
+
+// Detect picks the format for path from its extension.
+func Detect(path string) (string, bool) {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if ext == "" {
+		return "", false
+	}
+	for name, exporter := range exporters {
+		if slices.Contains(exporter.Extensions(), ext) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// WriteFile exports transcript to path. An empty format is detected from the
+// extension. The file is written next to its destination and renamed into
+// place so a failed export never leaves a truncated file behind.
+func WriteFile(path, format string, transcript Transcript) error {
+	if format == "" {
+		detected, ok := Detect(path)
+		if !ok {
+			return fmt.Errorf("cannot tell export format from %q: use one of %s", path, strings.Join(Formats(), ", "))
+		}
+		format = detected
+	}
+	exporter, ok := Get(format)
+	if !ok {
+		return fmt.Errorf("unknown export format %q: use one of %s", format, strings.Join(Formats(), ", "))
+	}
+	if transcript.Exported.IsZero() {
+		transcript.Exported = time.Now()
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := exporter.Export(tmp, transcript); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// DefaultPath returns a file name for exporting session in format.
+func DefaultPath(session opencode.Session, format string) string {
+	ext := format
+	if exporter, ok := Get(format); ok {
+		ext = exporter.Extensions()[0]
+	}
+	return fmt.Sprintf("session-%s.%s", session.ID, ext)
+}
+
+func timestamp(millis float64) time.Time {
+	return time.UnixMilli(int64(millis))
+}
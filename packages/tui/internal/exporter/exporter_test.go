@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+)
+
+func testTranscript() Transcript {
+	return Transcript{
+		Session:  opencode.Session{ID: "ses_1", Title: "Fix the build"},
+		Exported: time.UnixMilli(2000),
+		Messages: []app.Message{
+			{
+				Info: opencode.UserMessage{ID: "msg_1", Role: opencode.UserMessageRoleUser, SessionID: "ses_1", Time: opencode.UserMessageTime{Created: 1000}},
+				Parts: []opencode.PartUnion{
+					opencode.TextPart{ID: "prt_1", MessageID: "msg_1", Type: opencode.TextPartTypeText, Text: "please fix main.go"},
+				},
+			},
+			{
+				Info: opencode.AssistantMessage{
+					ID:         "msg_2",
+					Role:       opencode.AssistantMessageRoleAssistant,
+					SessionID:  "ses_1",
+					ProviderID: "anthropic",
+					ModelID:    "claude",
+					Cost:       0.25,
+					Tokens:     opencode.AssistantMessageTokens{Input: 1500, Output: 42},
+					Time:       opencode.AssistantMessageTime{Created: 1100, Completed: 1600},
+				},
+				Parts: []opencode.PartUnion{
+					opencode.ReasoningPart{ID: "prt_2", MessageID: "msg_2", Type: opencode.ReasoningPartTypeReasoning, Text: "look at the imports"},
+					opencode.ToolPart{
+						ID:        "prt_3",
+						MessageID: "msg_2",
+						Tool:      "edit",
+						Type:      opencode.ToolPartTypeTool,
+						State: opencode.ToolPartState{
+							Status:   opencode.ToolPartStateStatus("completed"),
+							Title:    "main.go",
+							Input:    map[string]any{"filePath": "main.go"},
+							Output:   "ok ``` done",
+							Metadata: map[string]any{"diff": "-old\n+new"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMarkdownIncludesToolDetail(t *testing.T) {
+	var out bytes.Buffer
+	if err := (markdownExporter{}).Export(&out, testTranscript()); err != nil {
+		t.Fatal(err)
+	}
+	markdown := out.String()
+	for _, want := range []string{
+		"# Fix the build",
+		"`anthropic/claude`",
+		"1.5K in · 42 out · $0.2500",
+		"<summary>Thinking</summary>",
+		"\"filePath\": \"main.go\"",
+		"```diff\n-old\n+new\n```",
+		"````\nok ``` done\n````",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown missing %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestJSONKeepsEveryPart(t *testing.T) {
+	var out bytes.Buffer
+	if err := (jsonExporter{}).Export(&out, testTranscript()); err != nil {
+		t.Fatal(err)
+	}
+	var document Document
+	if err := json.Unmarshal(out.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if document.Version != DocumentVersion || len(document.Messages) != 2 || len(document.Messages[1].Parts) != 2 {
+		t.Fatalf("unexpected document: %+v", document)
+	}
+	var part opencode.Part
+	if err := json.Unmarshal(document.Messages[1].Parts[1], &part); err != nil {
+		t.Fatal(err)
+	}
+	tool, ok := part.AsUnion().(opencode.ToolPart)
+	if !ok || tool.State.Output != "ok ``` done" {
+		t.Errorf("tool part did not round trip: %#v", part.AsUnion())
+	}
+}
+
+func TestWriteFileDetectsFormat(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.html")
+	if err := WriteFile(path, "", testTranscript()); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "<!DOCTYPE html>") {
+		t.Errorf("expected html, got %.40q", content)
+	}
+	if err := WriteFile(filepath.Join(dir, "session.txt"), "", testTranscript()); err == nil {
+		t.Error("expected unknown extension to fail")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestDecodeReadsJSONExport(t *testing.T) {
+	var out bytes.Buffer
+	if err := (jsonExporter{}).Export(&out, testTranscript()); err != nil {
+		t.Fatal(err)
+	}
+	transcript, err := Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcript.Session.ID != "ses_1" || len(transcript.Messages) != 2 {
+		t.Fatalf("unexpected transcript: %+v", transcript)
+	}
+	if _, ok := transcript.Messages[1].Info.(opencode.AssistantMessage); !ok {
+		t.Errorf("expected assistant message, got %T", transcript.Messages[1].Info)
+	}
+	if _, ok := transcript.Messages[1].Parts[0].(opencode.ReasoningPart); !ok {
+		t.Errorf("expected reasoning part, got %T", transcript.Messages[1].Parts[0])
+	}
+
+	if _, err := Decode(strings.NewReader(`{"version": 99}`)); err == nil {
+		t.Error("expected newer version to be rejected")
+	}
+}
+
+func TestDecodeReadsMessageArray(t *testing.T) {
+	data, err := json.MarshalIndent(testTranscript().Messages, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transcript, err := Decode(bytes.NewReader(append([]byte("\n "), data...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcript.Session.ID != "" || len(transcript.Messages) != 2 {
+		t.Fatalf("unexpected transcript: %+v", transcript)
+	}
+	if user, ok := transcript.Messages[0].Info.(opencode.UserMessage); !ok || user.ID != "msg_1" {
+		t.Errorf("expected user message msg_1, got %+v", transcript.Messages[0].Info)
+	}
+	if tool, ok := transcript.Messages[1].Parts[1].(opencode.ToolPart); !ok || tool.State.Title != "main.go" {
+		t.Errorf("expected the edit tool part, got %+v", transcript.Messages[1].Parts[1])
+	}
+}
@@ -0,0 +1,185 @@
+package exporter
+
+import (
+	"fmt"
+	"html/template"
+	"image/color"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss/v2/compat"
+	"github.com/sst/opencode/internal/theme"
+)
+
+type htmlExporter struct{}
+
+func (htmlExporter) Extensions() []string { return []string{"html", "htm"} }
+
+type palette struct {
+	Background, Panel, Element                         string
+	Text, Muted, Primary, Accent                       string
+	Border, Error, Success, Warning                    string
+	DiffAdded, DiffRemoved, DiffAddedBg, DiffRemovedBg string
+}
+
+func newPalette(t theme.Theme, dark bool) palette {
+	if t == nil {
+		t = theme.CurrentTheme()
+	}
+	pick := func(get func(theme.Theme) compat.AdaptiveColor, fallback string) string {
+		if t == nil {
+			return fallback
+		}
+		return hexColor(get(t), dark, fallback)
+	}
+	return palette{
+		Background:    pick(theme.Theme.Background, "#0a0a0a"),
+		Panel:         pick(theme.Theme.BackgroundPanel, "#141414"),
+		Element:       pick(theme.Theme.BackgroundElement, "#1e1e1e"),
+		Text:          pick(theme.Theme.Text, "#eeeeee"),
+		Muted:         pick(theme.Theme.TextMuted, "#808080"),
+		Primary:       pick(theme.Theme.Primary, "#fab283"),
+		Accent:        pick(theme.Theme.Accent, "#9d7cd8"),
+		Border:        pick(theme.Theme.Border, "#484848"),
+		Error:         pick(theme.Theme.Error, "#e06c75"),
+		Success:       pick(theme.Theme.Success, "#7fd88f"),
+		Warning:       pick(theme.Theme.Warning, "#f5a742"),
+		DiffAdded:     pick(theme.Theme.DiffAdded, "#4fd6be"),
+		DiffRemoved:   pick(theme.Theme.DiffRemoved, "#c53b53"),
+		DiffAddedBg:   pick(theme.Theme.DiffAddedBg, "#20303b"),
+		DiffRemovedBg: pick(theme.Theme.DiffRemovedBg, "#37222c"),
+	}
+}
+
+// hexColor resolves the variant for the background in use. Transparent or
+// unset colors, which themes use to inherit the terminal's, fall back.
+func hexColor(c compat.AdaptiveColor, dark bool, fallback string) string {
+	var variant color.Color = c.Light
+	if dark {
+		variant = c.Dark
+	}
+	if variant == nil {
+		return fallback
+	}
+	r, g, b, a := variant.RGBA()
+	if a == 0 {
+		return fallback
+	}
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+type diffLine struct {
+	Class string
+	Text  string
+}
+
+func diffLines(diff string) []diffLine {
+	var lines []diffLine
+	for _, line := range strings.Split(diff, "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "Index:"), strings.HasPrefix(line, "==="):
+			class = "meta"
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		lines = append(lines, diffLine{Class: class, Text: line})
+	}
+	return lines
+}
+
+var htmlTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"diffLines": diffLines,
+	"formatTime": func(t time.Time) string {
+		return t.Format(timeLayout)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+:root {
+  --bg: {{.Colors.Background}}; --panel: {{.Colors.Panel}}; --element: {{.Colors.Element}};
+  --text: {{.Colors.Text}}; --muted: {{.Colors.Muted}}; --primary: {{.Colors.Primary}};
+  --accent: {{.Colors.Accent}}; --border: {{.Colors.Border}}; --error: {{.Colors.Error}};
+  --success: {{.Colors.Success}}; --warning: {{.Colors.Warning}};
+  --diff-add: {{.Colors.DiffAdded}}; --diff-del: {{.Colors.DiffRemoved}};
+  --diff-add-bg: {{.Colors.DiffAddedBg}}; --diff-del-bg: {{.Colors.DiffRemovedBg}};
+}
+body { background: var(--bg); color: var(--text); font: 14px/1.5 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; margin: 0 auto; max-width: 960px; padding: 2rem 1rem; }
+h1 { color: var(--primary); font-size: 1.4rem; }
+header ul { color: var(--muted); list-style: none; padding: 0; }
+.message { border-left: 3px solid var(--border); margin: 1.5rem 0; padding: 0 1rem; }
+.message.user { border-color: var(--accent); }
+.message.assistant { border-color: var(--primary); }
+.role { font-weight: bold; }
+.meta { color: var(--muted); font-size: 0.85rem; }
+.text { white-space: pre-wrap; word-wrap: break-word; }
+details { background: var(--panel); border: 1px solid var(--border); border-radius: 4px; margin: 0.75rem 0; padding: 0.5rem 0.75rem; }
+summary { cursor: pointer; }
+.tool-completed summary .status { color: var(--success); }
+.tool-error summary .status { color: var(--error); }
+.tool-running summary .status, .tool-pending summary .status { color: var(--warning); }
+pre { background: var(--element); overflow-x: auto; padding: 0.5rem; white-space: pre; }
+pre .add { background: var(--diff-add-bg); color: var(--diff-add); display: block; }
+pre .del { background: var(--diff-del-bg); color: var(--diff-del); display: block; }
+pre .hunk, pre .meta { color: var(--muted); display: block; }
+.label { color: var(--muted); margin: 0.5rem 0 0; }
+.error { color: var(--error); }
+.reasoning { color: var(--muted); font-style: italic; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<ul>
+{{- with .Session.ID}}<li>Session: {{.}}</li>{{end}}
+{{- with .Session.Share.URL}}<li>Shared: <a href="{{.}}">{{.}}</a></li>{{end}}
+<li>Exported: {{formatTime .Exported}}</li>
+<li>Usage: {{.Total}}</li>
+</ul>
+</header>
+{{range .Messages}}
+<section class="message {{if eq .Role "User"}}user{{else}}assistant{{end}}" id="{{.ID}}">
+<div><span class="role">{{.Role}}</span> <span class="meta">{{formatTime .Time}}{{with .Model}} · {{.}}{{end}}{{with .Usage}} · {{.}}{{end}}</span></div>
+{{range .Parts}}
+{{- if eq .Kind "text"}}<div class="text">{{.Text}}</div>
+{{- else if eq .Kind "reasoning"}}<details class="reasoning"><summary>Thinking</summary><div class="text">{{.Text}}</div></details>
+{{- else if eq .Kind "file"}}<div class="meta">📎 {{.Filename}} ({{.Mime}})</div>
+{{- else if eq .Kind "tool"}}<details class="tool tool-{{.Status}}"><summary>🔧 <strong>{{.Tool}}</strong> {{.Title}} <span class="status">{{.Status}}</span>{{if .Duration}} <span class="meta">{{.Duration}}</span>{{end}}</summary>
+{{- with .Input}}<p class="label">Input</p><pre>{{.}}</pre>{{end}}
+{{- with .Diff}}<p class="label">Diff</p><pre>{{range diffLines .}}<span class="{{.Class}}">{{.Text}}</span>{{end}}</pre>{{end}}
+{{- with .Output}}<p class="label">Output</p><pre>{{.}}</pre>{{end}}
+{{- with .Error}}<p class="error">{{.}}</p>{{end}}
+</details>
+{{- end}}
+{{end}}
+{{- with .Error}}<p class="error">Error: {{.}}</p>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+func (htmlExporter) Export(w io.Writer, transcript Transcript) error {
+	views, total := buildViews(transcript.Messages)
+	title := transcript.Session.Title
+	if title == "" {
+		title = "Conversation History"
+	}
+	return htmlTemplate.Execute(w, map[string]any{
+		"Title":    title,
+		"Session":  transcript.Session,
+		"Exported": transcript.Exported,
+		"Total":    total,
+		"Messages": views,
+		"Colors":   newPalette(transcript.Theme, transcript.Dark),
+	})
+}
@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+)
+
+// ReadFile loads a transcript written by the JSON exporter.
+func ReadFile(path string) (Transcript, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Transcript{}, err
+	}
+	defer file.Close()
+	transcript, err := Decode(file)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("failed to read transcript %s: %w", path, err)
+	}
+	return transcript, nil
+}
+
+// Decode reads a JSON export back into SDK types. A bare array of messages,
+// as []app.Message marshals to, is read as a transcript without a session.
+func Decode(r io.Reader) (Transcript, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Transcript{}, err
+	}
+	var document Document
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &document.Messages)
+	} else {
+		err = json.Unmarshal(data, &document)
+	}
+	if err != nil {
+		return Transcript{}, err
+	}
+	if document.Version > DocumentVersion {
+		return Transcript{}, fmt.Errorf("transcript version %d is newer than supported version %d", document.Version, DocumentVersion)
+	}
+
+	transcript := Transcript{
+		Session:  document.Session,
+		Exported: document.Exported,
+		Messages: make([]app.Message, 0, len(document.Messages)),
+	}
+	for i, entry := range document.Messages {
+		var info opencode.Message
+		if err := json.Unmarshal(entry.Info, &info); err != nil {
+			return Transcript{}, fmt.Errorf("message %d: %w", i, err)
+		}
+		message := app.Message{Info: info.AsUnion(), Parts: make([]opencode.PartUnion, 0, len(entry.Parts))}
+		for j, raw := range entry.Parts {
+			var part opencode.Part
+			if err := json.Unmarshal(raw, &part); err != nil {
+				return Transcript{}, fmt.Errorf("message %d part %d: %w", i, j, err)
+			}
+			message.Parts = append(message.Parts, part.AsUnion())
+		}
+		transcript.Messages = append(transcript.Messages, message)
+	}
+	return transcript, nil
+}
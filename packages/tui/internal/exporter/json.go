@@ -0,0 +1,58 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	opencode "github.com/sst/opencode-api-go"
+)
+
+// DocumentVersion is bumped when the JSON export layout changes.
+const DocumentVersion = 1
+
+// Document is the JSON export layout. Messages and parts keep the server's
+// own JSON shape so nothing is lost and they decode back into SDK types.
+type Document struct {
+	Version  int               `json:"version"`
+	Exported time.Time         `json:"exported"`
+	Session  opencode.Session  `json:"session"`
+	Messages []DocumentMessage `json:"messages"`
+}
+
+type DocumentMessage struct {
+	Info  json.RawMessage   `json:"info"`
+	Parts []json.RawMessage `json:"parts"`
+}
+
+type jsonExporter struct{}
+
+func (jsonExporter) Extensions() []string { return []string{"json"} }
+
+func (jsonExporter) Export(w io.Writer, transcript Transcript) error {
+	document := Document{
+		Version:  DocumentVersion,
+		Exported: transcript.Exported,
+		Session:  transcript.Session,
+		Messages: make([]DocumentMessage, 0, len(transcript.Messages)),
+	}
+	for _, message := range transcript.Messages {
+		info, err := json.Marshal(message.Info)
+		if err != nil {
+			return err
+		}
+		entry := DocumentMessage{Info: info, Parts: make([]json.RawMessage, 0, len(message.Parts))}
+		for _, part := range message.Parts {
+			raw, err := json.Marshal(part)
+			if err != nil {
+				return err
+			}
+			entry.Parts = append(entry.Parts, raw)
+		}
+		document.Messages = append(document.Messages, entry)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+type markdownExporter struct{}
+
+func (markdownExporter) Extensions() []string { return []string{"md", "markdown"} }
+
+func (markdownExporter) Export(w io.Writer, transcript Transcript) error {
+	views, total := buildViews(transcript.Messages)
+
+	var b strings.Builder
+	title := transcript.Session.Title
+	if title == "" {
+		title = "Conversation History"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if transcript.Session.ID != "" {
+		fmt.Fprintf(&b, "- **Session:** `%s`\n", transcript.Session.ID)
+		fmt.Fprintf(&b, "- **Created:** %s\n", timestamp(transcript.Session.Time.Created).Format(timeLayout))
+	}
+	if transcript.Session.Share.URL != "" {
+		fmt.Fprintf(&b, "- **Shared:** %s\n", transcript.Session.Share.URL)
+	}
+	fmt.Fprintf(&b, "- **Exported:** %s\n", transcript.Exported.Format(timeLayout))
+	fmt.Fprintf(&b, "- **Usage:** %s\n\n", total)
+
+	for _, message := range views {
+		b.WriteString("---\n\n")
+		fmt.Fprintf(&b, "## %s\n\n", message.Role)
+		meta := []string{message.Time.Format(timeLayout)}
+		if message.Model != "" {
+			meta = append(meta, "`"+message.Model+"`")
+		}
+		if message.Usage != nil {
+			meta = append(meta, message.Usage.String())
+		}
+		fmt.Fprintf(&b, "*%s*\n\n", strings.Join(meta, " · "))
+
+		for _, part := range message.Parts {
+			writeMarkdownPart(&b, part)
+		}
+		if message.Error != "" {
+			fmt.Fprintf(&b, "> **Error:** %s\n\n", message.Error)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownPart(b *strings.Builder, part partView) {
+	switch part.Kind {
+	case partText:
+		b.WriteString(strings.TrimSpace(part.Text) + "\n\n")
+	case partReasoning:
+		b.WriteString("<details>\n<summary>Thinking</summary>\n\n")
+		b.WriteString(strings.TrimSpace(part.Text) + "\n\n")
+		b.WriteString("</details>\n\n")
+	case partFile:
+		fmt.Fprintf(b, "📎 **%s** (`%s`)\n\n", part.Filename, part.Mime)
+	case partTool:
+		heading := "**" + part.Tool + "**"
+		if part.Title != "" {
+			heading += " " + part.Title
+		}
+		status := part.Status
+		if part.Duration > 0 {
+			status += ", " + part.Duration.Round(10*time.Millisecond).String()
+		}
+		fmt.Fprintf(b, "### 🔧 %s (%s)\n\n", heading, status)
+		if part.Input != "" {
+			b.WriteString("**Input**\n\n" + fence(part.Input, "json") + "\n")
+		}
+		if part.Diff != "" {
+			b.WriteString("**Diff**\n\n" + fence(part.Diff, "diff") + "\n")
+		}
+		if part.Output != "" {
+			b.WriteString("**Output**\n\n" + fence(part.Output, "") + "\n")
+		}
+		if part.Error != "" {
+			fmt.Fprintf(b, "> **Error:** %s\n\n", part.Error)
+		}
+	}
+}
+
+// fence wraps content in a code block whose fence is longer than any run of
+// backticks inside it.
+func fence(content, lang string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	marker := strings.Repeat("`", max(3, longest+1))
+	return marker + lang + "\n" + content + "\n" + marker + "\n"
+}
@@ -0,0 +1,213 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode-api-go/shared"
+	"github.com/sst/opencode/internal/app"
+)
+
+// messageView is the format-neutral shape of a message shared by the
+// Markdown and HTML exporters.
+type messageView struct {
+	ID    string
+	Role  string
+	Time  time.Time
+	Model string
+	Usage *usage
+	Error string
+	Parts []partView
+}
+
+type partKind string
+
+const (
+	partText      partKind = "text"
+	partReasoning partKind = "reasoning"
+	partFile      partKind = "file"
+	partTool      partKind = "tool"
+)
+
+type partView struct {
+	Kind partKind
+	Text string
+
+	// File parts.
+	Filename string
+	Mime     string
+	URL      string
+
+	// Tool parts.
+	Tool     string
+	Title    string
+	Status   string
+	Input    string
+	Output   string
+	Error    string
+	Diff     string
+	Duration time.Duration
+}
+
+type usage struct {
+	Input      float64
+	Output     float64
+	Reasoning  float64
+	CacheRead  float64
+	CacheWrite float64
+	Cost       float64
+	Duration   time.Duration
+}
+
+func (u *usage) add(other *usage) {
+	u.Input += other.Input
+	u.Output += other.Output
+	u.Reasoning += other.Reasoning
+	u.CacheRead += other.CacheRead
+	u.CacheWrite += other.CacheWrite
+	u.Cost += other.Cost
+	u.Duration += other.Duration
+}
+
+func (u usage) String() string {
+	parts := []string{
+		fmt.Sprintf("%s in", formatTokens(u.Input)),
+		fmt.Sprintf("%s out", formatTokens(u.Output)),
+	}
+	if u.Reasoning > 0 {
+		parts = append(parts, fmt.Sprintf("%s reasoning", formatTokens(u.Reasoning)))
+	}
+	if u.CacheRead > 0 || u.CacheWrite > 0 {
+		parts = append(parts, fmt.Sprintf("%s/%s cache r/w", formatTokens(u.CacheRead), formatTokens(u.CacheWrite)))
+	}
+	parts = append(parts, fmt.Sprintf("$%.4f", u.Cost))
+	if u.Duration > 0 {
+		parts = append(parts, u.Duration.Round(100*time.Millisecond).String())
+	}
+	return strings.Join(parts, " · ")
+}
+
+func formatTokens(tokens float64) string {
+	switch {
+	case tokens >= 1_000_000:
+		return fmt.Sprintf("%.1fM", tokens/1_000_000)
+	case tokens >= 1_000:
+		return fmt.Sprintf("%.1fK", tokens/1_000)
+	}
+	return fmt.Sprintf("%d", int64(tokens))
+}
+
+func buildViews(messages []app.Message) ([]messageView, usage) {
+	var views []messageView
+	var total usage
+	for _, message := range messages {
+		var view messageView
+		switch info := message.Info.(type) {
+		case opencode.UserMessage:
+			view = messageView{ID: info.ID, Role: "User", Time: timestamp(info.Time.Created)}
+		case opencode.AssistantMessage:
+			view = messageView{
+				ID:    info.ID,
+				Role:  "Assistant",
+				Time:  timestamp(info.Time.Created),
+				Model: info.ProviderID + "/" + info.ModelID,
+				Usage: &usage{
+					Input:      info.Tokens.Input,
+					Output:     info.Tokens.Output,
+					Reasoning:  info.Tokens.Reasoning,
+					CacheRead:  info.Tokens.Cache.Read,
+					CacheWrite: info.Tokens.Cache.Write,
+					Cost:       info.Cost,
+				},
+				Error: assistantError(info.Error),
+			}
+			if info.Time.Completed > 0 {
+				view.Usage.Duration = timestamp(info.Time.Completed).Sub(view.Time)
+			}
+			total.add(view.Usage)
+		default:
+			continue
+		}
+		for _, part := range message.Parts {
+			if partView, ok := buildPart(part); ok {
+				view.Parts = append(view.Parts, partView)
+			}
+		}
+		views = append(views, view)
+	}
+	return views, total
+}
+
+func buildPart(part opencode.PartUnion) (partView, bool) {
+	switch p := part.(type) {
+	case opencode.TextPart:
+		if strings.TrimSpace(p.Text) == "" {
+			return partView{}, false
+		}
+		return partView{Kind: partText, Text: p.Text}, true
+	case opencode.ReasoningPart:
+		if strings.TrimSpace(p.Text) == "" {
+			return partView{}, false
+		}
+		return partView{Kind: partReasoning, Text: p.Text}, true
+	case opencode.FilePart:
+		filename := p.Filename
+		if filename == "" {
+			filename = p.Source.Path
+		}
+		return partView{Kind: partFile, Filename: filename, Mime: p.Mime, URL: p.URL}, true
+	case opencode.ToolPart:
+		view := partView{
+			Kind:   partTool,
+			Tool:   p.Tool,
+			Title:  p.State.Title,
+			Status: string(p.State.Status),
+			Output: strings.TrimRight(p.State.Output, "\n"),
+			Error:  p.State.Error,
+		}
+		if p.State.Input != nil {
+			if input, err := json.MarshalIndent(p.State.Input, "", "  "); err == nil && string(input) != "{}" {
+				view.Input = string(input)
+			}
+		}
+		if metadata, ok := p.State.Metadata.(map[string]any); ok {
+			if diff, ok := metadata["diff"].(string); ok {
+				view.Diff = strings.TrimRight(diff, "\n")
+			}
+			// bash streams its output into metadata before the part completes
+			if output, ok := metadata["output"].(string); ok && view.Output == "" {
+				view.Output = strings.TrimRight(output, "\n")
+			}
+		}
+		switch t := p.State.Time.(type) {
+		case opencode.ToolStateCompletedTime:
+			view.Duration = timestamp(t.End).Sub(timestamp(t.Start))
+		case opencode.ToolStateErrorTime:
+			view.Duration = timestamp(t.End).Sub(timestamp(t.Start))
+		}
+		return view, true
+	}
+	return partView{}, false
+}
+
+func assistantError(err opencode.AssistantMessageError) string {
+	if err.Name == "" {
+		return ""
+	}
+	var message string
+	switch data := err.Data.(type) {
+	case shared.ProviderAuthErrorData:
+		message = data.Message
+	case shared.UnknownErrorData:
+		message = data.Message
+	case map[string]any:
+		message, _ = data["message"].(string)
+	}
+	if message == "" {
+		return string(err.Name)
+	}
+	return fmt.Sprintf("%s: %s", err.Name, message)
+}
@@ -0,0 +1,491 @@
+// Package headless runs a single prompt without the full-screen TUI, writing
+// assistant text and tool activity to stdout for scripts and CI jobs.
+package headless
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/toast"
+)
+
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// PermissionMode decides how permission requests the permission policy
+// leaves to the user are answered, since nobody is around to press a key.
+type PermissionMode string
+
+const (
+	PermissionReject PermissionMode = "reject"
+	PermissionOnce   PermissionMode = "once"
+	PermissionAlways PermissionMode = "always"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case FormatText, FormatJSON:
+		return Format(value), nil
+	case "ndjson":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unknown format %q: expected text or json", value)
+}
+
+func ParsePermissionMode(value string) (PermissionMode, error) {
+	switch PermissionMode(value) {
+	case PermissionReject, PermissionOnce, PermissionAlways:
+		return PermissionMode(value), nil
+	case "allow", "approve":
+		return PermissionOnce, nil
+	}
+	return "", fmt.Errorf("unknown permission mode %q: expected reject, once or always", value)
+}
+
+// DefaultConnectTimeout is how long Run waits for the event stream to
+// connect when Options doesn't say.
+const DefaultConnectTimeout = 30 * time.Second
+
+type Options struct {
+	Prompt         string
+	Format         Format
+	Permissions    PermissionMode
+	ConnectTimeout time.Duration
+}
+
+// ErrSessionFailed is returned when the server reports a session.error.
+var ErrSessionFailed = errors.New("session failed")
+
+type runner struct {
+	ctx  context.Context
+	app  *app.App
+	opts Options
+	out  *output
+
+	results  chan tea.Msg
+	pending  int
+	sessions map[string]bool
+	printed  map[string]int
+	tools    map[string]opencode.ToolPartStateStatus
+	failure  error
+}
+
+// Run sends opts.Prompt through a.SendPrompt and streams the response until
+// the assistant finishes. It returns an error wrapping ErrSessionFailed if the
+// session reports an error. Permission requests go to a's permission policy
+// first, and are rejected unless opts says otherwise.
+func Run(ctx context.Context, a *app.App, opts Options, stdout, stderr io.Writer) error {
+	if strings.TrimSpace(opts.Prompt) == "" {
+		return errors.New("no prompt given: pass --prompt or pipe it on stdin")
+	}
+	opts.Permissions = cmp.Or(opts.Permissions, PermissionReject)
+	opts.ConnectTimeout = cmp.Or(opts.ConnectTimeout, DefaultConnectTimeout)
+
+	provider, model := a.SelectInitialModel()
+	if provider == nil || model == nil {
+		return errors.New("no model available: configure a provider or pass --model")
+	}
+	a.Provider, a.Model = provider, model
+
+	if a.InitialSession != nil && *a.InitialSession != "" {
+		if err := loadSession(ctx, a, *a.InitialSession); err != nil {
+			return err
+		}
+	}
+
+	// Commands still running when Run returns give up on delivering results
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r := &runner{
+		ctx:      ctx,
+		app:      a,
+		opts:     opts,
+		out:      &output{format: opts.Format, stdout: stdout, stderr: stderr},
+		results:  make(chan tea.Msg),
+		sessions: map[string]bool{},
+		printed:  map[string]int{},
+		tools:    map[string]opencode.ToolPartStateStatus{},
+	}
+
+	events := make(chan app.EventBatchMsg)
+	go func() {
+		defer close(events)
+		for {
+			cmd := a.WaitForEvents()
+			if cmd == nil {
+				return
+			}
+			batch, ok := cmd().(app.EventBatchMsg)
+			if !ok {
+				return
+			}
+			select {
+			case events <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Events published before the stream connects are never delivered
+	if err := waitForEventStream(ctx, a, opts.ConnectTimeout); err != nil {
+		return err
+	}
+
+	_, cmd := a.SendPrompt(ctx, app.Prompt{Text: opts.Prompt})
+	// A new session is created before the prompt is sent, and its permission
+	// requests may come before SessionCreatedMsg
+	if a.Session.ID != "" {
+		r.sessions[a.Session.ID] = true
+	}
+	r.exec(cmd)
+
+	for !r.finished() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-r.results:
+			r.pending--
+			r.handle(msg)
+		case batch, ok := <-events:
+			if !ok {
+				return errors.New("event stream closed before the assistant finished")
+			}
+			r.handleEvents(batch.Events)
+		}
+	}
+
+	if r.failure != nil {
+		return r.failure
+	}
+	r.out.done(a)
+	return nil
+}
+
+func loadSession(ctx context.Context, a *app.App, sessionID string) error {
+	sessions, err := a.ListSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			a.Session = &session
+			a.Messages, err = a.ListMessages(ctx, session.ID)
+			return err
+		}
+	}
+	return fmt.Errorf("session not found: %s", sessionID)
+}
+
+// waitForEventStream waits until a's event stream is connected, failing
+// after timeout.
+func waitForEventStream(ctx context.Context, a *app.App, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		status := a.EventStreamStatus()
+		if status.State == app.EventStreamConnected {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			if status.Err != nil {
+				return fmt.Errorf("event stream did not connect within %s: %w", timeout, status.Err)
+			}
+			return fmt.Errorf("event stream did not connect within %s", timeout)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// exec runs cmd in the background, delivering its result to r.results
+// unless Run has returned.
+func (r *runner) exec(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	r.pending++
+	go func() {
+		msg := cmd()
+		select {
+		case r.results <- msg:
+		case <-r.ctx.Done():
+		}
+	}()
+}
+
+// finished reports whether the prompt request has returned and the last
+// assistant message is complete, or something failed.
+func (r *runner) finished() bool {
+	if r.pending > 0 {
+		return false
+	}
+	if r.failure != nil {
+		return true
+	}
+	if len(r.app.Messages) == 0 {
+		return false
+	}
+	last, ok := r.app.Messages[len(r.app.Messages)-1].Info.(opencode.AssistantMessage)
+	return ok && last.Time.Completed != 0
+}
+
+func (r *runner) handle(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			r.exec(cmd)
+		}
+	case app.SessionCreatedMsg:
+		// SendPrompt has set the session already, and its prompt command may
+		// be reading it
+		r.sessions[msg.Session.ID] = true
+	case app.EventBatchMsg:
+		r.handleEvents(msg.Events)
+	case toast.ShowToastMsg:
+		// SendPrompt reports request failures as error toasts; other toasts
+		// are only notices
+		if !msg.Error {
+			slog.Info("Headless run notice", "message", msg.Message)
+			return
+		}
+		r.failure = errors.New(msg.Message)
+		r.out.error(msg.Message)
+	}
+}
+
+func (r *runner) handleEvents(batch []opencode.EventListResponse) {
+	for _, event := range r.app.ProcessEvents(batch) {
+		switch event := event.(type) {
+		case opencode.EventListResponseEventSessionUpdated:
+			if r.sessions[event.Properties.Info.ParentID] {
+				r.sessions[event.Properties.Info.ID] = true
+			}
+		case opencode.EventListResponseEventMessageUpdated:
+			r.app.UpdateMessage(event.Properties.Info)
+		case opencode.EventListResponseEventMessagePartUpdated:
+			r.app.UpdatePart(event.Properties.Part)
+			if event.Properties.Part.SessionID == r.app.Session.ID {
+				r.part(event.Properties.Part.AsUnion())
+			}
+		case opencode.EventListResponseEventMessagePartRemoved:
+			r.app.RemovePart(event.Properties.SessionID, event.Properties.MessageID, event.Properties.PartID)
+		case opencode.EventListResponseEventMessageRemoved:
+			r.app.RemoveMessage(event.Properties.SessionID, event.Properties.MessageID)
+		case opencode.EventListResponseEventPermissionUpdated:
+			if r.sessions[event.Properties.SessionID] {
+				r.respond(event.Properties)
+			}
+		case opencode.EventListResponseEventSessionError:
+			sessionID := event.Properties.SessionID
+			if sessionID != "" && !r.sessions[sessionID] {
+				continue
+			}
+			message := sessionErrorMessage(event.Properties.Error)
+			r.failure = fmt.Errorf("%w: %s", ErrSessionFailed, message)
+			r.out.error(message)
+		}
+	}
+}
+
+func (r *runner) part(part opencode.PartUnion) {
+	switch part := part.(type) {
+	case opencode.TextPart:
+		if !r.isAssistant(part.MessageID) || part.Synthetic {
+			return
+		}
+		printed := r.printed[part.ID]
+		if len(part.Text) <= printed {
+			return
+		}
+		r.printed[part.ID] = len(part.Text)
+		r.out.text(part, part.Text[printed:])
+	case opencode.ToolPart:
+		if r.tools[part.ID] == part.State.Status {
+			return
+		}
+		r.tools[part.ID] = part.State.Status
+		if part.State.Status == opencode.ToolPartStateStatusPending {
+			return
+		}
+		r.out.tool(part)
+	}
+}
+
+func (r *runner) isAssistant(messageID string) bool {
+	for _, message := range r.app.Messages {
+		if message.ID() == messageID {
+			_, ok := message.Info.(opencode.AssistantMessage)
+			return ok
+		}
+	}
+	return false
+}
+
+func (r *runner) respond(permission opencode.Permission) {
+	response, ok := r.app.PolicyResponse(permission)
+	if !ok {
+		response = opencode.SessionPermissionRespondParamsResponse(r.opts.Permissions)
+	}
+	r.out.permission(permission, response)
+	r.exec(func() tea.Msg {
+		_, err := r.app.Client.Session.Permissions.Respond(
+			context.Background(),
+			permission.SessionID,
+			permission.ID,
+			opencode.SessionPermissionRespondParams{Response: opencode.F(response)},
+		)
+		if err != nil {
+			slog.Error("Failed to respond to permission request", "error", err)
+			return toast.ShowToastMsg{Message: "failed to respond to permission request: " + err.Error()}
+		}
+		return nil
+	})
+}
+
+func sessionErrorMessage(sessionError opencode.EventListResponseEventSessionErrorPropertiesError) string {
+	switch err := sessionError.AsUnion().(type) {
+	case opencode.ProviderAuthError:
+		return "provider error: " + err.Data.Message
+	case opencode.UnknownError:
+		return err.Data.Message
+	case nil:
+		return "unknown error"
+	default:
+		return fmt.Sprintf("%v", err)
+	}
+}
+
+// output writes either human readable text or one JSON object per line.
+type output struct {
+	format     Format
+	stdout     io.Writer
+	stderr     io.Writer
+	midLine    bool
+	lastPartID string
+}
+
+func (o *output) json(v map[string]any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("Failed to encode headless output", "error", err)
+		return
+	}
+	fmt.Fprintln(o.stdout, string(data))
+}
+
+// line starts a fresh line on stdout if text was left unterminated.
+func (o *output) line() {
+	if o.midLine {
+		fmt.Fprintln(o.stdout)
+		o.midLine = false
+	}
+}
+
+func (o *output) text(part opencode.TextPart, delta string) {
+	if o.format == FormatJSON {
+		o.json(map[string]any{
+			"type":    "text",
+			"session": part.SessionID,
+			"message": part.MessageID,
+			"part":    part.ID,
+			"delta":   delta,
+		})
+		return
+	}
+	if o.lastPartID != part.ID {
+		o.line()
+		o.lastPartID = part.ID
+	}
+	fmt.Fprint(o.stdout, delta)
+	o.midLine = !strings.HasSuffix(delta, "\n")
+}
+
+func (o *output) tool(part opencode.ToolPart) {
+	if o.format == FormatJSON {
+		event := map[string]any{
+			"type":    "tool",
+			"session": part.SessionID,
+			"message": part.MessageID,
+			"part":    part.ID,
+			"tool":    part.Tool,
+			"status":  part.State.Status,
+			"title":   part.State.Title,
+			"input":   part.State.Input,
+		}
+		switch part.State.Status {
+		case opencode.ToolPartStateStatusCompleted:
+			event["output"] = part.State.Output
+		case opencode.ToolPartStateStatusError:
+			event["error"] = part.State.Error
+		}
+		o.json(event)
+		return
+	}
+	o.line()
+	o.lastPartID = part.ID
+	switch part.State.Status {
+	case opencode.ToolPartStateStatusRunning:
+		fmt.Fprintf(o.stdout, "→ %s %s\n", part.Tool, part.State.Title)
+	case opencode.ToolPartStateStatusCompleted:
+		fmt.Fprintf(o.stdout, "✓ %s %s\n", part.Tool, part.State.Title)
+	case opencode.ToolPartStateStatusError:
+		fmt.Fprintf(o.stdout, "✗ %s: %s\n", part.Tool, part.State.Error)
+	}
+}
+
+func (o *output) permission(permission opencode.Permission, response opencode.SessionPermissionRespondParamsResponse) {
+	if o.format == FormatJSON {
+		o.json(map[string]any{
+			"type":     "permission",
+			"session":  permission.SessionID,
+			"id":       permission.ID,
+			"title":    permission.Title,
+			"pattern":  permission.Pattern,
+			"response": response,
+		})
+		return
+	}
+	fmt.Fprintf(o.stderr, "permission %s: %s\n", response, permission.Title)
+}
+
+func (o *output) error(message string) {
+	if o.format == FormatJSON {
+		o.json(map[string]any{"type": "error", "message": message})
+		return
+	}
+	o.line()
+	fmt.Fprintln(o.stderr, "error:", message)
+}
+
+func (o *output) done(a *app.App) {
+	if o.format != FormatJSON {
+		o.line()
+		return
+	}
+	event := map[string]any{"type": "done", "session": a.Session.ID}
+	if len(a.Messages) > 0 {
+		if last, ok := a.Messages[len(a.Messages)-1].Info.(opencode.AssistantMessage); ok {
+			event["message"] = last.ID
+			event["cost"] = last.Cost
+			event["tokens"] = last.Tokens
+		}
+	}
+	o.json(event)
+}
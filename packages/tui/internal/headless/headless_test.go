@@ -0,0 +1,276 @@
+package headless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/policy"
+	"github.com/sst/opencode/internal/testserver"
+	"github.com/sst/opencode/internal/tuitest"
+)
+
+type result struct {
+	stdout, stderr string
+	err            error
+}
+
+// run runs opts against server with a permission policy file holding rules,
+// instead of that of whoever runs the tests.
+func run(t *testing.T, server *testserver.Server, rules string, opts Options) result {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), policy.FileName)
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(policy.EnvFile, path)
+	a := tuitest.NewApp(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	err := Run(ctx, a, opts, &stdout, &stderr)
+	return result{stdout.String(), stderr.String(), err}
+}
+
+func readTool() testserver.Tool {
+	return testserver.Tool{
+		Name:   "read",
+		Title:  "main.go",
+		Input:  map[string]any{"filePath": "/project/main.go"},
+		Output: "package main",
+	}
+}
+
+func TestRunText(t *testing.T) {
+	server := testserver.New()
+	defer server.Close()
+	server.Reply(testserver.Reply{
+		Tools:  []testserver.Tool{readTool()},
+		Text:   "main.go only declares package main.",
+		Chunks: 3,
+	})
+
+	got := run(t, server, "", Options{Prompt: "what is in main.go?", Format: FormatText})
+	if got.err != nil {
+		t.Fatalf("run failed: %v\n%s", got.err, got.stderr)
+	}
+	// The running state of the tool may be coalesced with its completion
+	want := "✓ read main.go\nmain.go only declares package main.\n"
+	if !strings.HasSuffix(got.stdout, want) {
+		t.Errorf("stdout is %q, want it to end with %q", got.stdout, want)
+	}
+	if got.stderr != "" {
+		t.Errorf("unexpected stderr %q", got.stderr)
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	server := testserver.New()
+	defer server.Close()
+	server.Reply(testserver.Reply{
+		Tools:  []testserver.Tool{readTool()},
+		Text:   "main.go only declares package main.",
+		Chunks: 3,
+	})
+
+	got := run(t, server, "", Options{Prompt: "what is in main.go?", Format: FormatJSON})
+	if got.err != nil {
+		t.Fatalf("run failed: %v\n%s", got.err, got.stderr)
+	}
+
+	var types []string
+	var text strings.Builder
+	var last map[string]any
+	for line := range strings.Lines(got.stdout) {
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		eventType, _ := event["type"].(string)
+		if len(types) == 0 || types[len(types)-1] != eventType {
+			types = append(types, eventType)
+		}
+		if eventType == "text" {
+			delta, _ := event["delta"].(string)
+			text.WriteString(delta)
+		}
+		last = event
+	}
+	if want := []string{"tool", "text", "done"}; !slices.Equal(types, want) {
+		t.Errorf("event types are %v, want %v", types, want)
+	}
+	if text.String() != "main.go only declares package main." {
+		t.Errorf("text deltas add up to %q", text.String())
+	}
+	sessions := server.Sessions()
+	if len(sessions) != 1 || last["session"] != sessions[0].ID {
+		t.Errorf("done event %v does not name the session %v", last, sessions)
+	}
+}
+
+func TestRunExitStatus(t *testing.T) {
+	t.Run("session error", func(t *testing.T) {
+		server := testserver.New()
+		defer server.Close()
+		server.Reply(testserver.Reply{Error: "rate limited"})
+
+		got := run(t, server, "", Options{Prompt: "hello", Format: FormatText})
+		if !errors.Is(got.err, ErrSessionFailed) {
+			t.Errorf("expected a session failure, got %v", got.err)
+		}
+		if !strings.Contains(got.stderr, "error: rate limited") {
+			t.Errorf("stderr is %q", got.stderr)
+		}
+	})
+
+	t.Run("session error as JSON", func(t *testing.T) {
+		server := testserver.New()
+		defer server.Close()
+		server.Reply(testserver.Reply{Error: "rate limited"})
+
+		got := run(t, server, "", Options{Prompt: "hello", Format: FormatJSON})
+		if !errors.Is(got.err, ErrSessionFailed) {
+			t.Errorf("expected a session failure, got %v", got.err)
+		}
+		if !strings.Contains(got.stdout, `{"message":"rate limited","type":"error"}`) {
+			t.Errorf("stdout is %q", got.stdout)
+		}
+	})
+
+	t.Run("no prompt", func(t *testing.T) {
+		server := testserver.New()
+		defer server.Close()
+
+		if got := run(t, server, "", Options{Prompt: " \n", Format: FormatText}); got.err == nil {
+			t.Error("expected an error without a prompt")
+		}
+		if sessions := server.Sessions(); len(sessions) != 0 {
+			t.Errorf("an empty prompt created %d sessions", len(sessions))
+		}
+	})
+}
+
+func TestRunPermissions(t *testing.T) {
+	rules := `
+[[rule]]
+action = "allow"
+tool = "bash"
+command = "go test *"
+
+[[rule]]
+action = "deny"
+tool = "bash"
+command = "rm *"
+`
+	tests := []struct {
+		name    string
+		rules   string
+		mode    PermissionMode
+		command string
+		want    string
+	}{
+		{"rejected by default", "", "", "go test ./...", "reject"},
+		{"answered by the flag", "", PermissionOnce, "go test ./...", "once"},
+		{"allowed by the policy", rules, PermissionReject, "go test ./...", "once"},
+		{"denied by the policy", rules, PermissionAlways, "rm -rf build", "reject"},
+		{"left to the flag by the policy", rules, PermissionAlways, "make", "always"},
+		{"chained commands aren't allowed", rules, PermissionReject, "go test ./... && rm -rf ~", "reject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := testserver.New()
+			defer server.Close()
+			server.Reply(testserver.Reply{
+				Tools: []testserver.Tool{{
+					Name:       "bash",
+					Title:      tt.command,
+					Input:      map[string]any{"command": tt.command},
+					Output:     "ok",
+					Permission: tt.command,
+				}},
+				Text: "done",
+			})
+
+			got := run(t, server, tt.rules, Options{Prompt: "run it", Format: FormatText, Permissions: tt.mode})
+			if got.err != nil {
+				t.Fatalf("run failed: %v\n%s", got.err, got.stderr)
+			}
+			if responses := permissionResponses(t, server); len(responses) != 1 || responses[0] != tt.want {
+				t.Errorf("answered %v, want %s", responses, tt.want)
+			}
+			if want := "permission " + tt.want + ": " + tt.command + "\n"; got.stderr != want {
+				t.Errorf("stderr is %q, want %q", got.stderr, want)
+			}
+			if pending := server.Permissions(); len(pending) != 0 {
+				t.Errorf("permission requests left unanswered: %+v", pending)
+			}
+		})
+	}
+}
+
+// permissionResponses returns the responses sent to permission requests.
+func permissionResponses(t *testing.T, server *testserver.Server) []string {
+	t.Helper()
+	var responses []string
+	for _, request := range server.Requests() {
+		if !strings.Contains(request.Path, "/permissions/") {
+			continue
+		}
+		var body struct {
+			Response string `json:"response"`
+		}
+		if err := json.Unmarshal(request.Body, &body); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, body.Response)
+	}
+	return responses
+}
+
+func TestWaitForEventStream(t *testing.T) {
+	// The event stream of an App that was never started doesn't connect
+	err := waitForEventStream(context.Background(), &app.App{}, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "did not connect within 20ms") {
+		t.Errorf("expected a connect timeout, got %v", err)
+	}
+}
+
+func TestHandleToasts(t *testing.T) {
+	tests := []struct {
+		name    string
+		toast   tea.Cmd
+		failure string
+	}{
+		{"error toasts fail the run", toast.NewErrorToast("failed to send message"), "failed to send message"},
+		{"info toasts don't", toast.NewInfoToast("Session shared"), ""},
+		{"success toasts don't", toast.NewSuccessToast("Session deleted successfully"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			r := &runner{out: &output{format: FormatText, stdout: &stdout, stderr: &stderr}}
+			r.handle(tt.toast())
+			var failure string
+			if r.failure != nil {
+				failure = r.failure.Error()
+			}
+			if failure != tt.failure {
+				t.Errorf("failure is %q, want %q", failure, tt.failure)
+			}
+			if (stderr.Len() > 0) != (tt.failure != "") {
+				t.Errorf("stderr is %q", stderr.String())
+			}
+		})
+	}
+}
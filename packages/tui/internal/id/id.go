@@ -10,10 +10,11 @@ import (
 )
 
 const (
-	PrefixSession = "ses"
-	PrefixMessage = "msg"
-	PrefixUser    = "usr"
-	PrefixPart    = "prt"
+	PrefixSession    = "ses"
+	PrefixMessage    = "msg"
+	PrefixUser       = "usr"
+	PrefixPart       = "prt"
+	PrefixPermission = "per"
 )
 
 const length = 26
@@ -27,10 +28,11 @@ var (
 type Prefix string
 
 const (
-	Session Prefix = PrefixSession
-	Message Prefix = PrefixMessage
-	User    Prefix = PrefixUser
-	Part    Prefix = PrefixPart
+	Session    Prefix = PrefixSession
+	Message    Prefix = PrefixMessage
+	User       Prefix = PrefixUser
+	Part       Prefix = PrefixPart
+	Permission Prefix = PrefixPermission
 )
 
 func ValidatePrefix(id string, prefix Prefix) bool {
@@ -0,0 +1,178 @@
+// Package logbuffer keeps the most recent log records in memory so the TUI
+// can show its own logs. A Tee handler copies every record into a Ring on its
+// way to the real handler.
+package logbuffer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attr is a record attribute with its value already formatted. Attributes
+// inside groups have dotted keys.
+type Attr struct {
+	Key   string
+	Value string
+}
+
+// Record is a log record as kept in the ring.
+type Record struct {
+	Seq     uint64
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   []Attr
+}
+
+// String formats the record on one line.
+func (r Record) String() string {
+	var b strings.Builder
+	b.WriteString(r.Time.Format("15:04:05.000"))
+	b.WriteString(" ")
+	b.WriteString(r.Level.String())
+	b.WriteString(" ")
+	b.WriteString(r.Message)
+	for _, attr := range r.Attrs {
+		fmt.Fprintf(&b, " %s=%s", attr.Key, attr.Value)
+	}
+	return b.String()
+}
+
+// Matches reports whether query appears in the message or an attribute,
+// ignoring case.
+func (r Record) Matches(query string) bool {
+	if query == "" {
+		return true
+	}
+	query = strings.ToLower(query)
+	if strings.Contains(strings.ToLower(r.Message), query) {
+		return true
+	}
+	for _, attr := range r.Attrs {
+		if strings.Contains(strings.ToLower(attr.Key), query) ||
+			strings.Contains(strings.ToLower(attr.Value), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ring holds the last records added to it. It is safe for concurrent use.
+type Ring struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+	seq     uint64
+}
+
+// New returns a ring holding up to size records.
+func New(size int) *Ring {
+	return &Ring{records: make([]Record, max(size, 1))}
+}
+
+// Add stores a record, overwriting the oldest when the ring is full.
+func (r *Ring) Add(record Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	record.Seq = r.seq
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Records returns the stored records, oldest first.
+func (r *Ring) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Record(nil), r.records[:r.next]...)
+	}
+	records := make([]Record, 0, len(r.records))
+	records = append(records, r.records[r.next:]...)
+	return append(records, r.records[:r.next]...)
+}
+
+// Seq is the sequence number of the last record added, for noticing
+// changes without copying the records.
+func (r *Ring) Seq() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seq
+}
+
+type teeHandler struct {
+	next   slog.Handler
+	ring   *Ring
+	attrs  []Attr
+	prefix string
+}
+
+// Tee returns a handler that adds every record the next handler is enabled
+// for to ring before passing it on.
+func Tee(next slog.Handler, ring *Ring) slog.Handler {
+	return &teeHandler{next: next, ring: ring}
+}
+
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	record := Record{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   append([]Attr(nil), h.attrs...),
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		record.Attrs = appendAttr(record.Attrs, h.prefix, attr)
+		return true
+	})
+	h.ring.Add(record)
+	return h.next.Handle(ctx, r)
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append([]Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		clone.attrs = appendAttr(clone.attrs, h.prefix, attr)
+	}
+	return &clone
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func appendAttr(attrs []Attr, prefix string, attr slog.Attr) []Attr {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			attrs = appendAttr(attrs, prefix, member)
+		}
+		return attrs
+	}
+	return append(attrs, Attr{Key: prefix + attr.Key, Value: attr.Value.String()})
+}
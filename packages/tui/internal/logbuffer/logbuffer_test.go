@@ -0,0 +1,55 @@
+package logbuffer
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRingKeepsNewest(t *testing.T) {
+	ring := New(3)
+	for _, message := range []string{"a", "b", "c", "d", "e"} {
+		ring.Add(Record{Message: message})
+	}
+	var got []string
+	for _, record := range ring.Records() {
+		got = append(got, record.Message)
+	}
+	if strings.Join(got, "") != "cde" {
+		t.Errorf("records = %v, want c d e", got)
+	}
+	if ring.Seq() != 5 {
+		t.Errorf("seq = %d, want 5", ring.Seq())
+	}
+}
+
+func TestTeeCopiesRecords(t *testing.T) {
+	var out bytes.Buffer
+	ring := New(10)
+	logger := slog.New(Tee(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}), ring))
+
+	logger.Debug("hidden")
+	logger.With("session", "ses_1").WithGroup("req").Info("sent", "status", 200, slog.Group("timing", "ms", 12))
+
+	records := ring.Records()
+	if len(records) != 1 {
+		t.Fatalf("expected the debug record to be filtered like the next handler, got %d", len(records))
+	}
+	record := records[0]
+	want := []Attr{{"session", "ses_1"}, {"req.status", "200"}, {"req.timing.ms", "12"}}
+	if len(record.Attrs) != len(want) {
+		t.Fatalf("attrs = %v, want %v", record.Attrs, want)
+	}
+	for i := range want {
+		if record.Attrs[i] != want[i] {
+			t.Errorf("attr %d = %v, want %v", i, record.Attrs[i], want[i])
+		}
+	}
+	if !strings.Contains(out.String(), "msg=sent") {
+		t.Error("expected the record to reach the next handler")
+	}
+	if !record.Matches("SES_1") || !record.Matches("timing") || record.Matches("missing") {
+		t.Error("expected search to cover the message and attributes, ignoring case")
+	}
+}
@@ -0,0 +1,303 @@
+// Package logsink batches log records on their way to the server. Every
+// record is also appended to a rotating local file, so logs survive the
+// server being unreachable and can be read after a crash. Records the server
+// has not accepted are retried with backoff; what cannot be kept is dropped
+// and counted instead of blocking the caller.
+package logsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Entry is one log record.
+type Entry struct {
+	Time    time.Time      `json:"time"`
+	Level   slog.Level     `json:"level"`
+	Service string         `json:"service"`
+	Message string         `json:"message"`
+	Extra   map[string]any `json:"extra,omitempty"`
+}
+
+// Sender delivers a batch in order, returning how many entries were accepted
+// before any error.
+type Sender func(ctx context.Context, batch []Entry) (int, error)
+
+// Stats are cumulative counters for a Sink.
+type Stats struct {
+	Sent     int64
+	Dropped  int64 // never written anywhere
+	Unsent   int64 // written to the file but given up on for the server
+	Failures int64
+	Pending  int
+}
+
+// Options configure a Sink. Zero values use the defaults.
+type Options struct {
+	// Service names the sink's own records, such as drop reports.
+	Service string
+	// BatchSize entries trigger a flush before FlushInterval elapses.
+	BatchSize     int
+	FlushInterval time.Duration
+	// MaxPending bounds both the entries waiting to be flushed and those
+	// waiting to be retried.
+	MaxPending int
+	// File is the local log. Empty disables it.
+	File        string
+	MaxFileSize int64
+	MaxFiles    int
+}
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultMaxPending    = 10_000
+	defaultMaxFileSize   = 5 << 20
+	defaultMaxFiles      = 3
+	maxBackoff           = 30 * time.Second
+	closeTimeout         = 3 * time.Second
+)
+
+// Sink is safe for concurrent use.
+type Sink struct {
+	send Sender
+	opts Options
+	file *RotatingFile
+
+	mu            sync.Mutex
+	pending       []Entry
+	stats         Stats
+	reportedDrops int64
+	closed        bool
+
+	// flushMu serializes flushes; retry and backoff are only touched under it
+	flushMu      sync.Mutex
+	retry        []Entry
+	backoff      time.Duration
+	backoffUntil time.Time
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New starts a sink that flushes in the background until ctx is done or it
+// is closed. A local file that cannot be opened is reported and the sink
+// works without it.
+func New(ctx context.Context, send Sender, opts Options) (*Sink, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = defaultMaxPending
+	}
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = defaultMaxFileSize
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = defaultMaxFiles
+	}
+	s := &Sink{
+		send: send,
+		opts: opts,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	var err error
+	if opts.File != "" {
+		s.file, err = OpenRotatingFile(opts.File, opts.MaxFileSize, opts.MaxFiles)
+	}
+	go s.run(ctx)
+	return s, err
+}
+
+// Add queues an entry without blocking. After Close, entries only go to the
+// local file.
+func (s *Sink) Add(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		s.write([]Entry{entry})
+		return
+	}
+	if len(s.pending) >= s.opts.MaxPending {
+		s.stats.Dropped++
+		s.mu.Unlock()
+		return
+	}
+	s.pending = append(s.pending, entry)
+	full := len(s.pending) >= s.opts.BatchSize
+	s.mu.Unlock()
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Stats returns the sink's counters.
+func (s *Sink) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Pending = len(s.pending)
+	return stats
+}
+
+// Flush writes everything queued to the file and sends it, ignoring any
+// backoff from earlier failures.
+func (s *Sink) Flush(ctx context.Context) error {
+	return s.flush(ctx, true)
+}
+
+// Close stops the background flushing and makes a final flush, giving the
+// server a few seconds. It is safe to call more than once.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.stop)
+	<-s.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	err := s.Flush(ctx)
+
+	s.flushMu.Lock()
+	if n := len(s.retry); n > 0 {
+		s.mu.Lock()
+		s.stats.Unsent += int64(n)
+		s.mu.Unlock()
+		s.write([]Entry{s.report(slog.LevelWarn, "log records not sent before exit", "count", n)})
+		s.retry = nil
+	}
+	s.flushMu.Unlock()
+	return err
+}
+
+func (s *Sink) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		s.flush(ctx, false)
+	}
+}
+
+func (s *Sink) flush(ctx context.Context, force bool) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	dropped := s.stats.Dropped - s.reportedDrops
+	s.reportedDrops = s.stats.Dropped
+	s.mu.Unlock()
+	if dropped > 0 {
+		batch = append(batch, s.report(slog.LevelWarn, "dropped log records", "count", dropped))
+	}
+	s.write(batch)
+	s.retry = append(s.retry, batch...)
+	s.trimRetry()
+
+	if len(s.retry) == 0 || (!force && time.Now().Before(s.backoffUntil)) {
+		return nil
+	}
+	for len(s.retry) > 0 {
+		n := min(s.opts.BatchSize, len(s.retry))
+		sent, err := s.send(ctx, s.retry[:n])
+		sent = max(0, min(sent, n))
+		s.retry = s.retry[sent:]
+		s.mu.Lock()
+		s.stats.Sent += int64(sent)
+		if err != nil {
+			s.stats.Failures++
+		}
+		s.mu.Unlock()
+		if err != nil {
+			s.backoff = min(max(2*s.backoff, s.opts.FlushInterval), maxBackoff)
+			s.backoffUntil = time.Now().Add(s.backoff)
+			// reported to the file only; logging it would feed back into
+			// the sink
+			s.write([]Entry{s.report(slog.LevelError, "failed to send logs", "error", err.Error(), "retryIn", s.backoff.String())})
+			return err
+		}
+	}
+	s.backoff = 0
+	s.backoffUntil = time.Time{}
+	return nil
+}
+
+// trimRetry gives up on the oldest unsent entries beyond MaxPending. They
+// are already in the file.
+func (s *Sink) trimRetry() {
+	if over := len(s.retry) - s.opts.MaxPending; over > 0 {
+		s.retry = append([]Entry(nil), s.retry[over:]...)
+		s.mu.Lock()
+		s.stats.Unsent += int64(over)
+		s.mu.Unlock()
+	}
+}
+
+func (s *Sink) report(level slog.Level, message string, args ...any) Entry {
+	extra := map[string]any{}
+	for i := 0; i+1 < len(args); i += 2 {
+		extra[fmt.Sprint(args[i])] = args[i+1]
+	}
+	return Entry{Time: time.Now(), Level: level, Service: s.opts.Service, Message: message, Extra: extra}
+}
+
+// write appends entries to the local file as JSON lines.
+func (s *Sink) write(entries []Entry) {
+	if s.file == nil || len(entries) == 0 {
+		return
+	}
+	var buf []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			// extras that don't encode are written as text
+			extra := make(map[string]any, len(entry.Extra))
+			for key, value := range entry.Extra {
+				extra[key] = fmt.Sprint(value)
+			}
+			entry.Extra = extra
+			line, _ = json.Marshal(entry)
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	s.file.Write(buf)
+}
+
+// File is the path of the local log, or empty when there is none.
+func (s *Sink) File() string {
+	if s.file == nil {
+		return ""
+	}
+	return s.file.Path()
+}
@@ -0,0 +1,181 @@
+package logsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]Entry
+	fail    bool
+}
+
+func (r *recorder) send(_ context.Context, batch []Entry) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return 0, errors.New("server down")
+	}
+	r.batches = append(r.batches, append([]Entry(nil), batch...))
+	return len(batch), nil
+}
+
+func (r *recorder) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []string
+	for _, batch := range r.batches {
+		for _, entry := range batch {
+			messages = append(messages, entry.Message)
+		}
+	}
+	return messages
+}
+
+func readLines(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestFlushesBySize(t *testing.T) {
+	r := &recorder{}
+	sink, err := New(context.Background(), r.send, Options{BatchSize: 3, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	for _, message := range []string{"a", "b", "c"} {
+		sink.Add(Entry{Message: message})
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(r.messages()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a full batch to be sent, got %v", r.messages())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(r.batches) != 1 {
+		t.Errorf("expected one batch, got %d", len(r.batches))
+	}
+}
+
+func TestFlushesByTime(t *testing.T) {
+	r := &recorder{}
+	sink, _ := New(context.Background(), r.send, Options{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer sink.Close()
+	sink.Add(Entry{Message: "lonely"})
+	deadline := time.Now().Add(2 * time.Second)
+	for len(r.messages()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the interval to flush a partial batch")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestKeepsLogsWhileServerIsDown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log", "tui.log")
+	r := &recorder{fail: true}
+	sink, err := New(context.Background(), r.send, Options{Service: "tui", FlushInterval: time.Hour, File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Add(Entry{Level: slog.LevelError, Message: "crash"})
+	if err := sink.Flush(context.Background()); err == nil {
+		t.Fatal("expected the failed send to be reported")
+	}
+	entries := readLines(t, path)
+	if len(entries) != 2 || entries[0].Message != "crash" || entries[1].Message != "failed to send logs" {
+		t.Fatalf("expected the record and the failure in the file, got %+v", entries)
+	}
+
+	r.mu.Lock()
+	r.fail = false
+	r.mu.Unlock()
+	sink.Add(Entry{Message: "recovered"})
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.messages(); len(got) != 2 || got[0] != "crash" || got[1] != "recovered" {
+		t.Errorf("expected the failed record to be retried in order, got %v", got)
+	}
+	stats := sink.Stats()
+	if stats.Sent != 2 || stats.Failures != 1 || stats.Pending != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	sink.Add(Entry{Message: "after close"})
+	if entries := readLines(t, path); entries[len(entries)-1].Message != "after close" {
+		t.Error("expected records after Close to still reach the file")
+	}
+}
+
+func TestCountsDrops(t *testing.T) {
+	r := &recorder{}
+	sink, _ := New(context.Background(), r.send, Options{Service: "tui", BatchSize: 10, MaxPending: 2, FlushInterval: time.Hour})
+	for range 5 {
+		sink.Add(Entry{Message: "spam"})
+	}
+	if dropped := sink.Stats().Dropped; dropped != 3 {
+		t.Errorf("expected 3 drops, got %d", dropped)
+	}
+	sink.Close()
+	// the report counts against MaxPending too, pushing out the oldest record
+	got := r.messages()
+	if len(got) != 2 || got[1] != "dropped log records" {
+		t.Errorf("expected the drops to be reported, got %v", got)
+	}
+	if unsent := sink.Stats().Unsent; unsent != 1 {
+		t.Errorf("expected 1 record given up on, got %d", unsent)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tui.log")
+	file, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, want := range map[string]string{
+		"tui.log":   "fourth\n",
+		"tui.log.1": "third\n",
+		"tui.log.2": "second\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("expected only two rotated files to be kept")
+	}
+}
@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditFileName is the audit log's name under the state directory.
+const AuditFileName = "tui-permissions.log"
+
+// maxAuditEntries is how many decisions are kept in memory for review. The
+// log file itself is never truncated.
+const maxAuditEntries = 500
+
+// Decision is one permission request answered by a rule.
+type Decision struct {
+	Time         time.Time `json:"time"`
+	SessionID    string    `json:"sessionID"`
+	PermissionID string    `json:"permissionID"`
+	Tool         string    `json:"tool"`
+	Title        string    `json:"title"`
+	Command      string    `json:"command,omitempty"`
+	Path         string    `json:"path,omitempty"`
+	Action       Action    `json:"action"`
+	Rule         string    `json:"rule"`
+}
+
+// Audit is an append-only JSON lines log of automatic decisions.
+type Audit struct {
+	path    string
+	mu      sync.Mutex
+	entries []Decision
+}
+
+// OpenAudit reads the recent decisions from the log at path. A missing or
+// partly unreadable log is not an error; unreadable lines are skipped.
+func OpenAudit(path string) *Audit {
+	audit := &Audit{path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		return audit
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var decision Decision
+		if json.Unmarshal(scanner.Bytes(), &decision) == nil {
+			audit.entries = append(audit.entries, decision)
+		}
+	}
+	if len(audit.entries) > maxAuditEntries {
+		audit.entries = slices.Clone(audit.entries[len(audit.entries)-maxAuditEntries:])
+	}
+	return audit
+}
+
+// Record appends a decision to the log. The decision is kept for review even
+// when writing the file fails.
+func (a *Audit) Record(decision Decision) error {
+	if decision.Time.IsZero() {
+		decision.Time = time.Now()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, decision)
+	if len(a.entries) > maxAuditEntries {
+		a.entries = a.entries[1:]
+	}
+	if a.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Entries returns the recent decisions, newest first.
+func (a *Audit) Entries() []Decision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entries := slices.Clone(a.entries)
+	slices.Reverse(entries)
+	return entries
+}
+
+// Path is where the log is written.
+func (a *Audit) Path() string {
+	return a.path
+}
@@ -0,0 +1,284 @@
+// Package policy decides permission requests locally before the user is
+// asked. Rules come from a per-user and a per-project TOML file; the first
+// rule matching a request decides it, with the user's rules checked first.
+// Requests no rule matches are left for the user.
+//
+//	[[rule]]
+//	action = "allow"
+//	tool = "bash"
+//	command_regex = '(ls|git (status|diff|log))\b.*'
+//
+//	[[rule]]
+//	action = "deny"
+//	tool = "edit"
+//	path = "**/*.lock"
+//
+// A project checked out from elsewhere can't be trusted to allow anything, so
+// its file only adds deny and ask rules unless the user's file lists it:
+//
+//	trusted_projects = ["/home/me/src/opencode"]
+//
+// Bash commands that chain, pipe, substitute or redirect are never allowed
+// by a rule, as a rule written for the first command would also run the rest.
+// Neither are relative paths leading out of the project, like "../.env".
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	EnvFile  = "OPENCODE_PERMISSIONS_FILE"
+	FileName = "permissions.toml"
+)
+
+// Action is what a rule does with the requests it matches.
+type Action string
+
+const (
+	Allow Action = "allow"
+	Deny  Action = "deny"
+	Ask   Action = "ask"
+)
+
+// Request is the part of a permission request rules are matched against.
+type Request struct {
+	Tool string
+	// Command is the shell command of a bash request.
+	Command string
+	// Path is the file a request touches, relative to the project root when
+	// it is inside it.
+	Path string
+}
+
+// Rule matches requests on every field it sets. A rule with no conditions
+// but a tool matches every call of that tool.
+type Rule struct {
+	Action Action `toml:"action"`
+	// Tool is a tool name, or a glob such as "*" or "todo*".
+	Tool string `toml:"tool"`
+	// Command is a glob over the whole bash command, where * also matches
+	// spaces and slashes.
+	Command string `toml:"command"`
+	// CommandRegex is a regular expression matched against the whole bash
+	// command.
+	CommandRegex string `toml:"command_regex"`
+	// Path is a glob over the file path, where * stays within a directory and
+	// ** crosses directories.
+	Path string `toml:"path"`
+
+	source  string
+	tool    *regexp.Regexp
+	command *regexp.Regexp
+	regex   *regexp.Regexp
+	path    *regexp.Regexp
+}
+
+// Source is the file and position the rule was loaded from.
+func (r *Rule) Source() string {
+	return r.source
+}
+
+func (r *Rule) compile() error {
+	switch r.Action {
+	case Allow, Deny, Ask:
+	default:
+		return fmt.Errorf("unknown action %q: expected allow, deny or ask", r.Action)
+	}
+	if r.Tool == "" && r.Command == "" && r.CommandRegex == "" && r.Path == "" {
+		return errors.New("rule has no conditions")
+	}
+	var err error
+	if r.Tool != "" {
+		r.tool = compileGlob(r.Tool, false)
+	}
+	if r.Command != "" {
+		r.command = compileGlob(r.Command, false)
+	}
+	if r.CommandRegex != "" {
+		if r.regex, err = regexp.Compile(`^(?:` + r.CommandRegex + `)$`); err != nil {
+			return fmt.Errorf("invalid command_regex: %w", err)
+		}
+	}
+	if r.Path != "" {
+		r.path = compileGlob(r.Path, true)
+	}
+	return nil
+}
+
+// Matches reports whether every condition of the rule holds for req. Command
+// and path conditions never match requests without a command or path.
+func (r *Rule) Matches(req Request) bool {
+	if r.tool != nil && !r.tool.MatchString(req.Tool) {
+		return false
+	}
+	if r.command != nil && (req.Command == "" || !r.command.MatchString(req.Command)) {
+		return false
+	}
+	if r.regex != nil && (req.Command == "" || !r.regex.MatchString(req.Command)) {
+		return false
+	}
+	if r.path != nil && (req.Path == "" || !r.path.MatchString(req.Path)) {
+		return false
+	}
+	return true
+}
+
+// Policy is an ordered list of rules.
+type Policy struct {
+	Rules []*Rule
+	// Ignored are the allow rules of a project the user doesn't trust, which
+	// are never applied.
+	Ignored []*Rule
+}
+
+type file struct {
+	TrustedProjects []string `toml:"trusted_projects"`
+	Rules           []*Rule  `toml:"rule"`
+}
+
+// Load reads the user's policy file and then the policy file of the project
+// at root, skipping either if it doesn't exist. The project's allow rules
+// are only applied if the user's file trusts the project.
+func Load(userPath, root string) (*Policy, error) {
+	policy := &Policy{}
+	user, err := readFile(userPath)
+	if err != nil {
+		return nil, err
+	}
+	policy.Rules = append(policy.Rules, user.Rules...)
+
+	project, err := readFile(ProjectPath(root))
+	if err != nil {
+		return nil, err
+	}
+	trusted := slices.ContainsFunc(user.TrustedProjects, func(path string) bool {
+		return filepath.Clean(path) == filepath.Clean(root)
+	})
+	for _, rule := range project.Rules {
+		if rule.Action == Allow && !trusted {
+			policy.Ignored = append(policy.Ignored, rule)
+			continue
+		}
+		policy.Rules = append(policy.Rules, rule)
+	}
+	return policy, nil
+}
+
+// readFile reads and compiles a policy file. A missing file reads as empty.
+func readFile(path string) (*file, error) {
+	var f file
+	if path == "" {
+		return &f, nil
+	}
+	if _, err := toml.DecodeFile(path, &f); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &f, nil
+		}
+		return nil, fmt.Errorf("failed to read permission policy %s: %w", path, err)
+	}
+	for i, rule := range f.Rules {
+		rule.source = fmt.Sprintf("%s rule %d", path, i+1)
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("%s: %w", rule.source, err)
+		}
+	}
+	return &f, nil
+}
+
+// Evaluate returns the action of the first rule matching req, and the rule.
+// Allow rules are passed over for chained commands. Without a match it
+// returns Ask and a nil rule.
+func (p *Policy) Evaluate(req Request) (Action, *Rule) {
+	if p == nil {
+		return Ask, nil
+	}
+	for _, rule := range p.Rules {
+		if rule.Action == Allow && (Chained(req.Command) || Escapes(req.Path)) {
+			continue
+		}
+		if rule.Matches(req) {
+			return rule.Action, rule
+		}
+	}
+	return Ask, nil
+}
+
+// Chained reports whether a shell command runs more than one command or
+// redirects output: it contains a separator, pipe, background job, command
+// substitution, redirection or newline. Quoting is ignored, so some harmless
+// commands are reported too.
+func Chained(command string) bool {
+	return strings.ContainsAny(command, ";&|`>\n\r") ||
+		strings.Contains(command, "$(") ||
+		strings.Contains(command, "<(")
+}
+
+// Escapes reports whether a relative, slash-separated path leads out of the
+// directory it is relative to, like "../secrets" does.
+func Escapes(path string) bool {
+	return path == ".." || strings.HasPrefix(path, "../")
+}
+
+// UserPath is the per-user policy file, or the file named by
+// OPENCODE_PERMISSIONS_FILE.
+func UserPath() string {
+	if path := os.Getenv(EnvFile); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "opencode", FileName)
+}
+
+// ProjectPath is the policy file checked into a project.
+func ProjectPath(root string) string {
+	if root == "" {
+		return ""
+	}
+	return filepath.Join(root, ".opencode", FileName)
+}
+
+// compileGlob turns a glob into an anchored regular expression. For paths,
+// * and ? stop at slashes and ** matches across them; otherwise * matches
+// anything.
+func compileGlob(glob string, path bool) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && path && i+1 < len(glob) && glob[i+1] == '*':
+			i++
+			if i+1 < len(glob) && glob[i+1] == '/' {
+				// "**/" also matches no directories at all
+				i++
+				b.WriteString("(?:.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*' && path:
+			b.WriteString("[^/]*")
+		case c == '*':
+			b.WriteString(".*")
+		case c == '?' && path:
+			b.WriteString("[^/]")
+		case c == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
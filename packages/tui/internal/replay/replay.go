@@ -0,0 +1,276 @@
+// Package replay opens exported transcripts in the chat view and plays them
+// back with their original timing, so rendering can be demoed or debugged
+// without a live session.
+package replay
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/exporter"
+)
+
+const (
+	DefaultMaxGap = 3 * time.Second
+	// textChunks is how many steps a text part is revealed over.
+	textChunks = 8
+)
+
+type Options struct {
+	// Speed scales the original timing; 2 plays twice as fast.
+	Speed float64
+	// MaxGap caps idle time between steps, before Speed is applied.
+	MaxGap time.Duration
+}
+
+// LoadedMsg opens a transcript read-only. Replay is nil when the transcript
+// is shown all at once.
+type LoadedMsg struct {
+	Path       string
+	Transcript exporter.Transcript
+	Replay     *Player
+}
+
+// Load reads the JSON transcript at path. When replay is set the messages are
+// played back with opts instead of shown at once.
+func Load(path string, replay bool, opts Options) tea.Cmd {
+	return func() tea.Msg {
+		transcript, err := exporter.ReadFile(path)
+		if err != nil {
+			return toast.NewErrorToast(err.Error(), toast.WithTitle("Import failed"))()
+		}
+		msg := LoadedMsg{Path: path, Transcript: transcript}
+		if replay {
+			msg.Replay = NewPlayer(Timeline(transcript.Messages, opts))
+		}
+		return msg
+	}
+}
+
+// Step adds a message or updates one part of it.
+type Step struct {
+	Delay     time.Duration
+	Info      opencode.MessageUnion
+	MessageID string
+	Part      opencode.PartUnion
+}
+
+// Apply performs the step on a.
+func (s Step) Apply(a *app.App) {
+	if s.Info != nil {
+		a.UpsertMessage(s.Info)
+	}
+	if s.Part != nil {
+		a.UpsertPart(s.MessageID, s.Part)
+	}
+}
+
+type timedStep struct {
+	at    float64
+	order int
+	step  Step
+}
+
+// Timeline turns messages into steps ordered by their original timestamps.
+// Text and reasoning are revealed in chunks and tools go from running to
+// their final state, as they would have streamed in. Parts without their own
+// timestamps inherit the one before them.
+func Timeline(messages []app.Message, opts Options) []Step {
+	var timed []timedStep
+	add := func(at float64, step Step) {
+		timed = append(timed, timedStep{at: at, order: len(timed), step: step})
+	}
+
+	for _, message := range messages {
+		created, completed := messageTimes(message.Info)
+		add(created, Step{Info: inProgress(message.Info)})
+		cursor := created
+		id := message.ID()
+
+		for _, part := range message.Parts {
+			switch p := part.(type) {
+			case opencode.TextPart:
+				start, end := later(cursor, p.Time.Start), p.Time.End
+				for _, chunk := range chunks(p.Text, start, end) {
+					partial := p
+					partial.Text = chunk.text
+					add(chunk.at, Step{MessageID: id, Part: partial})
+					cursor = max(cursor, chunk.at)
+				}
+			case opencode.ReasoningPart:
+				start, end := later(cursor, p.Time.Start), p.Time.End
+				for _, chunk := range chunks(p.Text, start, end) {
+					partial := p
+					partial.Text = chunk.text
+					add(chunk.at, Step{MessageID: id, Part: partial})
+					cursor = max(cursor, chunk.at)
+				}
+			case opencode.ToolPart:
+				start, end := toolTimes(p.State)
+				start = later(cursor, start)
+				if end > start {
+					running := p
+					running.State.Status = opencode.ToolPartStateStatus("running")
+					running.State.Output = ""
+					running.State.Error = ""
+					add(start, Step{MessageID: id, Part: running})
+				}
+				end = max(end, start)
+				add(end, Step{MessageID: id, Part: p})
+				cursor = end
+			case opencode.StepFinishPart:
+				if completed > cursor {
+					cursor = completed
+				}
+				add(cursor, Step{MessageID: id, Part: p})
+			default:
+				add(cursor, Step{MessageID: id, Part: p})
+			}
+		}
+		if completed > 0 {
+			// the final message update carries the completion time and cost
+			add(max(cursor, completed), Step{Info: message.Info})
+		}
+	}
+
+	slices.SortStableFunc(timed, func(a, b timedStep) int {
+		switch {
+		case a.at < b.at:
+			return -1
+		case a.at > b.at:
+			return 1
+		}
+		return a.order - b.order
+	})
+
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	maxGap := opts.MaxGap
+	if maxGap <= 0 {
+		maxGap = DefaultMaxGap
+	}
+
+	steps := make([]Step, 0, len(timed))
+	var previous float64
+	for i, t := range timed {
+		if i > 0 && t.at > previous {
+			gap := min(time.Duration(t.at-previous)*time.Millisecond, maxGap)
+			t.step.Delay = time.Duration(float64(gap) / speed)
+		}
+		previous = max(previous, t.at)
+		steps = append(steps, t.step)
+	}
+	return steps
+}
+
+type chunk struct {
+	at   float64
+	text string
+}
+
+// chunks reveals text in up to textChunks growing prefixes spread between
+// start and end, split on word boundaries.
+func chunks(text string, start, end float64) []chunk {
+	words := strings.SplitAfter(text, " ")
+	n := min(textChunks, len(words))
+	if n <= 1 || end <= start {
+		return []chunk{{at: max(start, end), text: text}}
+	}
+	result := make([]chunk, 0, n)
+	for i := 1; i <= n; i++ {
+		cut := len(words) * i / n
+		result = append(result, chunk{
+			at:   start + (end-start)*float64(i)/float64(n),
+			text: strings.Join(words[:cut], ""),
+		})
+	}
+	return result
+}
+
+func later(cursor, at float64) float64 {
+	if at == 0 {
+		return cursor
+	}
+	return max(cursor, at)
+}
+
+func messageTimes(info opencode.MessageUnion) (created, completed float64) {
+	switch casted := info.(type) {
+	case opencode.UserMessage:
+		return casted.Time.Created, 0
+	case opencode.AssistantMessage:
+		return casted.Time.Created, casted.Time.Completed
+	}
+	return 0, 0
+}
+
+// inProgress returns info as it looked before the assistant finished.
+func inProgress(info opencode.MessageUnion) opencode.MessageUnion {
+	if assistant, ok := info.(opencode.AssistantMessage); ok {
+		assistant.Time.Completed = 0
+		return assistant
+	}
+	return info
+}
+
+func toolTimes(state opencode.ToolPartState) (start, end float64) {
+	switch t := state.Time.(type) {
+	case opencode.ToolStateRunningTime:
+		return t.Start, 0
+	case opencode.ToolStateCompletedTime:
+		return t.Start, t.End
+	case opencode.ToolStateErrorTime:
+		return t.Start, t.End
+	case map[string]any:
+		start, _ = t["start"].(float64)
+		end, _ = t["end"].(float64)
+	}
+	return start, end
+}
+
+var players atomic.Int64
+
+// Player delivers a timeline one StepMsg at a time. Messages from a player
+// that has been replaced carry a stale ID and should be ignored.
+type Player struct {
+	ID    int64
+	steps []Step
+	next  int
+}
+
+type StepMsg struct {
+	PlayerID int64
+	Step     Step
+	Done     bool
+}
+
+func NewPlayer(steps []Step) *Player {
+	return &Player{ID: players.Add(1), steps: steps}
+}
+
+// Next schedules the next step, or returns nil when the timeline is over.
+func (p *Player) Next() tea.Cmd {
+	if p == nil || p.next >= len(p.steps) {
+		return nil
+	}
+	step := p.steps[p.next]
+	p.next++
+	msg := StepMsg{PlayerID: p.ID, Step: step, Done: p.next == len(p.steps)}
+	if step.Delay <= 0 {
+		return func() tea.Msg { return msg }
+	}
+	return tea.Tick(step.Delay, func(time.Time) tea.Msg { return msg })
+}
+
+// Progress returns how many steps have been played and the total.
+func (p *Player) Progress() (int, int) {
+	return p.next, len(p.steps)
+}
@@ -0,0 +1,77 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+)
+
+func TestTimelineFollowsOriginalTiming(t *testing.T) {
+	messages := []app.Message{
+		{
+			Info: opencode.UserMessage{ID: "msg_1", Time: opencode.UserMessageTime{Created: 1000}},
+			Parts: []opencode.PartUnion{
+				opencode.TextPart{ID: "prt_1", MessageID: "msg_1", Text: "hi"},
+			},
+		},
+		{
+			Info: opencode.AssistantMessage{ID: "msg_2", Time: opencode.AssistantMessageTime{Created: 2000, Completed: 60000}},
+			Parts: []opencode.PartUnion{
+				opencode.ToolPart{ID: "prt_2", MessageID: "msg_2", Tool: "bash", State: opencode.ToolPartState{
+					Status: opencode.ToolPartStateStatus("completed"),
+					Output: "ok",
+					Time:   opencode.ToolStateCompletedTime{Start: 2500, End: 3000},
+				}},
+				opencode.StepFinishPart{ID: "prt_3", MessageID: "msg_2"},
+			},
+		},
+	}
+
+	steps := Timeline(messages, Options{Speed: 2})
+	if len(steps) != 7 {
+		t.Fatalf("expected 7 steps, got %d", len(steps))
+	}
+
+	first, ok := steps[2].Info.(opencode.AssistantMessage)
+	if !ok || first.Time.Completed != 0 || steps[2].Delay != 500*time.Millisecond {
+		t.Errorf("assistant should start unfinished after a halved 1s gap: %+v", steps[2])
+	}
+	running := steps[3].Part.(opencode.ToolPart)
+	if running.State.Status != "running" || running.State.Output != "" {
+		t.Errorf("tool should first appear running: %+v", running.State)
+	}
+	if done := steps[4].Part.(opencode.ToolPart); done.State.Output != "ok" || steps[4].Delay != 250*time.Millisecond {
+		t.Errorf("tool should complete 500ms later at double speed: %+v", steps[4])
+	}
+	if steps[5].Delay != DefaultMaxGap/2 {
+		t.Errorf("long idle gaps should be capped, got %s", steps[5].Delay)
+	}
+	if final := steps[6].Info.(opencode.AssistantMessage); final.Time.Completed != 60000 {
+		t.Errorf("last step should finish the assistant message: %+v", final)
+	}
+}
+
+func TestChunksRevealTextGradually(t *testing.T) {
+	result := chunks("one two three four", 0, 400)
+	if len(result) != 4 || result[0].text != "one " || result[3].text != "one two three four" || result[3].at != 400 {
+		t.Errorf("unexpected chunks: %+v", result)
+	}
+	if result := chunks("single", 100, 0); len(result) != 1 || result[0].at != 100 {
+		t.Errorf("untimed text should appear at once: %+v", result)
+	}
+}
+
+func TestPlayerIgnoresFinishedTimeline(t *testing.T) {
+	player := NewPlayer([]Step{{}})
+	if player.Next() == nil {
+		t.Fatal("expected a step")
+	}
+	if player.Next() != nil {
+		t.Error("expected no more steps")
+	}
+	if other := NewPlayer(nil); other.ID == player.ID {
+		t.Error("players should have distinct IDs")
+	}
+}
@@ -0,0 +1,87 @@
+// Package search matches conversation search queries against message text.
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Query is a search as typed in the search bar.
+type Query struct {
+	Text string
+	// Regex treats Text as a regular expression instead of a literal string
+	Regex bool
+	// CaseSensitive disables the default case-insensitive matching
+	CaseSensitive bool
+}
+
+// Compile returns the pattern for the query, or nil when the query is empty.
+func (q Query) Compile() (*regexp.Regexp, error) {
+	if q.Text == "" {
+		return nil, nil
+	}
+	pattern := q.Text
+	if !q.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !q.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// Matches returns the byte ranges of the matches in s. Empty matches, which
+// patterns such as `a*` produce everywhere, are left out.
+func Matches(re *regexp.Regexp, s string) [][]int {
+	if re == nil {
+		return nil
+	}
+	var matches [][]int
+	for _, match := range re.FindAllStringIndex(s, -1) {
+		if match[1] > match[0] {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// Lines returns the zero-based line each match starts on.
+func Lines(s string, matches [][]int) []int {
+	lines := make([]int, len(matches))
+	line, offset := 0, 0
+	for i, match := range matches {
+		line += strings.Count(s[offset:match[0]], "\n")
+		offset = match[0]
+		lines[i] = line
+	}
+	return lines
+}
+
+// Document is the searchable text of one message: its rendered text and the
+// inputs and outputs of its tool calls, in display order.
+type Document struct {
+	ID    string
+	Texts []string
+}
+
+// Hit is one match, identified by the document it is in and its position
+// among that document's matches.
+type Hit struct {
+	ID         string
+	Occurrence int
+}
+
+// Hits returns every match of re across the documents, in order.
+func Hits(re *regexp.Regexp, documents []Document) []Hit {
+	var hits []Hit
+	for _, document := range documents {
+		occurrence := 0
+		for _, text := range document.Texts {
+			for range Matches(re, text) {
+				hits = append(hits, Hit{ID: document.ID, Occurrence: occurrence})
+				occurrence++
+			}
+		}
+	}
+	return hits
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryCompile(t *testing.T) {
+	tests := []struct {
+		query Query
+		text  string
+		want  int
+	}{
+		{Query{Text: "main.go"}, "Main.go and mainXgo", 1},
+		{Query{Text: "main.go", Regex: true}, "Main.go and mainXgo", 2},
+		{Query{Text: "Error", CaseSensitive: true}, "error Error ERROR", 1},
+		{Query{Text: "e*", Regex: true}, "bee", 1},
+		{Query{}, "anything", 0},
+	}
+	for _, test := range tests {
+		re, err := test.query.Compile()
+		if err != nil {
+			t.Fatalf("%+v: %v", test.query, err)
+		}
+		if got := len(Matches(re, test.text)); got != test.want {
+			t.Errorf("%+v on %q: %d matches, want %d", test.query, test.text, got, test.want)
+		}
+	}
+	if _, err := (Query{Text: "(", Regex: true}).Compile(); err == nil {
+		t.Error("expected an invalid pattern to fail")
+	}
+}
+
+func TestLines(t *testing.T) {
+	text := "one\ntwo two\n\nthree two"
+	re, _ := Query{Text: "two"}.Compile()
+	if got := Lines(text, Matches(re, text)); !reflect.DeepEqual(got, []int{1, 1, 3}) {
+		t.Errorf("Lines = %v", got)
+	}
+}
+
+func TestHits(t *testing.T) {
+	re, _ := Query{Text: "foo"}.Compile()
+	hits := Hits(re, []Document{
+		{ID: "a", Texts: []string{"foo", "bar foo"}},
+		{ID: "b", Texts: []string{"nothing"}},
+		{ID: "c", Texts: []string{"FOO"}},
+	})
+	want := []Hit{{"a", 0}, {"a", 1}, {"c", 0}}
+	if !reflect.DeepEqual(hits, want) {
+		t.Errorf("Hits = %v, want %v", hits, want)
+	}
+}
@@ -0,0 +1,310 @@
+package testserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sst/opencode/internal/id"
+)
+
+// Reply is what the scripted assistant answers to one prompt. Parts are sent
+// in the order reasoning, tools, text.
+type Reply struct {
+	Reasoning string
+	Tools     []Tool
+	Text      string
+	// Error fails the assistant message with this message instead of
+	// finishing it.
+	Error string
+	// Chunks streams the text over this many part updates. Zero sends it
+	// whole.
+	Chunks int
+	// Title renames the session after the reply, as the server's title
+	// generation would.
+	Title string
+}
+
+// Tool is a tool call made while answering.
+type Tool struct {
+	Name     string
+	Input    map[string]any
+	Output   string
+	Title    string
+	Metadata map[string]any
+	// Error fails the call with this message.
+	Error string
+	// Permission announces a permission request with this title before the
+	// call runs, with the input as its metadata. The call runs whatever the
+	// answer.
+	Permission string
+}
+
+// Text returns a reply that only answers with text.
+func Text(text string) Reply {
+	return Reply{Text: text}
+}
+
+func (s *Server) nextReply(prompt string) Reply {
+	if s.Respond != nil {
+		return s.Respond(prompt)
+	}
+	if len(s.replies) > 0 {
+		reply := s.replies[0]
+		s.replies = s.replies[1:]
+		return reply
+	}
+	return Text("You said: " + prompt)
+}
+
+type promptBody struct {
+	MessageID string `json:"messageID"`
+	Agent     string `json:"agent"`
+	Model     struct {
+		ProviderID string `json:"providerID"`
+		ModelID    string `json:"modelID"`
+	} `json:"model"`
+	Parts []Part `json:"parts"`
+}
+
+func (s *Server) prompt(w http.ResponseWriter, r *http.Request, session *Session) {
+	var body promptBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	user := s.addUserMessage(session, body.MessageID, body.Parts)
+	var text []string
+	for _, part := range user.Parts {
+		if part.Type == "text" && !part.Synthetic {
+			text = append(text, part.Text)
+		}
+	}
+	prompt := strings.Join(text, "\n")
+	writeJSON(w, s.answer(session, body.Agent, body.Model.ProviderID, body.Model.ModelID, s.nextReply(prompt)))
+	s.titleFrom(session, prompt)
+}
+
+func (s *Server) command(w http.ResponseWriter, r *http.Request, session *Session) {
+	var body struct {
+		MessageID string `json:"messageID"`
+		Agent     string `json:"agent"`
+		Model     string `json:"model"`
+		Command   string `json:"command"`
+		Arguments string `json:"arguments"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	prompt := strings.TrimSpace("/" + body.Command + " " + body.Arguments)
+	s.addUserMessage(session, body.MessageID, []Part{{Type: "text", Text: prompt}})
+	providerID, modelID, _ := strings.Cut(body.Model, "/")
+	writeJSON(w, s.answer(session, body.Agent, providerID, modelID, s.nextReply(prompt)))
+	s.titleFrom(session, prompt)
+}
+
+func (s *Server) shell(w http.ResponseWriter, r *http.Request, session *Session) {
+	var body struct {
+		Agent   string `json:"agent"`
+		Command string `json:"command"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	s.addUserMessage(session, "", []Part{{
+		Type:      "text",
+		Text:      "The following tool was executed by the user",
+		Synthetic: true,
+	}})
+	output := "$ " + body.Command
+	if s.ShellOutput != nil {
+		output = s.ShellOutput(body.Command)
+	}
+	reply := Reply{Tools: []Tool{{
+		Name:     "bash",
+		Input:    map[string]any{"command": body.Command},
+		Output:   output,
+		Title:    body.Command,
+		Metadata: map[string]any{"output": output},
+	}}}
+	response := s.answer(session, body.Agent, "", "", reply)
+	writeJSON(w, response.Info)
+}
+
+func (s *Server) addUserMessage(session *Session, messageID string, parts []Part) messageWithParts {
+	if messageID == "" {
+		messageID = id.Ascending(id.Message)
+	}
+	message := messageWithParts{Info: Message{
+		ID:        messageID,
+		SessionID: session.ID,
+		Role:      "user",
+		Time:      MessageTime{Created: s.now()},
+	}}
+	s.publish("message.updated", map[string]any{"info": message.Info})
+	for _, part := range parts {
+		part.ID = id.Ascending(id.Part)
+		part.SessionID = session.ID
+		part.MessageID = messageID
+		message.Parts = append(message.Parts, part)
+		s.publish("message.part.updated", map[string]any{"part": part})
+	}
+	s.messages[session.ID] = append(s.messages[session.ID], message)
+	return message
+}
+
+// answer streams reply as an assistant message the way the server does: the
+// message is announced, each part is sent as it grows, and the message is
+// updated once more when it completes.
+func (s *Server) answer(session *Session, agent, providerID, modelID string, reply Reply) messageWithParts {
+	if agent == "" {
+		agent = "build"
+	}
+	if providerID == "" && len(s.Providers.Providers) > 0 {
+		providerID = s.Providers.Providers[0].ID
+		modelID = s.Providers.Default[providerID]
+	}
+	info := Message{
+		ID:        id.Ascending(id.Message),
+		SessionID: session.ID,
+		Role:      "assistant",
+		Time:      MessageTime{Created: s.now()},
+		AssistantFields: &AssistantFields{
+			ModelID:    modelID,
+			ProviderID: providerID,
+			Mode:       agent,
+			Path:       &MessagePath{Cwd: s.Project.Worktree, Root: s.Project.Worktree},
+			System:     []string{},
+			Tokens:     &Tokens{},
+		},
+	}
+	message := messageWithParts{Info: info}
+	s.messages[session.ID] = append(s.messages[session.ID], message)
+	index := len(s.messages[session.ID]) - 1
+	s.publish("message.updated", map[string]any{"info": info})
+
+	send := func(part Part) {
+		message.Parts = upsertPart(message.Parts, part)
+		s.messages[session.ID][index] = message
+		s.publish("message.part.updated", map[string]any{"part": part})
+	}
+	newPart := func(partType string) Part {
+		return Part{ID: id.Ascending(id.Part), SessionID: session.ID, MessageID: info.ID, Type: partType}
+	}
+
+	send(newPart("step-start"))
+	if reply.Reasoning != "" {
+		part := newPart("reasoning")
+		part.Text = reply.Reasoning
+		part.Time = &PartTime{Start: s.now(), End: s.now()}
+		send(part)
+	}
+	for _, tool := range reply.Tools {
+		part := newPart("tool")
+		part.Tool = tool.Name
+		part.CallID = "call_" + part.ID
+		if tool.Permission != "" {
+			s.askPermission(Permission{
+				Type:      tool.Name,
+				SessionID: session.ID,
+				MessageID: info.ID,
+				CallID:    part.CallID,
+				Title:     tool.Permission,
+				Metadata:  tool.Input,
+			})
+		}
+		start := s.now()
+		part.State = &ToolState{Status: "running", Input: tool.Input, Title: tool.Title, Time: &PartTime{Start: start}}
+		send(part)
+		state := &ToolState{
+			Status:   "completed",
+			Input:    tool.Input,
+			Output:   tool.Output,
+			Title:    tool.Title,
+			Metadata: tool.Metadata,
+			Time:     &PartTime{Start: start, End: s.now()},
+		}
+		if tool.Error != "" {
+			state.Status = "error"
+			state.Output = ""
+			state.Error = tool.Error
+		}
+		if state.Metadata == nil {
+			state.Metadata = map[string]any{}
+		}
+		part.State = state
+		send(part)
+	}
+	if reply.Text != "" {
+		part := newPart("text")
+		part.Time = &PartTime{Start: s.now()}
+		for _, chunk := range chunks(reply.Text, reply.Chunks) {
+			part.Text = chunk
+			send(part)
+		}
+		part.Time.End = s.now()
+		send(part)
+	}
+
+	tokens := Tokens{Input: float64(10 * (index + 1)), Output: float64(len(strings.Fields(reply.Text)))}
+	if reply.Error != "" {
+		info.Error = map[string]any{
+			"name": "UnknownError",
+			"data": map[string]any{"message": reply.Error},
+		}
+		s.publish("session.error", map[string]any{"sessionID": session.ID, "error": info.Error})
+	} else {
+		finish := newPart("step-finish")
+		finish.Cost = new(float64)
+		finish.Tokens = &tokens
+		send(finish)
+	}
+	info.Tokens = &tokens
+	info.Time.Completed = s.now()
+	message.Info = info
+	s.messages[session.ID][index] = message
+	s.publish("message.updated", map[string]any{"info": info})
+	s.publish("session.idle", map[string]any{"sessionID": session.ID})
+
+	if reply.Title != "" {
+		session.Title = reply.Title
+		s.touch(session)
+	}
+	return message
+}
+
+// titleFrom names a session after its first prompt, like the server's title
+// generation does, unless a reply already set one.
+func (s *Server) titleFrom(session *Session, prompt string) {
+	if !strings.HasPrefix(session.Title, "New session - ") || prompt == "" {
+		return
+	}
+	title, _, _ := strings.Cut(prompt, "\n")
+	if len(title) > 50 {
+		title = title[:50]
+	}
+	session.Title = title
+	s.touch(session)
+}
+
+func upsertPart(parts []Part, part Part) []Part {
+	for i := range parts {
+		if parts[i].ID == part.ID {
+			parts[i] = part
+			return parts
+		}
+	}
+	return append(parts, part)
+}
+
+// chunks splits text into n growing prefixes on word boundaries.
+func chunks(text string, n int) []string {
+	words := strings.SplitAfter(text, " ")
+	if n <= 1 || len(words) == 1 {
+		return []string{text}
+	}
+	n = min(n, len(words))
+	prefixes := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		prefixes = append(prefixes, strings.Join(words[:len(words)*i/n], ""))
+	}
+	return prefixes
+}
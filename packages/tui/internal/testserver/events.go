@@ -0,0 +1,87 @@
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+type event struct {
+	id   int
+	data []byte
+}
+
+// Publish sends an event to every open stream, as the server's bus would.
+func (s *Server) Publish(eventType string, properties any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.publish(eventType, properties)
+}
+
+// publish must be called with the server locked. Events are kept so streams
+// that reconnect with Last-Event-ID are replayed what they missed.
+func (s *Server) publish(eventType string, properties any) {
+	data, err := json.Marshal(map[string]any{"type": eventType, "properties": properties})
+	if err != nil {
+		panic(fmt.Sprintf("testserver: encode %s event: %v", eventType, err))
+	}
+	e := event{id: len(s.events) + 1, data: data}
+	s.events = append(s.events, e)
+	for ch := range s.subscribers {
+		select {
+		case ch <- e:
+		default:
+			// a stream that stopped reading is dropped, and has to resume
+			close(ch)
+			delete(s.subscribers, ch)
+		}
+	}
+}
+
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ch := make(chan event, 1024)
+	s.mu.Lock()
+	var backlog []event
+	if last, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && last < len(s.events) {
+		backlog = append(backlog, s.events[last:]...)
+	}
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+		s.mu.Unlock()
+	}()
+
+	fmt.Fprintf(w, "data: %s\n\n", `{"type":"server.connected","properties":{}}`)
+	for _, e := range backlog {
+		fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.id, e.data)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.id, e.data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,479 @@
+// Package testserver is an in-memory opencode server for end-to-end tests. It
+// serves the session, message, file, find, agent, config and event endpoints
+// the TUI uses, answers prompts with scripted assistant replies streamed as
+// events, and records every request it receives.
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/opencode/internal/id"
+)
+
+// Request is one call the server received.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   []byte
+}
+
+// Server holds the fake's state. Exported fields configure the responses and
+// must be set before the client makes its first request.
+type Server struct {
+	Project   Project
+	Path      Path
+	Config    map[string]any
+	Agents    []Agent
+	Providers Providers
+	Commands  []Command
+	Files     []string
+	Status    []FileStatus
+	Symbols   []Symbol
+	// Respond picks the reply to each prompt. The default answers from the
+	// queue filled by Reply, then echoes the prompt.
+	Respond func(prompt string) Reply
+	// ShellOutput produces the output of shell commands run with "!".
+	ShellOutput func(command string) string
+	// Now stamps created and completed times. It defaults to a fixed clock
+	// advancing a second per call so snapshots are stable.
+	Now func() time.Time
+
+	http *httptest.Server
+
+	mu          sync.Mutex
+	requests    []Request
+	sessions    []Session
+	messages    map[string][]messageWithParts
+	replies     []Reply
+	permissions map[string]Permission
+	clock       time.Time
+
+	events      []event
+	subscribers map[chan event]struct{}
+}
+
+// New starts a server with one project, a build agent and one provider. Close
+// it when done.
+func New() *Server {
+	s := &Server{
+		Project: Project{ID: "prj_test", Worktree: "/project", VCS: "git"},
+		Path:    Path{Worktree: "/project", Directory: "/project"},
+		Config:  map[string]any{"keybinds": map[string]any{"leader": "ctrl+x"}},
+		Agents: []Agent{
+			{Name: "build", Mode: "primary", BuiltIn: true},
+			{Name: "plan", Mode: "primary", BuiltIn: true},
+			{Name: "general", Description: "General-purpose agent", Mode: "subagent", BuiltIn: true},
+		},
+		Providers: Providers{
+			Providers: []Provider{{
+				ID:   "anthropic",
+				Name: "Anthropic",
+				Models: map[string]Model{
+					"claude-sonnet": {
+						ID:       "claude-sonnet",
+						Name:     "Claude Sonnet",
+						ToolCall: true,
+						Cost:     ModelCost{Input: 3, Output: 15},
+						Limit:    ModelLimit{Context: 200000, Output: 8192},
+					},
+				},
+			}},
+			Default: map[string]string{"anthropic": "claude-sonnet"},
+		},
+		messages:    map[string][]messageWithParts{},
+		permissions: map[string]Permission{},
+		subscribers: map[chan event]struct{}{},
+		clock:       time.UnixMilli(1_700_000_000_000),
+	}
+	s.http = httptest.NewServer(s.routes())
+	return s
+}
+
+// URL is the base URL clients should use.
+func (s *Server) URL() string {
+	return s.http.URL
+}
+
+// Close disconnects event streams and stops the server.
+func (s *Server) Close() {
+	s.mu.Lock()
+	for ch := range s.subscribers {
+		close(ch)
+		delete(s.subscribers, ch)
+	}
+	s.mu.Unlock()
+	s.http.Close()
+}
+
+// Requests returns the calls received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// Reply queues replies for the next prompts, in order.
+func (s *Server) Reply(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies = append(s.replies, replies...)
+}
+
+// AddSession creates a session without going through the API.
+func (s *Server) AddSession(title string) Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newSession(title, "")
+}
+
+// Sessions returns the current sessions, newest first.
+func (s *Server) Sessions() []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.sessions)
+}
+
+// Messages returns the messages of a session, oldest first.
+func (s *Server) Messages(sessionID string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var infos []Message
+	for _, message := range s.messages[sessionID] {
+		infos = append(infos, message.Info)
+	}
+	return infos
+}
+
+func (s *Server) now() float64 {
+	if s.Now != nil {
+		return float64(s.Now().UnixMilli())
+	}
+	s.clock = s.clock.Add(time.Second)
+	return float64(s.clock.UnixMilli())
+}
+
+func (s *Server) newSession(title, parentID string) Session {
+	now := s.now()
+	if title == "" {
+		title = "New session - " + time.UnixMilli(int64(now)).UTC().Format(time.RFC3339)
+	}
+	session := Session{
+		ID:        id.Descending(id.Session),
+		ProjectID: s.Project.ID,
+		Directory: s.Project.Worktree,
+		ParentID:  parentID,
+		Title:     title,
+		Version:   "test",
+		Time:      SessionTime{Created: now, Updated: now},
+	}
+	s.sessions = slices.Insert(s.sessions, 0, session)
+	return session
+}
+
+func (s *Server) session(sessionID string) (*Session, bool) {
+	index := slices.IndexFunc(s.sessions, func(session Session) bool {
+		return session.ID == sessionID
+	})
+	if index < 0 {
+		return nil, false
+	}
+	return &s.sessions[index], true
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	get := func(value func() any) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			v := value()
+			s.mu.Unlock()
+			writeJSON(w, v)
+		}
+	}
+
+	mux.HandleFunc("GET /project/current", get(func() any { return s.Project }))
+	mux.HandleFunc("GET /project", get(func() any { return []Project{s.Project} }))
+	mux.HandleFunc("GET /path", get(func() any { return s.Path }))
+	mux.HandleFunc("GET /config", get(func() any { return s.Config }))
+	mux.HandleFunc("GET /config/providers", get(func() any { return s.Providers }))
+	mux.HandleFunc("GET /agent", get(func() any { return s.Agents }))
+	mux.HandleFunc("GET /command", get(func() any { return orEmpty(s.Commands) }))
+	mux.HandleFunc("GET /file/status", get(func() any { return orEmpty(s.Status) }))
+	mux.HandleFunc("GET /find/file", s.findFiles)
+	mux.HandleFunc("GET /find/symbol", s.findSymbols)
+	mux.HandleFunc("POST /log", get(func() any { return true }))
+	mux.HandleFunc("GET /event", s.streamEvents)
+
+	mux.HandleFunc("GET /session", get(func() any { return orEmpty(s.sessions) }))
+	mux.HandleFunc("POST /session", s.createSession)
+	mux.HandleFunc("GET /session/{id}", s.withSession(s.getSession))
+	mux.HandleFunc("PATCH /session/{id}", s.withSession(s.updateSession))
+	mux.HandleFunc("DELETE /session/{id}", s.withSession(s.deleteSession))
+	mux.HandleFunc("GET /session/{id}/children", s.withSession(s.children))
+	mux.HandleFunc("GET /session/{id}/message", s.withSession(s.listMessages))
+	mux.HandleFunc("GET /session/{id}/message/{messageID}", s.withSession(s.getMessage))
+	mux.HandleFunc("POST /session/{id}/message", s.withSession(s.prompt))
+	mux.HandleFunc("POST /session/{id}/command", s.withSession(s.command))
+	mux.HandleFunc("POST /session/{id}/shell", s.withSession(s.shell))
+	mux.HandleFunc("POST /session/{id}/abort", s.withSession(acknowledge))
+	mux.HandleFunc("POST /session/{id}/init", s.withSession(acknowledge))
+	mux.HandleFunc("POST /session/{id}/summarize", s.withSession(acknowledge))
+	mux.HandleFunc("POST /session/{id}/share", s.withSession(s.share))
+	mux.HandleFunc("DELETE /session/{id}/share", s.withSession(s.unshare))
+	mux.HandleFunc("POST /session/{id}/revert", s.withSession(s.revert))
+	mux.HandleFunc("POST /session/{id}/unrevert", s.withSession(s.unrevert))
+	mux.HandleFunc("POST /session/{id}/permissions/{permissionID}", s.withSession(s.respondPermission))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: body})
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// sessionHandler handles a request for an existing session. It is called with
+// the server locked.
+type sessionHandler func(w http.ResponseWriter, r *http.Request, session *Session)
+
+func (s *Server) withSession(handle sessionHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		session, ok := s.session(r.PathValue("id"))
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("session %s not found", r.PathValue("id")))
+			return
+		}
+		handle(w, r, session)
+	}
+}
+
+func acknowledge(w http.ResponseWriter, r *http.Request, session *Session) {
+	writeJSON(w, true)
+}
+
+func (s *Server) findFiles(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(r.URL.Query().Get("query"))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := []string{}
+	for _, file := range s.Files {
+		if strings.Contains(strings.ToLower(file), query) {
+			files = append(files, file)
+		}
+	}
+	writeJSON(w, files)
+}
+
+func (s *Server) findSymbols(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(r.URL.Query().Get("query"))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	symbols := []Symbol{}
+	for _, symbol := range s.Symbols {
+		if strings.Contains(strings.ToLower(symbol.Name), query) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	writeJSON(w, symbols)
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ParentID string `json:"parentID"`
+		Title    string `json:"title"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.newSession(body.Title, body.ParentID)
+	s.publish("session.updated", map[string]any{"info": session})
+	writeJSON(w, session)
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request, session *Session) {
+	writeJSON(w, session)
+}
+
+func (s *Server) updateSession(w http.ResponseWriter, r *http.Request, session *Session) {
+	var body struct {
+		Title *string `json:"title"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Title != nil {
+		session.Title = *body.Title
+	}
+	s.touch(session)
+	writeJSON(w, session)
+}
+
+func (s *Server) deleteSession(w http.ResponseWriter, r *http.Request, session *Session) {
+	deleted := *session
+	s.sessions = slices.DeleteFunc(s.sessions, func(other Session) bool {
+		return other.ID == deleted.ID
+	})
+	delete(s.messages, deleted.ID)
+	s.publish("session.deleted", map[string]any{"info": deleted})
+	writeJSON(w, true)
+}
+
+func (s *Server) children(w http.ResponseWriter, r *http.Request, session *Session) {
+	children := []Session{}
+	for _, other := range s.sessions {
+		if other.ParentID == session.ID {
+			children = append(children, other)
+		}
+	}
+	writeJSON(w, children)
+}
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request, session *Session) {
+	writeJSON(w, orEmpty(s.messages[session.ID]))
+}
+
+func (s *Server) getMessage(w http.ResponseWriter, r *http.Request, session *Session) {
+	for _, message := range s.messages[session.ID] {
+		if message.Info.ID == r.PathValue("messageID") {
+			writeJSON(w, message)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "message not found")
+}
+
+func (s *Server) share(w http.ResponseWriter, r *http.Request, session *Session) {
+	session.Share = &SessionShare{URL: "https://opencode.test/s/" + session.ID}
+	s.touch(session)
+	writeJSON(w, session)
+}
+
+func (s *Server) unshare(w http.ResponseWriter, r *http.Request, session *Session) {
+	session.Share = nil
+	s.touch(session)
+	writeJSON(w, session)
+}
+
+func (s *Server) revert(w http.ResponseWriter, r *http.Request, session *Session) {
+	var body SessionRevert
+	if !readJSON(w, r, &body) {
+		return
+	}
+	session.Revert = &body
+	s.touch(session)
+	writeJSON(w, session)
+}
+
+func (s *Server) unrevert(w http.ResponseWriter, r *http.Request, session *Session) {
+	session.Revert = nil
+	s.touch(session)
+	writeJSON(w, session)
+}
+
+// touch bumps the session's update time and announces the change.
+func (s *Server) touch(session *Session) {
+	session.Time.Updated = s.now()
+	s.publish("session.updated", map[string]any{"info": *session})
+}
+
+// AskPermission announces a pending permission request, as a tool would
+// before running.
+func (s *Server) AskPermission(permission Permission) Permission {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.askPermission(permission)
+}
+
+func (s *Server) askPermission(permission Permission) Permission {
+	if permission.ID == "" {
+		permission.ID = id.Ascending(id.Permission)
+	}
+	if permission.Metadata == nil {
+		permission.Metadata = map[string]any{}
+	}
+	permission.Time.Created = s.now()
+	s.permissions[permission.ID] = permission
+	s.publish("permission.updated", permission)
+	return permission
+}
+
+// Permissions returns the requests that have not been answered.
+func (s *Server) Permissions() []Permission {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []Permission
+	for _, permission := range s.permissions {
+		pending = append(pending, permission)
+	}
+	slices.SortFunc(pending, func(a, b Permission) int { return strings.Compare(a.ID, b.ID) })
+	return pending
+}
+
+func (s *Server) respondPermission(w http.ResponseWriter, r *http.Request, session *Session) {
+	var body struct {
+		Response string `json:"response"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	permissionID := r.PathValue("permissionID")
+	if _, ok := s.permissions[permissionID]; !ok {
+		writeError(w, http.StatusNotFound, "permission not found")
+		return
+	}
+	delete(s.permissions, permissionID)
+	s.publish("permission.replied", map[string]any{
+		"sessionID":    session.ID,
+		"permissionID": permissionID,
+		"response":     body.Response,
+	})
+	writeJSON(w, true)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"name": "UnknownError",
+		"data": map[string]any{"message": message},
+	})
+}
+
+// orEmpty keeps nil slices from being sent as null.
+func orEmpty[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,248 @@
+package testserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func do(t *testing.T, s *Server, method, path string, body any, v any) {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, _ := http.NewRequest(method, s.URL()+path, reader)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("%s %s: %s", method, path, res.Status)
+	}
+	if v != nil {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type streamedEvent struct {
+	ID   string
+	Type string `json:"type"`
+	Raw  json.RawMessage
+}
+
+// subscribe opens the event stream and returns received events on a channel.
+func subscribe(t *testing.T, s *Server, lastEventID string) <-chan streamedEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.URL()+"/event", nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan streamedEvent, 256)
+	go func() {
+		defer res.Body.Close()
+		defer close(events)
+		scanner := bufio.NewScanner(res.Body)
+		scanner.Buffer(nil, 1<<20)
+		var current streamedEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				current.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				current.Raw = json.RawMessage(strings.TrimPrefix(line, "data: "))
+				json.Unmarshal(current.Raw, &current)
+			case line == "":
+				events <- current
+				current = streamedEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func collect(t *testing.T, events <-chan streamedEvent, until string) []streamedEvent {
+	t.Helper()
+	var got []streamedEvent
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatalf("stream closed before %s", until)
+			}
+			got = append(got, e)
+			if e.Type == until {
+				return got
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s, got %d events", until, len(got))
+		}
+	}
+}
+
+func TestPromptStreamsScriptedReply(t *testing.T) {
+	s := New()
+	defer s.Close()
+	s.Reply(Reply{
+		Tools: []Tool{{Name: "read", Input: map[string]any{"filePath": "main.go"}, Output: "package main"}},
+		Text:  "main.go declares package main",
+	})
+	events := subscribe(t, s, "")
+	if first := collect(t, events, "server.connected"); len(first) != 1 {
+		t.Fatalf("expected the stream to open with server.connected, got %+v", first)
+	}
+
+	var session Session
+	do(t, s, http.MethodPost, "/session", map[string]any{}, &session)
+	var response messageWithParts
+	do(t, s, http.MethodPost, "/session/"+session.ID+"/message", map[string]any{
+		"parts": []map[string]any{{"type": "text", "text": "what is in main.go?"}},
+	}, &response)
+
+	if response.Info.Role != "assistant" || response.Info.Time.Completed == 0 {
+		t.Errorf("expected a completed assistant message, got %+v", response.Info)
+	}
+	var types []string
+	for _, part := range response.Parts {
+		types = append(types, part.Type)
+	}
+	if strings.Join(types, ",") != "step-start,tool,text,step-finish" {
+		t.Errorf("parts = %v", types)
+	}
+	if tool := response.Parts[1]; tool.State.Status != "completed" || tool.State.Output != "package main" {
+		t.Errorf("unexpected tool part %+v", tool.State)
+	}
+
+	got := collect(t, events, "session.idle")
+	var sequence []string
+	for _, e := range got {
+		if e.ID == "" {
+			t.Errorf("event %s has no id", e.Type)
+		}
+		sequence = append(sequence, e.Type)
+	}
+	want := []string{
+		"session.updated",
+		"message.updated", "message.part.updated",
+		"message.updated",
+		"message.part.updated", "message.part.updated", "message.part.updated",
+		"message.part.updated", "message.part.updated",
+		"message.part.updated",
+		"message.updated", "session.idle",
+	}
+	if strings.Join(sequence, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v\nwant %v", sequence, want)
+	}
+
+	if messages := s.Messages(session.ID); len(messages) != 2 {
+		t.Errorf("expected user and assistant messages, got %d", len(messages))
+	}
+	if title := s.Sessions()[0].Title; title != "what is in main.go?" {
+		t.Errorf("session title = %q", title)
+	}
+}
+
+func TestToolAsksPermission(t *testing.T) {
+	s := New()
+	defer s.Close()
+	s.Reply(Reply{Tools: []Tool{{
+		Name:       "bash",
+		Input:      map[string]any{"command": "make"},
+		Output:     "ok",
+		Permission: "make",
+	}}})
+
+	var session Session
+	do(t, s, http.MethodPost, "/session", map[string]any{}, &session)
+	var response messageWithParts
+	do(t, s, http.MethodPost, "/session/"+session.ID+"/message", map[string]any{
+		"parts": []map[string]any{{"type": "text", "text": "build it"}},
+	}, &response)
+
+	pending := s.Permissions()
+	if len(pending) != 1 {
+		t.Fatalf("expected one permission request, got %+v", pending)
+	}
+	permission := pending[0]
+	if permission.Type != "bash" || permission.Title != "make" || permission.Metadata["command"] != "make" {
+		t.Errorf("unexpected permission request %+v", permission)
+	}
+	if tool := response.Parts[1]; permission.CallID != tool.CallID || permission.MessageID != response.Info.ID {
+		t.Errorf("permission request %+v is not for tool call %s", permission, tool.CallID)
+	}
+}
+
+func TestEventStreamResumes(t *testing.T) {
+	s := New()
+	defer s.Close()
+	s.Publish("file.edited", map[string]any{"file": "a.go"})
+	s.Publish("file.edited", map[string]any{"file": "b.go"})
+
+	events := subscribe(t, s, "1")
+	got := collect(t, events, "file.edited")
+	if len(got) != 2 || got[1].ID != "2" || !strings.Contains(string(got[1].Raw), "b.go") {
+		t.Errorf("expected only the missed event, got %+v", got)
+	}
+}
+
+func TestSessionEndpoints(t *testing.T) {
+	s := New()
+	defer s.Close()
+	parent := s.AddSession("parent")
+	var child Session
+	do(t, s, http.MethodPost, "/session", map[string]any{"parentID": parent.ID, "title": "child"}, &child)
+
+	var children []Session
+	do(t, s, http.MethodGet, "/session/"+parent.ID+"/children", nil, &children)
+	if len(children) != 1 || children[0].ID != child.ID {
+		t.Errorf("children = %+v", children)
+	}
+
+	var shared Session
+	do(t, s, http.MethodPost, "/session/"+parent.ID+"/share", nil, &shared)
+	if shared.Share == nil {
+		t.Error("expected a share URL")
+	}
+	do(t, s, http.MethodPatch, "/session/"+parent.ID, map[string]any{"title": "renamed"}, &shared)
+	if shared.Title != "renamed" {
+		t.Errorf("title = %q", shared.Title)
+	}
+
+	permission := s.AskPermission(Permission{Type: "bash", SessionID: parent.ID, Title: "rm -rf build"})
+	do(t, s, http.MethodPost, "/session/"+parent.ID+"/permissions/"+permission.ID, map[string]any{"response": "once"}, nil)
+	if pending := s.Permissions(); len(pending) != 0 {
+		t.Errorf("expected the permission to be answered, got %+v", pending)
+	}
+
+	do(t, s, http.MethodDelete, "/session/"+child.ID, nil, nil)
+	if len(s.Sessions()) != 1 {
+		t.Errorf("expected one session left, got %d", len(s.Sessions()))
+	}
+
+	var requests []string
+	for _, r := range s.Requests() {
+		requests = append(requests, r.Method+" "+r.Path)
+	}
+	if len(requests) != 6 || requests[0] != "POST /session" {
+		t.Errorf("requests = %v", requests)
+	}
+}
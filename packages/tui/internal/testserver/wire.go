@@ -0,0 +1,225 @@
+package testserver
+
+// Wire types mirror the JSON the opencode server sends. They are kept
+// separate from the SDK types so the fake exercises the client's decoding the
+// same way the real server does.
+
+type Project struct {
+	ID       string `json:"id"`
+	Worktree string `json:"worktree"`
+	VCS      string `json:"vcs,omitempty"`
+	Time     struct {
+		Created     float64 `json:"created"`
+		Initialized float64 `json:"initialized,omitempty"`
+	} `json:"time"`
+}
+
+type Path struct {
+	State     string `json:"state"`
+	Config    string `json:"config"`
+	Worktree  string `json:"worktree"`
+	Directory string `json:"directory"`
+}
+
+type Agent struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Mode        string          `json:"mode"`
+	BuiltIn     bool            `json:"builtIn"`
+	Tools       map[string]bool `json:"tools"`
+	Options     map[string]any  `json:"options"`
+	Permission  map[string]any  `json:"permission"`
+}
+
+type Model struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	ReleaseDate string         `json:"release_date"`
+	Attachment  bool           `json:"attachment"`
+	Reasoning   bool           `json:"reasoning"`
+	Temperature bool           `json:"temperature"`
+	ToolCall    bool           `json:"tool_call"`
+	Cost        ModelCost      `json:"cost"`
+	Limit       ModelLimit     `json:"limit"`
+	Options     map[string]any `json:"options"`
+}
+
+type ModelCost struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+type ModelLimit struct {
+	Context float64 `json:"context"`
+	Output  float64 `json:"output"`
+}
+
+type Provider struct {
+	ID     string           `json:"id"`
+	Name   string           `json:"name"`
+	Env    []string         `json:"env"`
+	Models map[string]Model `json:"models"`
+}
+
+type Providers struct {
+	Providers []Provider        `json:"providers"`
+	Default   map[string]string `json:"default"`
+}
+
+type Command struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Template    string `json:"template"`
+}
+
+type FileStatus struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Status  string `json:"status"`
+}
+
+type Symbol struct {
+	Name     string   `json:"name"`
+	Kind     int      `json:"kind"`
+	Location Location `json:"location"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Session struct {
+	ID        string         `json:"id"`
+	ProjectID string         `json:"projectID"`
+	Directory string         `json:"directory"`
+	ParentID  string         `json:"parentID,omitempty"`
+	Title     string         `json:"title"`
+	Version   string         `json:"version"`
+	Time      SessionTime    `json:"time"`
+	Share     *SessionShare  `json:"share,omitempty"`
+	Revert    *SessionRevert `json:"revert,omitempty"`
+}
+
+type SessionTime struct {
+	Created float64 `json:"created"`
+	Updated float64 `json:"updated"`
+}
+
+type SessionShare struct {
+	URL string `json:"url"`
+}
+
+type SessionRevert struct {
+	MessageID string `json:"messageID"`
+	PartID    string `json:"partID,omitempty"`
+}
+
+// Message is a user or assistant message. User messages leave out the
+// assistant fields.
+type Message struct {
+	ID        string      `json:"id"`
+	SessionID string      `json:"sessionID"`
+	Role      string      `json:"role"`
+	Time      MessageTime `json:"time"`
+	*AssistantFields
+}
+
+// AssistantFields are the fields of assistant messages, all but Error
+// required by the API schema.
+type AssistantFields struct {
+	ModelID    string         `json:"modelID"`
+	ProviderID string         `json:"providerID"`
+	Mode       string         `json:"mode"`
+	Path       *MessagePath   `json:"path"`
+	System     []string       `json:"system"`
+	Cost       float64        `json:"cost"`
+	Tokens     *Tokens        `json:"tokens"`
+	Error      map[string]any `json:"error,omitempty"`
+}
+
+type MessageTime struct {
+	Created   float64 `json:"created"`
+	Completed float64 `json:"completed,omitempty"`
+}
+
+type MessagePath struct {
+	Cwd  string `json:"cwd"`
+	Root string `json:"root"`
+}
+
+type Tokens struct {
+	Input     float64 `json:"input"`
+	Output    float64 `json:"output"`
+	Reasoning float64 `json:"reasoning"`
+	Cache     struct {
+		Read  float64 `json:"read"`
+		Write float64 `json:"write"`
+	} `json:"cache"`
+}
+
+// Part is any message part; fields not used by a type are omitted.
+type Part struct {
+	ID        string     `json:"id"`
+	SessionID string     `json:"sessionID"`
+	MessageID string     `json:"messageID"`
+	Type      string     `json:"type"`
+	Text      string     `json:"text,omitempty"`
+	Synthetic bool       `json:"synthetic,omitempty"`
+	Time      *PartTime  `json:"time,omitempty"`
+	CallID    string     `json:"callID,omitempty"`
+	Tool      string     `json:"tool,omitempty"`
+	State     *ToolState `json:"state,omitempty"`
+	Mime      string     `json:"mime,omitempty"`
+	Filename  string     `json:"filename,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	Cost      *float64   `json:"cost,omitempty"`
+	Tokens    *Tokens    `json:"tokens,omitempty"`
+}
+
+type PartTime struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end,omitempty"`
+}
+
+type ToolState struct {
+	Status   string         `json:"status"`
+	Input    map[string]any `json:"input"`
+	Output   string         `json:"output,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Title    string         `json:"title,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Time     *PartTime      `json:"time,omitempty"`
+}
+
+type Permission struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Pattern   any            `json:"pattern,omitempty"`
+	SessionID string         `json:"sessionID"`
+	MessageID string         `json:"messageID"`
+	CallID    string         `json:"callID,omitempty"`
+	Title     string         `json:"title"`
+	Metadata  map[string]any `json:"metadata"`
+	Time      struct {
+		Created float64 `json:"created"`
+	} `json:"time"`
+}
+
+// messageWithParts is the shape of message list and prompt responses.
+type messageWithParts struct {
+	Info  Message `json:"info"`
+	Parts []Part  `json:"parts"`
+}
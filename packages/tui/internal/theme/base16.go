@@ -0,0 +1,67 @@
+package theme
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// parseBase16 reads a base16 scheme, either the original flat format or
+// the tinted-theming one with a palette section.
+func parseBase16(data []byte) (*palette, error) {
+	var scheme map[string]any
+	if err := yaml.Unmarshal(data, &scheme); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+	}
+	colors := scheme
+	if nested, ok := scheme["palette"].(map[any]any); ok {
+		colors = map[string]any{}
+		for key, value := range nested {
+			colors[fmt.Sprint(key)] = value
+		}
+	}
+
+	base := map[string]string{}
+	for i := range 16 {
+		key := fmt.Sprintf("base%02X", i)
+		value, ok := colors[key]
+		if !ok {
+			value, ok = colors[strings.ToLower(key)]
+		}
+		if !ok {
+			return nil, fmt.Errorf("missing %s", key)
+		}
+		// unquoted all-digit colors come back from YAML as numbers
+		text := fmt.Sprint(value)
+		if n, ok := value.(int); ok {
+			text = fmt.Sprintf("%06d", n)
+		}
+		color, err := normalizeHex(text, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		base[key] = color
+	}
+
+	// roles follow the base16 styling guidelines
+	return &palette{
+		background:        base["base00"],
+		backgroundPanel:   base["base01"],
+		backgroundElement: base["base02"],
+		text:              base["base05"],
+		textMuted:         base["base04"],
+		border:            base["base03"],
+		borderActive:      base["base04"],
+		borderSubtle:      base["base02"],
+		red:               base["base08"],
+		orange:            base["base09"],
+		yellow:            base["base0A"],
+		green:             base["base0B"],
+		cyan:              base["base0C"],
+		blue:              base["base0D"],
+		purple:            base["base0E"],
+		comment:           base["base03"],
+		punctuation:       base["base05"],
+	}, nil
+}
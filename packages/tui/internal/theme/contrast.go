@@ -0,0 +1,166 @@
+package theme
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/lipgloss/v2/compat"
+)
+
+// WCAG 2 minimum contrast ratios
+const (
+	// ContrastText is the AA minimum for body text.
+	ContrastText = 4.5
+	// ContrastUI is the AA minimum for large text and interface elements;
+	// it is used for secondary text such as muted labels and line numbers.
+	ContrastUI = 3.0
+)
+
+// ContrastPair is a foreground drawn over a background somewhere in the UI.
+type ContrastPair struct {
+	Foreground string // theme key, as in theme files
+	Background string
+	Minimum    float64
+	fg, bg     func(Theme) compat.AdaptiveColor
+}
+
+// ContrastPairs are the pairs CheckContrast measures.
+var ContrastPairs = []ContrastPair{
+	{"text", "background", ContrastText, Theme.Text, Theme.Background},
+	{"text", "backgroundPanel", ContrastText, Theme.Text, Theme.BackgroundPanel},
+	{"text", "backgroundElement", ContrastText, Theme.Text, Theme.BackgroundElement},
+	{"markdownText", "background", ContrastText, Theme.MarkdownText, Theme.Background},
+	{"text", "diffAddedBg", ContrastText, Theme.Text, Theme.DiffAddedBg},
+	{"text", "diffRemovedBg", ContrastText, Theme.Text, Theme.DiffRemovedBg},
+	{"text", "diffContextBg", ContrastText, Theme.Text, Theme.DiffContextBg},
+	{"textMuted", "background", ContrastUI, Theme.TextMuted, Theme.Background},
+	{"textMuted", "backgroundPanel", ContrastUI, Theme.TextMuted, Theme.BackgroundPanel},
+	{"textMuted", "diffLineNumber", ContrastUI, Theme.TextMuted, Theme.DiffLineNumber},
+	{"diffAdded", "diffAddedBg", ContrastUI, Theme.DiffAdded, Theme.DiffAddedBg},
+	{"diffRemoved", "diffRemovedBg", ContrastUI, Theme.DiffRemoved, Theme.DiffRemovedBg},
+	{"diffAdded", "diffAddedLineNumberBg", ContrastUI, Theme.DiffAdded, Theme.DiffAddedLineNumberBg},
+	{"diffRemoved", "diffRemovedLineNumberBg", ContrastUI, Theme.DiffRemoved, Theme.DiffRemovedLineNumberBg},
+	{"primary", "background", ContrastUI, Theme.Primary, Theme.Background},
+	{"error", "backgroundPanel", ContrastUI, Theme.Error, Theme.BackgroundPanel},
+	{"warning", "backgroundPanel", ContrastUI, Theme.Warning, Theme.BackgroundPanel},
+	{"success", "backgroundPanel", ContrastUI, Theme.Success, Theme.BackgroundPanel},
+	{"info", "backgroundPanel", ContrastUI, Theme.Info, Theme.BackgroundPanel},
+	{"syntaxComment", "backgroundPanel", ContrastUI, Theme.SyntaxComment, Theme.BackgroundPanel},
+}
+
+// ContrastIssue is a pair below its minimum in one variant.
+type ContrastIssue struct {
+	ContrastPair
+	Variant string // "dark" or "light"
+	Ratio   float64
+	// Suggestion is the closest foreground that passes, or empty when
+	// none does against this background.
+	Suggestion string
+}
+
+func (i ContrastIssue) String() string {
+	s := fmt.Sprintf("%s: %s on %s is %.2f:1, needs %.1f:1",
+		i.Variant, i.Foreground, i.Background, i.Ratio, i.Minimum)
+	if i.Suggestion != "" {
+		s += fmt.Sprintf("; try %s %s", i.Foreground, i.Suggestion)
+	} else {
+		s += fmt.Sprintf("; change %s", i.Background)
+	}
+	return s
+}
+
+// CheckContrast measures every ContrastPair in both variants of a theme.
+// Pairs involving "none" or the 16 ANSI colors are skipped, since their
+// actual color is up to the terminal.
+func CheckContrast(t Theme) []ContrastIssue {
+	var issues []ContrastIssue
+	for _, pair := range ContrastPairs {
+		fg, bg := pair.fg(t), pair.bg(t)
+		variants := []struct {
+			name   string
+			fg, bg color.Color
+		}{
+			{"dark", fg.Dark, bg.Dark},
+			{"light", fg.Light, bg.Light},
+		}
+		for _, variant := range variants {
+			fgRGB, ok := measurable(variant.fg)
+			if !ok {
+				continue
+			}
+			bgRGB, ok := measurable(variant.bg)
+			if !ok {
+				continue
+			}
+			ratio := contrastRatio(fgRGB, bgRGB)
+			if ratio >= pair.Minimum {
+				continue
+			}
+			issues = append(issues, ContrastIssue{
+				ContrastPair: pair,
+				Variant:      variant.name,
+				Ratio:        ratio,
+				Suggestion:   suggestForeground(fgRGB, bgRGB, pair.Minimum),
+			})
+		}
+	}
+	return issues
+}
+
+func measurable(c color.Color) (rgb, bool) {
+	if c == nil || isAnsiColor(c) {
+		return rgb{}, false
+	}
+	if _, ok := c.(lipgloss.NoColor); ok {
+		return rgb{}, false
+	}
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return rgb{}, false
+	}
+	return rgb{r: float64(r >> 8), g: float64(g >> 8), b: float64(b >> 8)}, true
+}
+
+// luminance is the WCAG relative luminance of an sRGB color.
+func luminance(c rgb) float64 {
+	channel := func(v float64) float64 {
+		v /= 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.r) + 0.7152*channel(c.g) + 0.0722*channel(c.b)
+}
+
+func contrastRatio(a, b rgb) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// suggestForeground moves fg toward white or black, whichever the
+// background leaves more room for, until it reaches minimum.
+func suggestForeground(fg, bg rgb, minimum float64) string {
+	target := rgb{255, 255, 255}
+	if contrastRatio(rgb{}, bg) > contrastRatio(target, bg) {
+		target = rgb{}
+	}
+	for step := 1; step <= 20; step++ {
+		amount := float64(step) / 20
+		candidate := rgb{
+			r: fg.r + (target.r-fg.r)*amount,
+			g: fg.g + (target.g-fg.g)*amount,
+			b: fg.b + (target.b-fg.b)*amount,
+		}
+		candidate, _, _ = parseHex(candidate.hex())
+		if contrastRatio(candidate, bg) >= minimum {
+			return candidate.hex()
+		}
+	}
+	return ""
+}
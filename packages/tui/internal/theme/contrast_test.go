@@ -0,0 +1,52 @@
+package theme
+
+import (
+	"math"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/lipgloss/v2/compat"
+)
+
+func TestContrastRatio(t *testing.T) {
+	white := rgb{255, 255, 255}
+	if ratio := contrastRatio(white, rgb{}); math.Abs(ratio-21) > 0.01 {
+		t.Errorf("white on black = %.2f, want 21", ratio)
+	}
+	if ratio := contrastRatio(rgb{119, 119, 119}, white); math.Abs(ratio-4.48) > 0.01 {
+		t.Errorf("#777777 on white = %.2f, want 4.48", ratio)
+	}
+}
+
+func TestCheckContrastBothVariants(t *testing.T) {
+	theme := &LoadedTheme{name: "faint"}
+	theme.BackgroundColor = compat.AdaptiveColor{Dark: lipgloss.Color("#000000"), Light: lipgloss.Color("#ffffff")}
+	theme.BackgroundPanelColor = theme.BackgroundColor
+	// readable on the dark background, nearly invisible on the light one
+	theme.TextColor = compat.AdaptiveColor{Dark: lipgloss.Color("#eeeeee"), Light: lipgloss.Color("#dddddd")}
+	// ANSI colors are up to the terminal and are not measured
+	theme.TextMutedColor = compat.AdaptiveColor{Dark: lipgloss.Color("8"), Light: lipgloss.Color("8")}
+
+	var found *ContrastIssue
+	for _, issue := range CheckContrast(theme) {
+		if issue.Foreground == "textMuted" {
+			t.Errorf("unexpected issue for an ANSI color: %s", issue)
+		}
+		if issue.Foreground == "text" && issue.Background == "background" {
+			if issue.Variant != "light" {
+				t.Errorf("unexpected issue in the dark variant: %s", issue)
+			}
+			found = &issue
+		}
+	}
+	if found == nil {
+		t.Fatal("expected light text on white to be reported")
+	}
+	suggestion, _, err := parseHex(found.Suggestion)
+	if err != nil {
+		t.Fatalf("bad suggestion %q", found.Suggestion)
+	}
+	if ratio := contrastRatio(suggestion, rgb{255, 255, 255}); ratio < ContrastText {
+		t.Errorf("suggestion %s only reaches %.2f:1", found.Suggestion, ratio)
+	}
+}
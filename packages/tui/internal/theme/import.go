@@ -0,0 +1,409 @@
+package theme
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ImportFormat is a color scheme format other than opencode's own.
+type ImportFormat string
+
+const (
+	FormatBase16 ImportFormat = "base16"
+	FormatVSCode ImportFormat = "vscode"
+	FormatITerm  ImportFormat = "iterm"
+)
+
+// ImportFormats lists the formats Import understands.
+var ImportFormats = []ImportFormat{FormatBase16, FormatVSCode, FormatITerm}
+
+// themeKeys are the keys of a theme file in the order they are written.
+var themeKeys = []string{
+	"primary", "secondary", "accent", "error", "warning", "success", "info",
+	"text", "textMuted", "background", "backgroundPanel", "backgroundElement",
+	"border", "borderActive", "borderSubtle",
+	"diffAdded", "diffRemoved", "diffContext", "diffHunkHeader",
+	"diffHighlightAdded", "diffHighlightRemoved", "diffAddedBg", "diffRemovedBg",
+	"diffContextBg", "diffLineNumber", "diffAddedLineNumberBg", "diffRemovedLineNumberBg",
+	"markdownText", "markdownHeading", "markdownLink", "markdownLinkText",
+	"markdownCode", "markdownBlockQuote", "markdownEmph", "markdownStrong",
+	"markdownHorizontalRule", "markdownListItem", "markdownListEnumeration",
+	"markdownImage", "markdownImageText", "markdownCodeBlock",
+	"syntaxComment", "syntaxKeyword", "syntaxFunction", "syntaxVariable",
+	"syntaxString", "syntaxNumber", "syntaxType", "syntaxOperator", "syntaxPunctuation",
+}
+
+// DetectFormat guesses the format of a theme file from its name and
+// contents. An empty format means opencode's own.
+func DetectFormat(filename string, data []byte) (ImportFormat, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return FormatBase16, nil
+	case ".itermcolors":
+		return FormatITerm, nil
+	case ".json":
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(stripJSONC(data), &probe); err != nil {
+			return "", fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+		if _, ok := probe["theme"]; ok {
+			return "", nil
+		}
+		_, colors := probe["colors"]
+		_, tokens := probe["tokenColors"]
+		if colors || tokens {
+			return FormatVSCode, nil
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("unrecognized theme file %s", filepath.Base(filename))
+}
+
+// Import converts a color scheme to an opencode theme.
+func Import(format ImportFormat, data []byte) (*JSONTheme, error) {
+	var p *palette
+	var err error
+	switch format {
+	case FormatBase16:
+		p, err = parseBase16(data)
+	case FormatVSCode:
+		p, err = parseVSCode(data)
+	case FormatITerm:
+		p, err = parseITerm(data)
+	default:
+		return nil, fmt.Errorf("unknown theme format %q, expected one of base16, vscode or iterm", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to import %s theme: %w", format, err)
+	}
+	if err := p.missing(); err != nil {
+		return nil, fmt.Errorf("failed to import %s theme: %w", format, err)
+	}
+	return p.theme(), nil
+}
+
+// parseThemeFile loads a theme file in any supported format.
+func parseThemeFile(name, filename string, data []byte) (Theme, error) {
+	format, err := DetectFormat(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		return parseJSONTheme(name, data)
+	}
+	jsonTheme, err := Import(format, data)
+	if err != nil {
+		return nil, err
+	}
+	data, err = MarshalJSONTheme(jsonTheme)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONTheme(name, data)
+}
+
+// themeExtensions are the files loaded from theme directories.
+var themeExtensions = []string{".json", ".yaml", ".yml", ".itermcolors"}
+
+// LoadThemeFile reads a theme file in any supported format without
+// registering it. The theme is named after the file.
+func LoadThemeFile(filename string) (Theme, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parseThemeFile(themeName(filename), filename, data)
+}
+
+// IsThemeFile reports whether a file in a theme directory is loaded.
+func IsThemeFile(filename string) bool {
+	return slices.Contains(themeExtensions, strings.ToLower(filepath.Ext(filename)))
+}
+
+// themeName is the theme a file in a theme directory registers.
+func themeName(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// MarshalJSONTheme writes a theme file with its keys in the usual order.
+func MarshalJSONTheme(t *JSONTheme) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{\n  \"$schema\": \"https://opencode.ai/theme.json\"")
+	writeSection := func(name string, values map[string]any, order []string) error {
+		if len(values) == 0 {
+			return nil
+		}
+		fmt.Fprintf(&buf, ",\n  %q: {", name)
+		first := true
+		seen := map[string]bool{}
+		write := func(key string) error {
+			value, err := json.Marshal(values[key])
+			if err != nil {
+				return err
+			}
+			if !first {
+				buf.WriteString(",")
+			}
+			first = false
+			fmt.Fprintf(&buf, "\n    %q: %s", key, value)
+			seen[key] = true
+			return nil
+		}
+		for _, key := range order {
+			if _, ok := values[key]; ok {
+				if err := write(key); err != nil {
+					return err
+				}
+			}
+		}
+		rest := make([]string, 0, len(values))
+		for key := range values {
+			if !seen[key] {
+				rest = append(rest, key)
+			}
+		}
+		slices.Sort(rest)
+		for _, key := range rest {
+			if err := write(key); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("\n  }")
+		return nil
+	}
+	if err := writeSection("defs", t.Defs, paletteDefs); err != nil {
+		return nil, err
+	}
+	if err := writeSection("theme", t.Theme, themeKeys); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n}\n")
+	return buf.Bytes(), nil
+}
+
+// palette is what the importers extract from a scheme. Empty syntax and
+// diff colors fall back to the accents.
+type palette struct {
+	background, backgroundPanel, backgroundElement string
+	text, textMuted                                string
+	border, borderActive, borderSubtle             string
+
+	red, orange, yellow, green, cyan, blue, purple string
+
+	link                                      string
+	comment, keyword, function, variable, str string
+	number, types, operator, punctuation      string
+	diffAddedBg, diffRemovedBg                string
+}
+
+// paletteDefs are the defs an imported theme has, in order.
+var paletteDefs = []string{
+	"bg", "bgPanel", "bgElement", "fg", "fgMuted",
+	"line", "lineActive", "lineSubtle",
+	"red", "orange", "yellow", "green", "cyan", "blue", "purple",
+}
+
+// fill derives the colors a scheme did not provide.
+func (p *palette) fill() {
+	or := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	or(&p.backgroundPanel, blend(p.background, p.text, 0.04))
+	or(&p.backgroundElement, blend(p.background, p.text, 0.08))
+	or(&p.textMuted, blend(p.background, p.text, 0.6))
+	or(&p.border, blend(p.background, p.text, 0.25))
+	or(&p.borderActive, blend(p.background, p.text, 0.4))
+	or(&p.borderSubtle, blend(p.background, p.text, 0.15))
+	or(&p.orange, blend(p.red, p.yellow, 0.5))
+	or(&p.link, p.blue)
+	or(&p.comment, p.textMuted)
+	or(&p.keyword, p.purple)
+	or(&p.function, p.blue)
+	or(&p.variable, p.red)
+	or(&p.str, p.green)
+	or(&p.number, p.orange)
+	or(&p.types, p.yellow)
+	or(&p.operator, p.cyan)
+	or(&p.punctuation, p.text)
+	or(&p.diffAddedBg, blend(p.background, p.green, 0.15))
+	or(&p.diffRemovedBg, blend(p.background, p.red, 0.15))
+}
+
+func (p *palette) theme() *JSONTheme {
+	p.fill()
+	defs := map[string]any{
+		"bg":         p.background,
+		"bgPanel":    p.backgroundPanel,
+		"bgElement":  p.backgroundElement,
+		"fg":         p.text,
+		"fgMuted":    p.textMuted,
+		"line":       p.border,
+		"lineActive": p.borderActive,
+		"lineSubtle": p.borderSubtle,
+		"red":        p.red,
+		"orange":     p.orange,
+		"yellow":     p.yellow,
+		"green":      p.green,
+		"cyan":       p.cyan,
+		"blue":       p.blue,
+		"purple":     p.purple,
+	}
+	// colors equal to a def refer to it, so the file reads like a palette.
+	// Def names must differ from theme keys, which share one namespace.
+	ref := func(color string) string {
+		for _, name := range paletteDefs {
+			if defs[name] == color {
+				return name
+			}
+		}
+		return color
+	}
+	theme := map[string]any{
+		"primary":                 "blue",
+		"secondary":               "purple",
+		"accent":                  "cyan",
+		"error":                   "red",
+		"warning":                 "orange",
+		"success":                 "green",
+		"info":                    "cyan",
+		"text":                    "fg",
+		"textMuted":               "fgMuted",
+		"background":              "bg",
+		"backgroundPanel":         "bgPanel",
+		"backgroundElement":       "bgElement",
+		"border":                  "line",
+		"borderActive":            "lineActive",
+		"borderSubtle":            "lineSubtle",
+		"diffAdded":               "green",
+		"diffRemoved":             "red",
+		"diffContext":             "fgMuted",
+		"diffHunkHeader":          "fgMuted",
+		"diffHighlightAdded":      blend(p.green, p.text, 0.3),
+		"diffHighlightRemoved":    blend(p.red, p.text, 0.3),
+		"diffAddedBg":             ref(p.diffAddedBg),
+		"diffRemovedBg":           ref(p.diffRemovedBg),
+		"diffContextBg":           "bgPanel",
+		"diffLineNumber":          "bgElement",
+		"diffAddedLineNumberBg":   blend(p.background, p.green, 0.1),
+		"diffRemovedLineNumberBg": blend(p.background, p.red, 0.1),
+		"markdownText":            "fg",
+		"markdownHeading":         "purple",
+		"markdownLink":            ref(p.link),
+		"markdownLinkText":        "cyan",
+		"markdownCode":            "green",
+		"markdownBlockQuote":      "yellow",
+		"markdownEmph":            "yellow",
+		"markdownStrong":          "orange",
+		"markdownHorizontalRule":  "fgMuted",
+		"markdownListItem":        "blue",
+		"markdownListEnumeration": "cyan",
+		"markdownImage":           ref(p.link),
+		"markdownImageText":       "cyan",
+		"markdownCodeBlock":       "fg",
+		"syntaxComment":           ref(p.comment),
+		"syntaxKeyword":           ref(p.keyword),
+		"syntaxFunction":          ref(p.function),
+		"syntaxVariable":          ref(p.variable),
+		"syntaxString":            ref(p.str),
+		"syntaxNumber":            ref(p.number),
+		"syntaxType":              ref(p.types),
+		"syntaxOperator":          ref(p.operator),
+		"syntaxPunctuation":       ref(p.punctuation),
+	}
+	return &JSONTheme{Defs: defs, Theme: theme}
+}
+
+// missing reports the first required palette color a scheme left empty.
+func (p *palette) missing() error {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"background", p.background}, {"foreground", p.text},
+		{"red", p.red}, {"yellow", p.yellow}, {"green", p.green},
+		{"cyan", p.cyan}, {"blue", p.blue}, {"purple", p.purple},
+	}
+	for _, color := range required {
+		if color.value == "" {
+			return fmt.Errorf("no %s color", color.name)
+		}
+	}
+	return nil
+}
+
+type rgb struct{ r, g, b float64 }
+
+// parseHex reads #rgb, #rrggbb and #rrggbbaa colors, with or without the
+// hash. Alpha is returned separately, from 0 to 1.
+func parseHex(s string) (rgb, float64, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 || len(s) == 4 {
+		var expanded strings.Builder
+		for _, c := range s {
+			expanded.WriteRune(c)
+			expanded.WriteRune(c)
+		}
+		s = expanded.String()
+	}
+	if len(s) != 6 && len(s) != 8 {
+		return rgb{}, 0, fmt.Errorf("invalid color %q", s)
+	}
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return rgb{}, 0, fmt.Errorf("invalid color %q", s)
+	}
+	alpha := 1.0
+	if len(s) == 8 {
+		alpha = float64(value&0xff) / 255
+		value >>= 8
+	}
+	return rgb{
+		r: float64(value >> 16 & 0xff),
+		g: float64(value >> 8 & 0xff),
+		b: float64(value & 0xff),
+	}, alpha, nil
+}
+
+func (c rgb) hex() string {
+	clamp := func(v float64) int {
+		return int(math.Max(0, math.Min(255, math.Round(v))))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", clamp(c.r), clamp(c.g), clamp(c.b))
+}
+
+// blend mixes amount of b into a. Colors that don't parse leave a as is.
+func blend(a, b string, amount float64) string {
+	ca, _, errA := parseHex(a)
+	cb, _, errB := parseHex(b)
+	if errA != nil || errB != nil {
+		return a
+	}
+	return rgb{
+		r: ca.r + (cb.r-ca.r)*amount,
+		g: ca.g + (cb.g-ca.g)*amount,
+		b: ca.b + (cb.b-ca.b)*amount,
+	}.hex()
+}
+
+// normalizeHex returns a color as #rrggbb, compositing any alpha over
+// background.
+func normalizeHex(color, background string) (string, error) {
+	c, alpha, err := parseHex(color)
+	if err != nil {
+		return "", err
+	}
+	if alpha < 1 && background != "" {
+		return blend(background, c.hex(), alpha), nil
+	}
+	return c.hex(), nil
+}
@@ -0,0 +1,163 @@
+package theme
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const base16Scheme = `scheme: "Tomorrow Night"
+author: "Chris Kempson"
+base00: "1d1f21"
+base01: "282a2e"
+base02: "373b41"
+base03: "969896"
+base04: "b4b7b4"
+base05: "c5c8c6"
+base06: "e0e0e0"
+base07: "ffffff"
+base08: "cc6666"
+base09: "de935f"
+base0A: "f0c674"
+base0B: "b5bd68"
+base0C: "8abeb7"
+base0D: "81a2be"
+base0E: "b294bb"
+base0F: "a3685a"
+`
+
+const vscodeScheme = `{
+	// comments and trailing commas are allowed
+	"name": "Test",
+	"type": "dark",
+	"colors": {
+		"editor.background": "#1e1e1e",
+		"editor.foreground": "#d4d4d4",
+		"terminal.ansiRed": "#f44747",
+		"terminal.ansiGreen": "#6a9955",
+		"terminal.ansiYellow": "#dcdcaa",
+		"terminal.ansiBlue": "#569cd6",
+		"terminal.ansiMagenta": "#c586c0",
+		"terminal.ansiCyan": "#4ec9b0",
+		"diffEditor.insertedTextBackground": "#9bb95533", /* translucent */
+	},
+	"tokenColors": [
+		{"scope": "comment", "settings": {"foreground": "#6A9955"}},
+		{"scope": ["string", "meta.embedded string"], "settings": {"foreground": "#ce9178"}},
+		{"scope": "keyword.control, storage", "settings": {"foreground": "#C586C0"}},
+	],
+}`
+
+func itermScheme() string {
+	color := func(r, g, b string) string {
+		return `<dict><key>Blue Component</key><real>` + b + `</real>` +
+			`<key>Color Space</key><string>sRGB</string>` +
+			`<key>Green Component</key><real>` + g + `</real>` +
+			`<key>Red Component</key><real>` + r + `</real></dict>`
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0"><dict>
+<key>Ansi 1 Color</key>` + color("1", "0", "0") + `
+<key>Ansi 2 Color</key>` + color("0", "1", "0") + `
+<key>Ansi 3 Color</key>` + color("1", "1", "0") + `
+<key>Ansi 4 Color</key>` + color("0", "0", "1") + `
+<key>Ansi 5 Color</key>` + color("1", "0", "1") + `
+<key>Ansi 6 Color</key>` + color("0", "1", "1") + `
+<key>Background Color</key>` + color("0", "0", "0") + `
+<key>Foreground Color</key>` + color("1", "1", "1") + `
+<key>Bold Font</key><true/>
+</dict></plist>`
+}
+
+func TestImportFormats(t *testing.T) {
+	tests := []struct {
+		file   string
+		data   string
+		format ImportFormat
+		want   map[string]string // theme key to the color it resolves to
+	}{
+		{"tomorrow.yaml", base16Scheme, FormatBase16, map[string]string{
+			"background": "#1d1f21", "text": "#c5c8c6", "primary": "#81a2be",
+			"syntaxComment": "#969896", "diffAdded": "#b5bd68",
+		}},
+		{"dark.json", vscodeScheme, FormatVSCode, map[string]string{
+			"background": "#1e1e1e", "error": "#f44747", "syntaxString": "#ce9178",
+			"syntaxKeyword": "#c586c0", "diffAddedBg": "#373d29",
+		}},
+		{"plain.itermcolors", itermScheme(), FormatITerm, map[string]string{
+			"background": "#000000", "text": "#ffffff", "success": "#00ff00", "secondary": "#ff00ff",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			format, err := DetectFormat(test.file, []byte(test.data))
+			if err != nil || format != test.format {
+				t.Fatalf("DetectFormat = %q, %v", format, err)
+			}
+			jsonTheme, err := Import(format, []byte(test.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range themeKeys {
+				if _, ok := jsonTheme.Theme[key]; !ok {
+					t.Errorf("missing theme key %s", key)
+				}
+			}
+			data, err := MarshalJSONTheme(jsonTheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			theme, err := parseJSONTheme("imported", data)
+			if err != nil {
+				t.Fatalf("imported theme does not load: %v\n%s", err, data)
+			}
+			loaded := theme.(*LoadedTheme)
+			colors := map[string]func() string{
+				"background":    func() string { return colorHex(loaded.Background().Dark) },
+				"text":          func() string { return colorHex(loaded.Text().Dark) },
+				"primary":       func() string { return colorHex(loaded.Primary().Dark) },
+				"secondary":     func() string { return colorHex(loaded.Secondary().Dark) },
+				"error":         func() string { return colorHex(loaded.Error().Dark) },
+				"success":       func() string { return colorHex(loaded.Success().Dark) },
+				"diffAdded":     func() string { return colorHex(loaded.DiffAdded().Dark) },
+				"diffAddedBg":   func() string { return colorHex(loaded.DiffAddedBg().Dark) },
+				"syntaxComment": func() string { return colorHex(loaded.SyntaxComment().Dark) },
+				"syntaxString":  func() string { return colorHex(loaded.SyntaxString().Dark) },
+				"syntaxKeyword": func() string { return colorHex(loaded.SyntaxKeyword().Dark) },
+			}
+			for key, want := range test.want {
+				if got := colors[key](); got != want {
+					t.Errorf("%s = %s, want %s", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadImportedThemesFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "tomorrow-night.yml"), []byte(base16Scheme), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a theme"), 0644)
+	if err := loadThemesFromDirectory(dir); err != nil {
+		t.Fatal(err)
+	}
+	if GetTheme("tomorrow-night") == nil {
+		t.Error("expected the base16 scheme to be registered")
+	}
+	if GetTheme("notes") != nil {
+		t.Error("expected other files to be ignored")
+	}
+}
+
+func TestDetectFormatKeepsOpencodeThemes(t *testing.T) {
+	data, _ := json.Marshal(map[string]any{"theme": map[string]any{"primary": "#ffffff"}})
+	format, err := DetectFormat("mine.json", data)
+	if err != nil || format != "" {
+		t.Errorf("DetectFormat = %q, %v, want opencode's own format", format, err)
+	}
+	if _, err := DetectFormat("theme.toml", nil); err == nil {
+		t.Error("expected an unknown extension to be rejected")
+	}
+}
@@ -0,0 +1,129 @@
+package theme
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// parseITerm reads an iTerm2 .itermcolors file, an XML property list of
+// colors with components from 0 to 1.
+func parseITerm(data []byte) (*palette, error) {
+	colors, err := parsePlistColors(data)
+	if err != nil {
+		return nil, err
+	}
+	ansi := func(n int) string {
+		return colors[fmt.Sprintf("Ansi %d Color", n)]
+	}
+	background := colors["Background Color"]
+	return &palette{
+		background:        background,
+		backgroundElement: colors["Selection Color"],
+		text:              colors["Foreground Color"],
+		textMuted:         ansi(8),
+		red:               ansi(1),
+		green:             ansi(2),
+		yellow:            ansi(3),
+		blue:              ansi(4),
+		purple:            ansi(5),
+		cyan:              ansi(6),
+		link:              colors["Link Color"],
+	}, nil
+}
+
+// parsePlistColors returns the colors in the top level dictionary of a
+// property list, by key.
+func parsePlistColors(data []byte) (map[string]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// skip to the top level dictionary
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plist: no dictionary")
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "dict" {
+			break
+		}
+	}
+
+	colors := map[string]string{}
+	var key string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plist: %w", err)
+		}
+		switch token := token.(type) {
+		case xml.EndElement:
+			if token.Name.Local == "dict" {
+				return colors, nil
+			}
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "key":
+				if err := decoder.DecodeElement(&key, &token); err != nil {
+					return nil, fmt.Errorf("failed to read plist: %w", err)
+				}
+			case "dict":
+				components, err := plistDict(decoder)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read plist: %s: %w", key, err)
+				}
+				if color, ok := plistColor(components); ok {
+					colors[key] = color
+				}
+			default:
+				if err := decoder.Skip(); err != nil {
+					return nil, fmt.Errorf("failed to read plist: %w", err)
+				}
+			}
+		}
+	}
+}
+
+// plistDict reads a dictionary of numbers, ignoring other values.
+func plistDict(decoder *xml.Decoder) (map[string]float64, error) {
+	values := map[string]float64{}
+	var key string
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.EndElement:
+			return values, nil
+		case xml.StartElement:
+			var text string
+			if err := decoder.DecodeElement(&text, &token); err != nil {
+				return nil, err
+			}
+			switch token.Name.Local {
+			case "key":
+				key = text
+			case "real", "integer":
+				if value, err := strconv.ParseFloat(text, 64); err == nil {
+					values[key] = value
+				}
+			}
+		}
+	}
+}
+
+// plistColor converts iTerm2's color components to #rrggbb.
+func plistColor(components map[string]float64) (string, bool) {
+	r, okR := components["Red Component"]
+	g, okG := components["Green Component"]
+	b, okB := components["Blue Component"]
+	if !okR || !okG || !okB {
+		return "", false
+	}
+	return rgb{r: r * 255, g: g * 255, b: b * 255}.hex(), true
+}
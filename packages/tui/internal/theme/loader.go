@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"image/color"
+	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
@@ -67,11 +68,25 @@ func LoadThemesFromJSON() error {
 // 2. USER_CONFIG/opencode/themes/*.json
 // 3. PROJECT_ROOT/.opencode/themes/*.json
 // 4. CWD/.opencode/themes/*.json
+//
+// Besides opencode's own format, base16 (.yaml, .yml), VS Code (.json) and
+// iTerm2 (.itermcolors) schemes are imported.
 func LoadThemesFromDirectories(userConfig, projectRoot, cwd string) error {
 	if err := LoadThemesFromJSON(); err != nil {
 		return fmt.Errorf("failed to load built-in themes: %w", err)
 	}
 
+	for _, dir := range Directories(userConfig, projectRoot, cwd) {
+		if err := loadThemesFromDirectory(dir); err != nil {
+			fmt.Printf("Warning: Failed to load themes from %s: %v\n", dir, err)
+		}
+	}
+
+	return nil
+}
+
+// Directories returns the custom theme directories, lowest priority first.
+func Directories(userConfig, projectRoot, cwd string) []string {
 	dirs := []string{
 		filepath.Join(userConfig, "themes"),
 		filepath.Join(projectRoot, ".opencode", "themes"),
@@ -79,14 +94,7 @@ func LoadThemesFromDirectories(userConfig, projectRoot, cwd string) error {
 	if cwd != projectRoot {
 		dirs = append(dirs, filepath.Join(cwd, ".opencode", "themes"))
 	}
-
-	for _, dir := range dirs {
-		if err := loadThemesFromDirectory(dir); err != nil {
-			fmt.Printf("Warning: Failed to load themes from %s: %v\n", dir, err)
-		}
-	}
-
-	return nil
+	return dirs
 }
 
 func loadThemesFromDirectory(dir string) error {
@@ -100,11 +108,11 @@ func loadThemesFromDirectory(dir string) error {
 	}
 
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+		if entry.IsDir() || !IsThemeFile(entry.Name()) {
 			continue
 		}
 
-		themeName := strings.TrimSuffix(entry.Name(), ".json")
+		themeName := themeName(entry.Name())
 		filePath := filepath.Join(dir, entry.Name())
 
 		data, err := os.ReadFile(filePath)
@@ -113,11 +121,15 @@ func loadThemesFromDirectory(dir string) error {
 			continue
 		}
 
-		theme, err := parseJSONTheme(themeName, data)
+		theme, err := parseThemeFile(themeName, filePath, data)
 		if err != nil {
 			fmt.Printf("Warning: Failed to parse theme %s: %v\n", filePath, err)
 			continue
 		}
+		if issues := CheckContrast(theme); len(issues) > 0 {
+			slog.Warn("Theme has low contrast colors",
+				"theme", themeName, "file", filePath, "issues", len(issues), "first", issues[0].String())
+		}
 
 		RegisterTheme(themeName, theme)
 	}
@@ -147,20 +159,35 @@ func parseJSONTheme(name string, data []byte) (Theme, error) {
 	for key, value := range jsonTheme.Theme {
 		resolved, err := resolver.resolveColor(key, value)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve color %s: %w", key, err)
+			return nil, &ColorError{Key: key, Op: "resolve", Err: err}
 		}
 		adaptiveColor, err := parseResolvedColor(resolved)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse color %s: %w", key, err)
+			return nil, &ColorError{Key: key, Op: "parse", Err: err}
 		}
 		if err := setThemeColor(theme, key, adaptiveColor); err != nil {
-			return nil, fmt.Errorf("failed to set color %s: %w", key, err)
+			return nil, &ColorError{Key: key, Op: "set", Err: err}
 		}
 	}
 
 	return theme, nil
 }
 
+// ColorError reports the theme key whose color could not be loaded.
+type ColorError struct {
+	Key string
+	Op  string // resolve, parse or set
+	Err error
+}
+
+func (e *ColorError) Error() string {
+	return fmt.Sprintf("failed to %s color %s: %v", e.Op, e.Key, e.Err)
+}
+
+func (e *ColorError) Unwrap() error {
+	return e.Err
+}
+
 type colorResolver struct {
 	colors  map[string]*colorRef
 	visited map[string]bool
@@ -40,6 +40,8 @@ func RegisterTheme(name string, theme Theme) {
 	// If this is the first theme, make it the default
 	if globalManager.currentName == "" {
 		globalManager.currentName = name
+	}
+	if globalManager.currentName == name {
 		globalManager.currentUsesAnsiCache = themeUsesAnsiColors(theme)
 	}
 }
@@ -0,0 +1,191 @@
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type vscodeTheme struct {
+	Colors      map[string]string `json:"colors"`
+	TokenColors []struct {
+		Scope    any `json:"scope"`
+		Settings struct {
+			Foreground string `json:"foreground"`
+		} `json:"settings"`
+	} `json:"tokenColors"`
+}
+
+// parseVSCode reads a VS Code color theme. Themes that only "include"
+// another file need that file imported instead.
+func parseVSCode(data []byte) (*palette, error) {
+	var vscode vscodeTheme
+	if err := json.Unmarshal(stripJSONC(data), &vscode); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	background, _ := normalizeHex(vscode.Colors["editor.background"], "")
+	// first returns the first of keys the theme sets, blended over the
+	// background when it is translucent
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := vscode.Colors[key]; ok {
+				if color, err := normalizeHex(value, background); err == nil {
+					return color
+				}
+			}
+		}
+		return ""
+	}
+	scopes := vscode.scopes(background)
+	token := func(targets ...string) string {
+		for _, target := range targets {
+			if color := scopes.match(target); color != "" {
+				return color
+			}
+		}
+		return ""
+	}
+	or := func(values ...string) string {
+		for _, value := range values {
+			if value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	p := &palette{
+		background:        background,
+		backgroundPanel:   first("sideBar.background", "panel.background", "editorWidget.background"),
+		backgroundElement: first("editor.lineHighlightBackground", "list.hoverBackground", "input.background"),
+		text:              first("editor.foreground", "foreground"),
+		textMuted:         first("editorLineNumber.foreground", "descriptionForeground"),
+		border:            first("panel.border", "editorGroup.border", "contrastBorder"),
+		borderActive:      first("focusBorder"),
+		borderSubtle:      first("editorIndentGuide.background", "editorIndentGuide.background1"),
+		link:              first("textLink.foreground"),
+		comment:           token("comment"),
+		keyword:           token("keyword.control", "storage.type"),
+		function:          token("entity.name.function"),
+		variable:          token("variable.other", "variable"),
+		str:               token("string.quoted", "string"),
+		number:            token("constant.numeric"),
+		types:             token("entity.name.type", "support.type"),
+		operator:          token("keyword.operator"),
+		punctuation:       token("punctuation"),
+		diffAddedBg:       first("diffEditor.insertedLineBackground", "diffEditor.insertedTextBackground"),
+		diffRemovedBg:     first("diffEditor.removedLineBackground", "diffEditor.removedTextBackground"),
+	}
+	// accents come from the terminal palette, then from the syntax colors
+	p.red = or(first("terminal.ansiRed", "editorError.foreground"), p.variable)
+	p.yellow = or(first("terminal.ansiYellow", "editorWarning.foreground"), p.types)
+	p.green = or(first("terminal.ansiGreen", "gitDecoration.addedResourceForeground"), p.str)
+	p.cyan = or(first("terminal.ansiCyan"), p.operator)
+	p.blue = or(first("terminal.ansiBlue", "textLink.foreground"), p.function)
+	p.purple = or(first("terminal.ansiMagenta"), p.keyword)
+	p.orange = p.number
+	return p, nil
+}
+
+type scopeColors map[string]string
+
+// match returns the color of the most specific scope selector that
+// target falls under.
+func (s scopeColors) match(target string) string {
+	for scope := target; scope != ""; {
+		if color, ok := s[scope]; ok {
+			return color
+		}
+		i := strings.LastIndex(scope, ".")
+		if i < 0 {
+			break
+		}
+		scope = scope[:i]
+	}
+	return ""
+}
+
+func (v vscodeTheme) scopes(background string) scopeColors {
+	scopes := scopeColors{}
+	for _, rule := range v.TokenColors {
+		color, err := normalizeHex(rule.Settings.Foreground, background)
+		if err != nil {
+			continue
+		}
+		var selectors []string
+		switch scope := rule.Scope.(type) {
+		case string:
+			selectors = strings.Split(scope, ",")
+		case []any:
+			for _, s := range scope {
+				if s, ok := s.(string); ok {
+					selectors = append(selectors, s)
+				}
+			}
+		}
+		for _, selector := range selectors {
+			selector = strings.TrimSpace(selector)
+			// descendant selectors such as "meta.tag string" are too
+			// specific to stand for a whole category
+			if selector == "" || strings.Contains(selector, " ") {
+				continue
+			}
+			if _, ok := scopes[selector]; !ok {
+				scopes[selector] = color
+			}
+		}
+	}
+	return scopes
+}
+
+// stripJSONC removes the comments and trailing commas VS Code allows in
+// its JSON files.
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && (data[i] != '*' || data[i+1] != '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			// drop a comma left before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
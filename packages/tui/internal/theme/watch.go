@@ -0,0 +1,134 @@
+package theme
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the several events editors cause for one save.
+const reloadDebounce = 100 * time.Millisecond
+
+// ReloadEvent describes one theme reloaded from disk.
+type ReloadEvent struct {
+	Name string
+	// Path is the file that was loaded, empty when the theme was removed
+	Path string
+	// Err is set when the file could not be loaded; the previous version of
+	// the theme stays registered. A *ColorError names the offending key.
+	Err error
+	// Removed is set when no custom file is left; a built-in theme of the
+	// same name is restored, anything else stays registered
+	Removed bool
+	// Active is set when the reloaded theme is the current one
+	Active bool
+	// Contrast lists the reloaded theme's readability problems
+	Contrast []ContrastIssue
+}
+
+// Watcher reloads custom themes when their files change.
+type Watcher struct {
+	dirs    []string
+	watcher *fsnotify.Watcher
+}
+
+// Watch watches the theme directories, lowest priority first as returned by
+// Directories. Directories that don't exist yet are not watched.
+func Watch(dirs []string) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	return &Watcher{dirs: dirs, watcher: watcher}, nil
+}
+
+// Next blocks until theme files change, reloads them and reports what
+// happened. It returns false once the watcher is closed.
+func (w *Watcher) Next() ([]ReloadEvent, bool) {
+	changed := map[string]bool{}
+	var quiet <-chan time.Time
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return nil, false
+			}
+			if !IsThemeFile(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			changed[themeName(event.Name)] = true
+			quiet = time.After(reloadDebounce)
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil, false
+			}
+		case <-quiet:
+			events := make([]ReloadEvent, 0, len(changed))
+			for name := range changed {
+				events = append(events, w.reload(name))
+			}
+			return events, true
+		}
+	}
+}
+
+// reload registers the highest priority file for name, following the same
+// override order as LoadThemesFromDirectories.
+func (w *Watcher) reload(name string) ReloadEvent {
+	event := ReloadEvent{Name: name}
+	for i := len(w.dirs) - 1; i >= 0; i-- {
+		for _, ext := range themeExtensions {
+			file := filepath.Join(w.dirs[i], name+ext)
+			data, err := os.ReadFile(file)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			event.Path = file
+			if err == nil {
+				var theme Theme
+				if theme, err = parseThemeFile(name, file, data); err == nil {
+					event.Active = register(name, theme)
+					event.Contrast = CheckContrast(theme)
+				}
+			}
+			event.Err = err
+			return event
+		}
+	}
+
+	event.Removed = true
+	if data, err := themesFS.ReadFile(path.Join("themes", name+".json")); err == nil {
+		if theme, err := parseJSONTheme(name, data); err == nil {
+			event.Active = register(name, theme)
+		}
+	}
+	return event
+}
+
+// register replaces a theme, reapplying it when it is the current one.
+func register(name string, theme Theme) bool {
+	RegisterTheme(name, theme)
+	if CurrentThemeName() != name {
+		return false
+	}
+	SetTheme(name)
+	return true
+}
+
+// Close stops watching; a blocked Next returns false.
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
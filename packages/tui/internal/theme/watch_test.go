@@ -0,0 +1,82 @@
+package theme
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func nextReload(t *testing.T, w *Watcher) []ReloadEvent {
+	t.Helper()
+	result := make(chan []ReloadEvent, 1)
+	go func() {
+		events, _ := w.Next()
+		result <- events
+	}()
+	select {
+	case events := <-result:
+		return events
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a theme reload")
+		return nil
+	}
+}
+
+func TestWatchReloadsActiveTheme(t *testing.T) {
+	if err := LoadThemesFromJSON(); err != nil {
+		t.Fatal(err)
+	}
+	user := t.TempDir()
+	project := t.TempDir()
+	file := filepath.Join(project, "live.json")
+	os.WriteFile(file, []byte(`{"theme": {"primary": "#111111"}}`), 0644)
+	loadThemesFromDirectory(project)
+	if err := SetTheme("live"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTheme("opencode")
+
+	w, err := Watch([]string{user, project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	os.WriteFile(file, []byte(`{"theme": {"primary": "#222222"}}`), 0644)
+	events := nextReload(t, w)
+	if len(events) != 1 || events[0].Name != "live" || events[0].Err != nil || !events[0].Active {
+		t.Fatalf("events = %+v", events)
+	}
+	if got := CurrentTheme().Primary().Dark; got == nil || colorHex(got) != "#222222" {
+		t.Errorf("primary = %v, want the reloaded color", got)
+	}
+
+	// a lower priority file does not override the project theme
+	os.WriteFile(filepath.Join(user, "live.json"), []byte(`{"theme": {"primary": "#333333"}}`), 0644)
+	nextReload(t, w)
+	if got := colorHex(CurrentTheme().Primary().Dark); got != "#222222" {
+		t.Errorf("primary = %s, want the project theme to keep priority", got)
+	}
+
+	os.WriteFile(file, []byte(`{"theme": {"primary": "missing"}}`), 0644)
+	events = nextReload(t, w)
+	var colorErr *ColorError
+	if len(events) != 1 || !errors.As(events[0].Err, &colorErr) || colorErr.Key != "primary" {
+		t.Fatalf("expected a color error naming the key, got %+v", events)
+	}
+	if got := colorHex(CurrentTheme().Primary().Dark); got != "#222222" {
+		t.Errorf("primary = %s, want the last good version kept", got)
+	}
+}
+
+func colorHex(c interface{ RGBA() (r, g, b, a uint32) }) string {
+	r, g, b, _ := c.RGBA()
+	const hex = "0123456789abcdef"
+	out := []byte{'#'}
+	for _, v := range []uint32{r >> 8, g >> 8, b >> 8} {
+		out = append(out, hex[v>>4], hex[v&0xf])
+	}
+	return string(out)
+}
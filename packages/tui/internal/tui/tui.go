@@ -3,11 +3,13 @@ package tui
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,7 +28,9 @@ import (
 	"github.com/sst/opencode/internal/components/modal"
 	"github.com/sst/opencode/internal/components/status"
 	"github.com/sst/opencode/internal/components/toast"
+	"github.com/sst/opencode/internal/exporter"
 	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/replay"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
 	"github.com/sst/opencode/internal/util"
@@ -77,6 +81,7 @@ type Model struct {
 	interruptKeyState    InterruptKeyState
 	exitKeyState         ExitKeyState
 	messagesRight        bool
+	replay               *replay.Player
 }
 
 func (a Model) Init() tea.Cmd {
@@ -92,6 +97,8 @@ func (a Model) Init() tea.Cmd {
 	cmds = append(cmds, a.status.Init())
 	cmds = append(cmds, a.completions.Init())
 	cmds = append(cmds, a.toastManager.Init())
+	cmds = append(cmds, a.app.WaitForEvents())
+	cmds = append(cmds, a.app.WatchThemes())
 
 	return tea.Batch(cmds...)
 }
@@ -104,17 +111,9 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyPressMsg:
 		keyString := msg.String()
 
-		if a.app.CurrentPermission.ID != "" {
+		if a.app.CurrentPermission.ID != "" && a.modal == nil {
 			if keyString == "enter" || keyString == "esc" || keyString == "a" {
-				sessionID := a.app.CurrentPermission.SessionID
-				permissionID := a.app.CurrentPermission.ID
 				a.editor.Focus()
-				a.app.Permissions = a.app.Permissions[1:]
-				if len(a.app.Permissions) > 0 {
-					a.app.CurrentPermission = a.app.Permissions[0]
-				} else {
-					a.app.CurrentPermission = opencode.Permission{}
-				}
 				response := opencode.SessionPermissionRespondParamsResponseOnce
 				switch keyString {
 				case "enter":
@@ -124,21 +123,7 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case "esc":
 					response = opencode.SessionPermissionRespondParamsResponseReject
 				}
-
-				return a, func() tea.Msg {
-					resp, err := a.app.Client.Session.Permissions.Respond(
-						context.Background(),
-						sessionID,
-						permissionID,
-						opencode.SessionPermissionRespondParams{Response: opencode.F(response)},
-					)
-					if err != nil {
-						slog.Error("Failed to respond to permission request", "error", err)
-						return toast.NewErrorToast("Failed to respond to permission request")()
-					}
-					slog.Debug("Responded to permission request", "response", resp)
-					return nil
-				}
+				return a, a.app.RespondToPermissions([]opencode.Permission{a.app.CurrentPermission}, response)
 			}
 		}
 
@@ -185,6 +170,27 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, cmd
 		}
 
+		// Conversation search takes the keyboard until it is closed; the
+		// scroll commands keep working so results can be read in context
+		if a.messages.Searching() && !a.isScrollKey(msg) {
+			updated, cmd := a.messages.Update(msg)
+			a.messages = updated.(chat.MessagesComponent)
+			return a, cmd
+		}
+
+		// A focused message block takes the keys that act on it; anything
+		// else returns focus to the editor and is handled as usual
+		if a.messages.BlockFocused() {
+			updated, cmd, handled := a.messages.UpdateFocusedBlock(msg)
+			a.messages = updated.(chat.MessagesComponent)
+			if handled {
+				return a, cmd
+			}
+			// no longer focused, so this handles the key as usual
+			updatedModel, next := a.Update(msg)
+			return updatedModel, tea.Batch(cmd, next)
+		}
+
 		// 2. Check for commands that require leader
 		if a.app.IsLeaderSequence {
 			matches := a.app.Commands.Matches(msg, a.app.IsLeaderSequence)
@@ -194,6 +200,14 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Outside insert mode the editor's Vim keybindings take printable
+		// keys as commands, and esc leaves insert and visual mode
+		if !a.showCompletionDialog && a.editor.WantsKey(msg) {
+			updated, cmd := a.editor.Update(msg)
+			a.editor = updated.(chat.EditorComponent)
+			return a, cmd
+		}
+
 		// 3. Handle completions trigger
 		if keyString == "/" &&
 			!a.showCompletionDialog &&
@@ -451,10 +465,48 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.editor = updated.(chat.EditorComponent)
 		cmds = append(cmds, cmd)
 	case app.SessionClearedMsg:
+		cmds = append(cmds, a.swapDraft(""))
 		a.app.Session = &opencode.Session{}
 		a.app.Messages = []app.Message{}
+		a.app.Transcript = ""
+		a.replay = nil
+	case replay.LoadedMsg:
+		a.replay = msg.Replay
+		a.app.Session = &msg.Transcript.Session
+		a.app.Transcript = msg.Path
+		a.app.Messages = msg.Transcript.Messages
+		notice := "Viewing " + msg.Path + " read-only"
+		if msg.Replay != nil {
+			a.app.Messages = []app.Message{}
+			notice = "Replaying " + msg.Path
+			cmds = append(cmds, msg.Replay.Next())
+		}
+		cmds = append(cmds, util.CmdHandler(app.SessionLoadedMsg{}), toast.NewInfoToast(notice))
+	case replay.StepMsg:
+		if a.replay == nil || msg.PlayerID != a.replay.ID {
+			// left over from a replay that was replaced or cleared
+			return a, nil
+		}
+		msg.Step.Apply(a.app)
+		if msg.Done {
+			a.replay = nil
+			cmds = append(cmds, toast.NewInfoToast("Replay finished"))
+		} else {
+			cmds = append(cmds, a.replay.Next())
+		}
 	case dialog.CompletionDialogCloseMsg:
 		a.showCompletionDialog = false
+	case app.EventBatchMsg:
+		// Handle each event in arrival order, as if it were sent on its own
+		var model tea.Model = a
+		for _, event := range a.app.ProcessEvents(msg.Events) {
+			model, cmd = model.Update(event)
+			cmds = append(cmds, cmd)
+		}
+		if msg.Watch {
+			cmds = append(cmds, a.app.WaitForEvents())
+		}
+		return model, tea.Batch(cmds...)
 	case opencode.EventListResponseEventInstallationUpdated:
 		return a, toast.NewSuccessToast(
 			"opencode updated to "+msg.Properties.Version+", restart to apply.",
@@ -479,144 +531,25 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case opencode.EventListResponseEventMessagePartUpdated:
 		slog.Debug("message part updated", "message", msg.Properties.Part.MessageID, "part", msg.Properties.Part.ID)
-		if msg.Properties.Part.SessionID == a.app.Session.ID {
-			messageIndex := slices.IndexFunc(a.app.Messages, func(m app.Message) bool {
-				switch casted := m.Info.(type) {
-				case opencode.UserMessage:
-					return casted.ID == msg.Properties.Part.MessageID
-				case opencode.AssistantMessage:
-					return casted.ID == msg.Properties.Part.MessageID
-				}
-				return false
-			})
-			if messageIndex > -1 {
-				message := a.app.Messages[messageIndex]
-				partIndex := slices.IndexFunc(message.Parts, func(p opencode.PartUnion) bool {
-					switch casted := p.(type) {
-					case opencode.TextPart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.ReasoningPart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.FilePart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.ToolPart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.StepStartPart:
-						return casted.ID == msg.Properties.Part.ID
-					case opencode.StepFinishPart:
-						return casted.ID == msg.Properties.Part.ID
-					}
-					return false
-				})
-				if partIndex > -1 {
-					message.Parts[partIndex] = msg.Properties.Part.AsUnion()
-				}
-				if partIndex == -1 {
-					message.Parts = append(message.Parts, msg.Properties.Part.AsUnion())
-				}
-				a.app.Messages[messageIndex] = message
-			}
-		}
+		a.app.UpdatePart(msg.Properties.Part)
 	case opencode.EventListResponseEventMessagePartRemoved:
 		slog.Debug("message part removed", "session", msg.Properties.SessionID, "message", msg.Properties.MessageID, "part", msg.Properties.PartID)
-		if msg.Properties.SessionID == a.app.Session.ID {
-			messageIndex := slices.IndexFunc(a.app.Messages, func(m app.Message) bool {
-				switch casted := m.Info.(type) {
-				case opencode.UserMessage:
-					return casted.ID == msg.Properties.MessageID
-				case opencode.AssistantMessage:
-					return casted.ID == msg.Properties.MessageID
-				}
-				return false
-			})
-			if messageIndex > -1 {
-				message := a.app.Messages[messageIndex]
-				partIndex := slices.IndexFunc(message.Parts, func(p opencode.PartUnion) bool {
-					switch casted := p.(type) {
-					case opencode.TextPart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.ReasoningPart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.FilePart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.ToolPart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.StepStartPart:
-						return casted.ID == msg.Properties.PartID
-					case opencode.StepFinishPart:
-						return casted.ID == msg.Properties.PartID
-					}
-					return false
-				})
-				if partIndex > -1 {
-					// Remove the part at partIndex
-					message.Parts = append(message.Parts[:partIndex], message.Parts[partIndex+1:]...)
-					a.app.Messages[messageIndex] = message
-				}
-			}
-		}
+		a.app.RemovePart(msg.Properties.SessionID, msg.Properties.MessageID, msg.Properties.PartID)
 	case opencode.EventListResponseEventMessageRemoved:
 		slog.Debug("message removed", "session", msg.Properties.SessionID, "message", msg.Properties.MessageID)
-		if msg.Properties.SessionID == a.app.Session.ID {
-			messageIndex := slices.IndexFunc(a.app.Messages, func(m app.Message) bool {
-				switch casted := m.Info.(type) {
-				case opencode.UserMessage:
-					return casted.ID == msg.Properties.MessageID
-				case opencode.AssistantMessage:
-					return casted.ID == msg.Properties.MessageID
-				}
-				return false
-			})
-			if messageIndex > -1 {
-				a.app.Messages = append(a.app.Messages[:messageIndex], a.app.Messages[messageIndex+1:]...)
-			}
-		}
+		a.app.RemoveMessage(msg.Properties.SessionID, msg.Properties.MessageID)
 	case opencode.EventListResponseEventMessageUpdated:
-		if msg.Properties.Info.SessionID == a.app.Session.ID {
-			matchIndex := slices.IndexFunc(a.app.Messages, func(m app.Message) bool {
-				switch casted := m.Info.(type) {
-				case opencode.UserMessage:
-					return casted.ID == msg.Properties.Info.ID
-				case opencode.AssistantMessage:
-					return casted.ID == msg.Properties.Info.ID
-				}
-				return false
-			})
-
-			if matchIndex > -1 {
-				match := a.app.Messages[matchIndex]
-				a.app.Messages[matchIndex] = app.Message{
-					Info:  msg.Properties.Info.AsUnion(),
-					Parts: match.Parts,
-				}
-			}
-
-			if matchIndex == -1 {
-				a.app.Messages = append(a.app.Messages, app.Message{
-					Info:  msg.Properties.Info.AsUnion(),
-					Parts: []opencode.PartUnion{},
-				})
-			}
-		}
+		a.app.UpdateMessage(msg.Properties.Info)
 	case opencode.EventListResponseEventPermissionUpdated:
 		slog.Debug("permission updated", "session", msg.Properties.SessionID, "permission", msg.Properties.ID)
-		a.app.Permissions = append(a.app.Permissions, msg.Properties)
-		a.app.CurrentPermission = a.app.Permissions[0]
+		if cmd, ok := a.app.ApplyPermissionPolicy(msg.Properties); ok {
+			cmds = append(cmds, cmd)
+			break
+		}
+		a.app.QueuePermission(msg.Properties)
 		a.editor.Blur()
 	case opencode.EventListResponseEventPermissionReplied:
-		index := slices.IndexFunc(a.app.Permissions, func(p opencode.Permission) bool {
-			return p.ID == msg.Properties.PermissionID
-		})
-		if index > -1 {
-			a.app.Permissions = append(a.app.Permissions[:index], a.app.Permissions[index+1:]...)
-		}
-		if a.app.CurrentPermission.ID == msg.Properties.PermissionID {
-			if len(a.app.Permissions) > 0 {
-				a.app.CurrentPermission = a.app.Permissions[0]
-			} else {
-				a.app.CurrentPermission = opencode.Permission{}
-			}
-		}
+		a.app.DequeuePermission(msg.Properties.PermissionID)
 	case opencode.EventListResponseEventSessionError:
 		switch err := msg.Properties.Error.AsUnion().(type) {
 		case nil:
@@ -650,8 +583,11 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			slog.Error("Failed to list messages", "error", err.Error())
 			return a, toast.NewErrorToast("Failed to open session")
 		}
+		cmds = append(cmds, a.swapDraft(msg.ID))
 		a.app.Session = msg
 		a.app.Messages = messages
+		a.app.Transcript = ""
+		a.replay = nil
 		cmds = append(cmds, util.CmdHandler(app.SessionLoadedMsg{}))
 		return a, tea.Batch(cmds...)
 	case app.SessionCreatedMsg:
@@ -661,6 +597,9 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.messages = updated.(chat.MessagesComponent)
 		cmds = append(cmds, cmd)
 	case dialog.RestoreToMessageMsg:
+		if a.app.Transcript != "" {
+			return a, toast.NewErrorToast(transcriptReadOnly)
+		}
 		cmd := func() tea.Msg {
 			// Find next user message after target
 			var nextMessageID string
@@ -696,10 +635,10 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case app.ModelSelectedMsg:
 		a.app.Provider = &msg.Provider
 		a.app.Model = &msg.Model
-		a.app.State.AgentModel[a.app.Agent().Name] = app.AgentModel{
+		a.app.State.SetAgentModel(a.app.Project.Worktree, a.app.Agent().Name, app.AgentModel{
 			ProviderID: msg.Provider.ID,
 			ModelID:    msg.Model.ID,
-		}
+		})
 		a.app.State.UpdateModelUsage(msg.Provider.ID, msg.Model.ID)
 		cmds = append(cmds, a.app.SaveState())
 	case app.AgentSelectedMsg:
@@ -709,6 +648,36 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case dialog.ThemeSelectedMsg:
 		a.app.State.Theme = msg.ThemeName
 		cmds = append(cmds, a.app.SaveState())
+	case app.ThemesReloadedMsg:
+		cmds = append(cmds, a.app.WatchThemes())
+		for _, event := range msg.Events {
+			var colorErr *theme.ColorError
+			switch {
+			case errors.As(event.Err, &colorErr):
+				cmds = append(cmds, toast.NewErrorToast(
+					fmt.Sprintf("%s: %v", colorErr.Key, colorErr.Err),
+					toast.WithTitle("Theme "+event.Name),
+				))
+			case event.Err != nil:
+				cmds = append(cmds, toast.NewErrorToast(
+					event.Err.Error(),
+					toast.WithTitle("Theme "+event.Name),
+				))
+			case event.Active:
+				// re-render everything styled with the old colors
+				cmds = append(cmds,
+					util.CmdHandler(dialog.ThemeSelectedMsg{ThemeName: event.Name}),
+					toast.NewInfoToast("Reloaded theme "+event.Name),
+				)
+			}
+			if len(event.Contrast) > 0 {
+				cmds = append(cmds, toast.NewWarningToast(
+					fmt.Sprintf("%s\n%d low contrast pairs, see `opencode theme check %s`",
+						event.Contrast[0], len(event.Contrast), event.Name),
+					toast.WithTitle("Theme "+event.Name),
+				))
+			}
+		}
 	case toast.ShowToastMsg:
 		tm, cmd := a.toastManager.Update(msg)
 		a.toastManager = tm
@@ -739,7 +708,7 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// API
 	case api.Request:
-		slog.Info("api", "path", msg.Path)
+		slog.Info("api", "id", msg.ID, "path", msg.Path)
 		var response any = true
 		switch msg.Path {
 		case "/tui/open-help":
@@ -790,7 +759,7 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			if command.Name == "" {
 				slog.Error("Invalid command passed to /tui/execute-command", "command", body.Command)
-				return a, nil
+				return a, api.Reply(context.Background(), msg, fmt.Errorf("unknown command: %s", body.Command))
 			}
 			updated, cmd := a.executeCommand(commands.Command(command))
 			a = updated.(Model)
@@ -831,14 +800,14 @@ func (a Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			default:
 				slog.Error("Invalid toast variant", "variant", body.Variant)
-				return a, nil
+				return a, api.Reply(context.Background(), msg, fmt.Errorf("invalid toast variant: %s", body.Variant))
 			}
 			cmds = append(cmds, toastCmd)
 
 		default:
-			break
+			response = fmt.Errorf("unknown path: %s", msg.Path)
 		}
-		cmds = append(cmds, api.Reply(context.Background(), a.app.Client, response))
+		cmds = append(cmds, api.Reply(context.Background(), msg, response))
 	}
 
 	s, cmd := a.status.Update(msg)
@@ -904,8 +873,13 @@ func (a Model) View() (string, *tea.Cursor) {
 	}
 
 	cursor := a.editor.Cursor()
-	cursor.Position.X += editorX
-	cursor.Position.Y += editorY
+	if a.messages.Searching() {
+		// the search bar draws its own cursor
+		cursor = nil
+	} else {
+		cursor.Position.X += editorX
+		cursor.Position.Y += editorY
+	}
 
 	return mainLayout + "\n" + a.status.View(), cursor
 }
@@ -914,6 +888,14 @@ func (a Model) Cleanup() {
 	// Cleanup status component
 	a.status.Cleanup()
 
+	// Keep what was typed but not sent for the next launch
+	if a.app != nil && a.app.Transcript == "" {
+		a.app.State.SetDraft(a.app.Project.Worktree, a.app.Session.ID, a.editor.Prompt())
+		if err := app.SaveState(a.app.StatePath, a.app.State); err != nil {
+			slog.Error("Failed to save state", "error", err)
+		}
+	}
+
 	// Cleanup app resources (including SSE event stream)
 	if a.app != nil {
 		a.app.Cleanup()
@@ -1108,8 +1090,27 @@ func (a Model) chat() (string, int, int) {
 	return mainLayout, editorX + 5, editorY + 2
 }
 
+// isScrollKey reports whether a key press is bound to scrolling the messages.
+func (a Model) isScrollKey(msg tea.KeyPressMsg) bool {
+	for _, command := range a.app.Commands.Matches(msg, false) {
+		switch command.Name {
+		case commands.MessagesPageUpCommand,
+			commands.MessagesPageDownCommand,
+			commands.MessagesHalfPageUpCommand,
+			commands.MessagesHalfPageDownCommand,
+			commands.MessagesFirstCommand,
+			commands.MessagesLastCommand:
+			return true
+		}
+	}
+	return false
+}
+
+// transcriptReadOnly is shown for commands that would change the session an
+// imported transcript was exported from.
+const transcriptReadOnly = "Imported transcripts are read-only"
+
 func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
 	cmds := []tea.Cmd{
 		util.CmdHandler(commands.CommandExecutedMsg(command)),
 	}
@@ -1181,6 +1182,14 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 	case commands.SessionListCommand:
 		sessionDialog := dialog.NewSessionDialog(a.app)
 		a.modal = sessionDialog
+	case commands.PermissionListCommand:
+		permissionDialog := dialog.NewPermissionDialog(a.app)
+		a.modal = permissionDialog
+		cmds = append(cmds, permissionDialog.Init())
+	case commands.AppLogsCommand:
+		logDialog := dialog.NewLogDialog(a.app)
+		a.modal = logDialog
+		cmds = append(cmds, logDialog.Init())
 	case commands.SessionTimelineCommand:
 		if a.app.Session.ID == "" {
 			return a, toast.NewErrorToast("No active session")
@@ -1191,6 +1200,9 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 		if a.app.Session.ID == "" {
 			return a, nil
 		}
+		if a.app.Transcript != "" {
+			return a, toast.NewErrorToast(transcriptReadOnly)
+		}
 		response, err := a.app.Client.Session.Share(context.Background(), a.app.Session.ID, opencode.SessionShareParams{})
 		if err != nil {
 			slog.Error("Failed to share session", "error", err)
@@ -1203,6 +1215,9 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 		if a.app.Session.ID == "" {
 			return a, nil
 		}
+		if a.app.Transcript != "" {
+			return a, toast.NewErrorToast(transcriptReadOnly)
+		}
 		_, err := a.app.Client.Session.Unshare(context.Background(), a.app.Session.ID, opencode.SessionUnshareParams{})
 		if err != nil {
 			slog.Error("Failed to unshare session", "error", err)
@@ -1214,12 +1229,18 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 		if a.app.Session.ID == "" {
 			return a, nil
 		}
+		if a.app.Transcript != "" {
+			return a, toast.NewErrorToast(transcriptReadOnly)
+		}
 		a.app.Cancel(context.Background(), a.app.Session.ID)
 		return a, nil
 	case commands.SessionCompactCommand:
 		if a.app.Session.ID == "" {
 			return a, nil
 		}
+		if a.app.Transcript != "" {
+			return a, toast.NewErrorToast(transcriptReadOnly)
+		}
 		// TODO: block until compaction is complete
 		a.app.CompactSession(context.Background())
 	case commands.SessionChildCycleCommand:
@@ -1349,46 +1370,51 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 			return a, toast.NewInfoToast("No messages to export.")
 		}
 
-		// Format to Markdown
-		markdownContent := formatConversationToMarkdown(messages)
-
-		// Check if EDITOR is set
-		editor := os.Getenv("EDITOR")
-		if editor == "" {
-			return a, toast.NewErrorToast("No EDITOR set, can't open editor")
+		transcript := exporter.Transcript{
+			Session:  *a.app.Session,
+			Messages: messages,
+			Theme:    theme.CurrentTheme(),
+			Dark:     styles.Terminal == nil || styles.Terminal.BackgroundIsDark,
 		}
 
-		// Create and write to temp file
-		tmpfile, err := os.CreateTemp("", "conversation-*.md")
-		if err != nil {
-			slog.Error("Failed to create temp file", "error", err)
-			return a, toast.NewErrorToast("Failed to create temporary file.")
+		args := strings.TrimSpace(command.Args)
+		if args == "" {
+			cmds = append(cmds, a.openExportInEditor(transcript))
+			break
 		}
 
-		_, err = tmpfile.WriteString(markdownContent)
-		if err != nil {
-			slog.Error("Failed to write to temp file", "error", err)
-			tmpfile.Close()
-			os.Remove(tmpfile.Name())
-			return a, toast.NewErrorToast("Failed to write conversation to file.")
+		// /export <format> [file] or /export <file>, where the file may
+		// contain spaces
+		format, path := "", args
+		first, rest, _ := strings.Cut(args, " ")
+		if _, ok := exporter.Get(first); ok {
+			format = first
+			path = strings.TrimSpace(rest)
+			if path == "" {
+				path = exporter.DefaultPath(transcript.Session, format)
+			}
 		}
-		tmpfile.Close()
-
-		// Open in editor
-		parts := strings.Fields(editor)
-		c := exec.Command(parts[0], append(parts[1:], tmpfile.Name())...) //nolint:gosec
-		c.Stdin = os.Stdin
-		c.Stdout = os.Stdout
-		c.Stderr = os.Stderr
-		cmd = tea.ExecProcess(c, func(err error) tea.Msg {
-			if err != nil {
-				slog.Error("Failed to open editor for conversation", "error", err)
+		if err := exporter.WriteFile(path, format, transcript); err != nil {
+			slog.Error("Failed to export session", "path", path, "error", err)
+			return a, toast.NewErrorToast(err.Error(), toast.WithTitle("Export failed"))
+		}
+		cmds = append(cmds, toast.NewSuccessToast("Exported to "+path))
+	case commands.SessionImportCommand:
+		// /import <file.json> [replay [speed]]
+		args := strings.Fields(command.Args)
+		if len(args) == 0 {
+			return a, toast.NewInfoToast("Usage: /import <file.json> [replay [speed]]")
+		}
+		replaying := len(args) > 1 && args[1] == "replay"
+		opts := replay.Options{Speed: 1}
+		if len(args) > 2 {
+			speed, err := strconv.ParseFloat(strings.TrimSuffix(args[2], "x"), 64)
+			if err != nil || speed <= 0 {
+				return a, toast.NewErrorToast("Invalid replay speed: " + args[2])
 			}
-			// Clean up the file after editor closes
-			os.Remove(tmpfile.Name())
-			return nil
-		})
-		cmds = append(cmds, cmd)
+			opts.Speed = speed
+		}
+		cmds = append(cmds, replay.Load(args[0], replaying, opts))
 	case commands.ToolDetailsCommand:
 		message := "Tool details are now visible"
 		if a.messages.ToolDetailsVisible() {
@@ -1443,6 +1469,33 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 		updated, cmd := a.editor.Newline()
 		a.editor = updated.(chat.EditorComponent)
 		cmds = append(cmds, cmd)
+	case commands.InputUndoCommand:
+		updated, cmd := a.editor.Undo()
+		a.editor = updated.(chat.EditorComponent)
+		cmds = append(cmds, cmd)
+	case commands.InputRedoCommand:
+		updated, cmd := a.editor.Redo()
+		a.editor = updated.(chat.EditorComponent)
+		cmds = append(cmds, cmd)
+	case commands.InputHistorySearchCommand:
+		historyDialog := dialog.NewPromptHistoryDialog(a.app)
+		a.modal = historyDialog
+		cmds = append(cmds, historyDialog.Init())
+	case commands.MessagesPreviousCommand:
+		updated, cmd := a.messages.FocusPreviousBlock()
+		a.messages = updated.(chat.MessagesComponent)
+		cmds = append(cmds, cmd)
+	case commands.MessagesNextCommand:
+		updated, cmd := a.messages.FocusNextBlock()
+		a.messages = updated.(chat.MessagesComponent)
+		cmds = append(cmds, cmd)
+	case commands.MessagesSearchCommand:
+		if a.app.Session.ID == "" {
+			return a, nil
+		}
+		updated, cmd := a.messages.Search()
+		a.messages = updated.(chat.MessagesComponent)
+		cmds = append(cmds, cmd)
 	case commands.MessagesFirstCommand:
 		updated, cmd := a.messages.GotoTop()
 		a.messages = updated.(chat.MessagesComponent)
@@ -1472,10 +1525,16 @@ func (a Model) executeCommand(command commands.Command) (tea.Model, tea.Cmd) {
 		a.messages = updated.(chat.MessagesComponent)
 		cmds = append(cmds, cmd)
 	case commands.MessagesUndoCommand:
+		if a.app.Transcript != "" {
+			return a, toast.NewErrorToast(transcriptReadOnly)
+		}
 		updated, cmd := a.messages.UndoLastMessage()
 		a.messages = updated.(chat.MessagesComponent)
 		cmds = append(cmds, cmd)
 	case commands.MessagesRedoCommand:
+		if a.app.Transcript != "" {
+			return a, toast.NewErrorToast(transcriptReadOnly)
+		}
 		updated, cmd := a.messages.RedoLastMessage()
 		a.messages = updated.(chat.MessagesComponent)
 		cmds = append(cmds, cmd)
@@ -1503,6 +1562,7 @@ func NewModel(app *app.App) tea.Model {
 
 	messages := chat.NewMessagesComponent(app)
 	editor := chat.NewEditorComponent(app)
+	editor.LoadDraft(app.State.Draft(app.Project.Worktree, app.Session.ID))
 	completions := dialog.NewCompletionDialogComponent("/", commandProvider)
 
 	var leaderBinding *key.Binding
@@ -1531,43 +1591,49 @@ func NewModel(app *app.App) tea.Model {
 	return model
 }
 
-func formatConversationToMarkdown(messages []app.Message) string {
-	var builder strings.Builder
-
-	builder.WriteString("# Conversation History\n\n")
-
-	for _, msg := range messages {
-		builder.WriteString("---\n\n")
-
-		var role string
-		var timestamp time.Time
+// swapDraft keeps what is in the editor as the draft of the current session
+// and loads the draft of the session being opened.
+func (a Model) swapDraft(sessionID string) tea.Cmd {
+	worktree := a.app.Project.Worktree
+	if a.app.Transcript == "" {
+		a.app.State.SetDraft(worktree, a.app.Session.ID, a.editor.Prompt())
+	}
+	a.editor.LoadDraft(a.app.State.Draft(worktree, sessionID))
+	return a.app.SaveState()
+}
 
-		switch info := msg.Info.(type) {
-		case opencode.UserMessage:
-			role = "User"
-			timestamp = time.UnixMilli(int64(info.Time.Created))
-		case opencode.AssistantMessage:
-			role = "Assistant"
-			timestamp = time.UnixMilli(int64(info.Time.Created))
-		default:
-			continue
-		}
+// openExportInEditor writes the transcript as Markdown to a temporary file and
+// opens it in $EDITOR, removing the file when the editor exits.
+func (a Model) openExportInEditor(transcript exporter.Transcript) tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		return toast.NewErrorToast("No EDITOR set, can't open editor. Use /export <format> <file> instead.")
+	}
 
-		builder.WriteString(
-			fmt.Sprintf("**%s** (*%s*)\n\n", role, timestamp.Format("2006-01-02 15:04:05")),
-		)
+	tmpfile, err := os.CreateTemp("", "conversation-*.md")
+	if err != nil {
+		slog.Error("Failed to create temp file", "error", err)
+		return toast.NewErrorToast("Failed to create temporary file.")
+	}
+	tmpfile.Close()
 
-		for _, part := range msg.Parts {
-			switch p := part.(type) {
-			case opencode.TextPart:
-				builder.WriteString(p.Text + "\n\n")
-			case opencode.FilePart:
-				builder.WriteString(fmt.Sprintf("[File: %s]\n\n", p.Filename))
-			case opencode.ToolPart:
-				builder.WriteString(fmt.Sprintf("[Tool: %s]\n\n", p.Tool))
-			}
-		}
+	if err := exporter.WriteFile(tmpfile.Name(), "markdown", transcript); err != nil {
+		slog.Error("Failed to write to temp file", "error", err)
+		os.Remove(tmpfile.Name())
+		return toast.NewErrorToast("Failed to write conversation to file.")
 	}
 
-	return builder.String()
+	parts := strings.Fields(editor)
+	c := exec.Command(parts[0], append(parts[1:], tmpfile.Name())...) //nolint:gosec
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		if err != nil {
+			slog.Error("Failed to open editor for conversation", "error", err)
+		}
+		// Clean up the file after editor closes
+		os.Remove(tmpfile.Name())
+		return nil
+	})
 }
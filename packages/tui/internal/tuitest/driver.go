@@ -0,0 +1,427 @@
+// Package tuitest drives the TUI model against a testserver for end-to-end
+// tests: key presses are fed into the model, the commands it returns are run
+// the way the bubbletea runtime would, and the rendered view can be compared
+// with golden snapshots.
+package tuitest
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode-api-go/option"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/backend"
+	"github.com/sst/opencode/internal/connection"
+	"github.com/sst/opencode/internal/testserver"
+	"github.com/sst/opencode/internal/tui"
+)
+
+var update = flag.Bool("update", false, "rewrite golden snapshots")
+
+// quiet is how long the model has to go without a message to count as
+// settled.
+const quiet = 50 * time.Millisecond
+
+type options struct {
+	width, height int
+	backend       string
+	session       string
+}
+
+// Option configures a Driver.
+type Option func(*options)
+
+// WithSize sets the terminal size. The default is 100x30.
+func WithSize(width, height int) Option {
+	return func(o *options) {
+		o.width = width
+		o.height = height
+	}
+}
+
+// WithBackend selects the API backend by name.
+func WithBackend(name string) Option {
+	return func(o *options) {
+		o.backend = name
+	}
+}
+
+// WithSession starts the TUI in an existing session, as --session does.
+func WithSession(id string) Option {
+	return func(o *options) {
+		o.session = id
+	}
+}
+
+// Driver is a TUI model connected to a testserver, or to none when it was
+// started with NewOffline.
+type Driver struct {
+	Server *testserver.Server
+	App    *app.App
+
+	t     testing.TB
+	model tea.Model
+	msgs  chan tea.Msg
+	done  chan struct{}
+	quit  bool
+}
+
+// New starts the TUI against server. The app's state is kept in a temporary
+// directory, and everything is shut down when the test ends.
+func New(t testing.TB, server *testserver.Server, opts ...Option) *Driver {
+	t.Helper()
+	o := options{width: 100, height: 30}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return start(t, server, NewApp(t, server, opts...), o)
+}
+
+// NewOffline starts the TUI without a server, as --import does. Its API
+// calls fail, and it is shut down when the test ends.
+func NewOffline(t testing.TB, opts ...Option) *Driver {
+	t.Helper()
+	o := options{width: 100, height: 30}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	a := app.NewOffline("test", t.TempDir())
+	t.Cleanup(a.Cleanup)
+	return start(t, nil, a, o)
+}
+
+// start runs the TUI model of a until the test ends.
+func start(t testing.TB, server *testserver.Server, a *app.App, o options) *Driver {
+	t.Helper()
+	d := &Driver{
+		Server: server,
+		App:    a,
+		t:      t,
+		model:  tui.NewModel(a),
+		msgs:   make(chan tea.Msg, 256),
+		done:   make(chan struct{}),
+	}
+	t.Cleanup(func() {
+		close(d.done)
+	})
+
+	d.run(d.model.Init())
+	d.Send(tea.WindowSizeMsg{Width: o.width, Height: o.height})
+	d.Settle()
+	return d
+}
+
+// NewApp connects an app to server without the TUI, for code that drives
+// the app directly. Its state is kept in a temporary directory, and it is
+// shut down when the test ends.
+func NewApp(t testing.TB, server *testserver.Server, opts ...Option) *app.App {
+	t.Helper()
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	server.Path.State = t.TempDir()
+	server.Path.Config = t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// The client is built as cmd/opencode builds it
+	profile := &connection.Profile{Server: server.URL(), Source: "test"}
+	transport, err := profile.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := opencode.NewClient(
+		option.WithBaseURL(profile.Server),
+		option.WithHTTPClient(transport),
+	)
+	api, err := backend.New(o.backend, client, profile.Server, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := client.Project.Current(ctx, opencode.ProjectCurrentParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agents, err := client.Agent.List(ctx, opencode.AgentListParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := client.Path.Get(ctx, opencode.PathGetParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var session *string
+	if o.session != "" {
+		session = &o.session
+	}
+	a, err := app.New(ctx, "test", project, path, *agents, client, api, profile, nil, nil, nil, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		a.Cleanup()
+	})
+	return a
+}
+
+// Send delivers msg to the model and runs the command it returns.
+func (d *Driver) Send(msg tea.Msg) {
+	d.t.Helper()
+	if d.quit {
+		d.t.Fatalf("tuitest: %T sent after the program quit", msg)
+	}
+	var cmd tea.Cmd
+	d.model, cmd = d.model.Update(msg)
+	d.run(cmd)
+}
+
+// Type sends text one key press per rune.
+func (d *Driver) Type(text string) {
+	d.t.Helper()
+	for _, r := range text {
+		d.Send(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	d.Settle()
+}
+
+// Press sends named keys such as "enter", "esc", "ctrl+x" or "shift+tab",
+// settling after each.
+func (d *Driver) Press(keys ...string) {
+	d.t.Helper()
+	for _, key := range keys {
+		msg, ok := ParseKey(key)
+		if !ok {
+			d.t.Fatalf("tuitest: unknown key %q", key)
+		}
+		d.Send(msg)
+		d.Settle()
+	}
+}
+
+// Settle delivers the results of pending commands until the model has been
+// quiet for a moment. Commands that never return, like the event stream,
+// don't hold it up.
+func (d *Driver) Settle() {
+	d.t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-d.msgs:
+			d.deliver(msg)
+		case <-time.After(quiet):
+			return
+		case <-deadline:
+			d.t.Fatal("tuitest: model did not settle")
+		}
+	}
+}
+
+// WaitFor settles until the screen contains text, failing after timeout.
+func (d *Driver) WaitFor(text string) {
+	d.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(d.Screen(), text) {
+		if time.Now().After(deadline) {
+			d.t.Fatalf("tuitest: %q never appeared on screen:\n%s", text, d.Screen())
+		}
+		select {
+		case msg := <-d.msgs:
+			d.deliver(msg)
+		case <-time.After(quiet):
+		}
+	}
+}
+
+// Quit reports whether the model asked the program to exit.
+func (d *Driver) Quit() bool {
+	return d.quit
+}
+
+// Screen is the current view without styling, with trailing spaces and blank
+// lines removed.
+func (d *Driver) Screen() string {
+	var view string
+	switch m := d.model.(type) {
+	case tea.CursorModel:
+		view, _ = m.View()
+	case tea.ViewModel:
+		view = m.View()
+	}
+	lines := strings.Split(ansi.Strip(view), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Snapshot compares the screen with testdata/<name>.golden. Run the tests
+// with -update to rewrite it.
+func (d *Driver) Snapshot(name string) {
+	d.t.Helper()
+	screen := d.Screen() + "\n"
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			d.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(screen), 0o644); err != nil {
+			d.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		d.t.Fatalf("tuitest: %v (run with -update to create it)", err)
+	}
+	if string(want) != screen {
+		d.t.Errorf("tuitest: screen does not match %s\ngot:\n%s\nwant:\n%s", path, screen, want)
+	}
+}
+
+func (d *Driver) deliver(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.QuitMsg:
+		d.quit = true
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			d.run(cmd)
+		}
+	default:
+		if cmds, ok := sequence(msg); ok {
+			d.runSequence(cmds)
+			return
+		}
+		if !d.quit {
+			d.Send(msg)
+		}
+	}
+}
+
+// run executes cmd in the background, like the runtime does, and queues its
+// result for the next Settle.
+func (d *Driver) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		msg := cmd()
+		if msg == nil {
+			return
+		}
+		select {
+		case d.msgs <- msg:
+		case <-d.done:
+		}
+	}()
+}
+
+// runSequence executes cmds one after the other, queuing each result before
+// the next starts.
+func (d *Driver) runSequence(cmds []tea.Cmd) {
+	go func() {
+		for _, cmd := range cmds {
+			if cmd == nil {
+				continue
+			}
+			msg := cmd()
+			if msg == nil {
+				continue
+			}
+			select {
+			case d.msgs <- msg:
+			case <-d.done:
+				return
+			}
+		}
+	}()
+}
+
+// sequence unpacks the message tea.Sequence returns, whose type is
+// unexported.
+func sequence(msg tea.Msg) ([]tea.Cmd, bool) {
+	v := reflect.ValueOf(msg)
+	if v.Kind() != reflect.Slice || v.Type().Elem() != reflect.TypeOf(tea.Cmd(nil)) {
+		return nil, false
+	}
+	cmds := make([]tea.Cmd, v.Len())
+	for i := range cmds {
+		cmds[i] = v.Index(i).Interface().(tea.Cmd)
+	}
+	return cmds, true
+}
+
+var namedKeys = map[string]rune{
+	"enter":     tea.KeyEnter,
+	"esc":       tea.KeyEscape,
+	"escape":    tea.KeyEscape,
+	"tab":       tea.KeyTab,
+	"backspace": tea.KeyBackspace,
+	"delete":    tea.KeyDelete,
+	"space":     tea.KeySpace,
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"left":      tea.KeyLeft,
+	"right":     tea.KeyRight,
+	"home":      tea.KeyHome,
+	"end":       tea.KeyEnd,
+	"pgup":      tea.KeyPgUp,
+	"pgdown":    tea.KeyPgDown,
+}
+
+// ParseKey turns a key name in the keybind syntax, like "ctrl+x" or
+// "shift+enter", into a key press.
+func ParseKey(key string) (tea.KeyPressMsg, bool) {
+	parts := strings.Split(key, "+")
+	name := parts[len(parts)-1]
+	if name == "" && strings.HasSuffix(key, "+") {
+		name = "+"
+		parts = parts[:len(parts)-1]
+	}
+	var msg tea.KeyPressMsg
+	for _, mod := range parts[:len(parts)-1] {
+		switch mod {
+		case "ctrl":
+			msg.Mod |= tea.ModCtrl
+		case "alt":
+			msg.Mod |= tea.ModAlt
+		case "shift":
+			msg.Mod |= tea.ModShift
+		default:
+			return msg, false
+		}
+	}
+	if code, ok := namedKeys[name]; ok {
+		msg.Code = code
+		if code == tea.KeySpace && msg.Mod == 0 {
+			msg.Text = " "
+		}
+		return msg, true
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	if size != len(name) {
+		return msg, false
+	}
+	msg.Code = r
+	if msg.Mod == 0 || msg.Mod == tea.ModShift {
+		msg.Text = name
+	}
+	return msg, true
+}
@@ -0,0 +1,189 @@
+package tuitest
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/exporter"
+	"github.com/sst/opencode/internal/replay"
+	"github.com/sst/opencode/internal/testserver"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want tea.KeyPressMsg
+	}{
+		{"a", tea.KeyPressMsg{Code: 'a', Text: "a"}},
+		{"enter", tea.KeyPressMsg{Code: tea.KeyEnter}},
+		{"ctrl+x", tea.KeyPressMsg{Code: 'x', Mod: tea.ModCtrl}},
+		{"shift+tab", tea.KeyPressMsg{Code: tea.KeyTab, Mod: tea.ModShift}},
+		{"ctrl+alt+up", tea.KeyPressMsg{Code: tea.KeyUp, Mod: tea.ModCtrl | tea.ModAlt}},
+		{"+", tea.KeyPressMsg{Code: '+', Text: "+"}},
+	}
+	for _, tt := range tests {
+		got, ok := ParseKey(tt.key)
+		if !ok || got != tt.want {
+			t.Errorf("ParseKey(%q) = %+v, %v; want %+v", tt.key, got, ok, tt.want)
+		}
+	}
+	if _, ok := ParseKey("hyper+x"); ok {
+		t.Error("expected unknown modifiers to be rejected")
+	}
+}
+
+func TestPromptRoundTrip(t *testing.T) {
+	server := testserver.New()
+	defer server.Close()
+	server.Reply(testserver.Reply{
+		Tools: []testserver.Tool{{
+			Name:   "read",
+			Title:  "main.go",
+			Input:  map[string]any{"filePath": "/project/main.go"},
+			Output: "package main",
+		}},
+		Text: "main.go only declares package main.",
+	})
+
+	d := New(t, server)
+	d.Type("what is in main.go?")
+	d.Press("enter")
+	d.WaitFor("main.go only declares package main.")
+
+	sessions := server.Sessions()
+	if len(sessions) != 1 {
+		t.Fatalf("expected the prompt to create a session, got %d", len(sessions))
+	}
+	messages := server.Messages(sessions[0].ID)
+	if len(messages) != 2 {
+		t.Fatalf("expected a user and an assistant message, got %d", len(messages))
+	}
+	if screen := d.Screen(); !strings.Contains(screen, "what is in main.go?") {
+		t.Errorf("prompt missing from the chat:\n%s", screen)
+	}
+}
+
+func TestResumeSession(t *testing.T) {
+	server := testserver.New()
+	defer server.Close()
+	session := server.AddSession("earlier work")
+
+	d := New(t, server, WithSession(session.ID), WithSize(80, 24))
+	d.Type("hello")
+	d.Press("enter")
+	d.WaitFor("You said: hello")
+
+	if messages := server.Messages(session.ID); len(messages) != 2 {
+		t.Errorf("expected the prompt to go to the resumed session, got %d messages", len(messages))
+	}
+}
+
+func TestDraftsFollowSessions(t *testing.T) {
+	server := testserver.New()
+	defer server.Close()
+	first := server.AddSession("first")
+	second := server.AddSession("second")
+
+	d := New(t, server, WithSession(first.ID))
+	open := func(id string) {
+		t.Helper()
+		session, err := d.App.Client.Session.Get(context.Background(), id, opencode.SessionGetParams{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Send(app.SessionSelectedMsg(session))
+		d.Settle()
+	}
+	draft := func(sessionID string) string {
+		return d.App.State.Draft(d.App.Project.Worktree, sessionID).Text
+	}
+
+	d.Type("half a thought, à moitié")
+	open(second.ID)
+	if screen := d.Screen(); strings.Contains(screen, "half a thought") {
+		t.Errorf("the draft of the first session followed into the second:\n%s", screen)
+	}
+	d.Type("second draft")
+
+	open(first.ID)
+	if screen := d.Screen(); !strings.Contains(screen, "half a thought, à moitié") || strings.Contains(screen, "second draft") {
+		t.Errorf("expected the draft of the first session back:\n%s", screen)
+	}
+	// Drafts back in the editor stay saved until replaced or sent
+	if got := draft(first.ID); got != "half a thought, à moitié" {
+		t.Errorf("draft of the first session is %q", got)
+	}
+	if got := draft(second.ID); got != "second draft" {
+		t.Errorf("draft of the second session is %q", got)
+	}
+
+	d.Send(app.SessionClearedMsg{})
+	d.Settle()
+	d.Type("new idea")
+	open(second.ID)
+	if got := draft(""); got != "new idea" {
+		t.Errorf("draft of the new session is %q", got)
+	}
+	if got := d.App.State.Draft("/elsewhere", "").Text; got != "" {
+		t.Errorf("the new session draft shows in another project: %q", got)
+	}
+	d.Send(app.SessionClearedMsg{})
+	d.Settle()
+	if screen := d.Screen(); !strings.Contains(screen, "new idea") {
+		t.Errorf("expected the new session draft back:\n%s", screen)
+	}
+
+	d.Press("enter")
+	d.WaitFor("You said: new idea")
+	if got := draft(""); got != "" {
+		t.Errorf("the draft is still kept after sending it: %q", got)
+	}
+}
+
+func TestImportWithoutServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	err := exporter.WriteFile(path, "", exporter.Transcript{
+		Session: opencode.Session{ID: "ses_1", Title: "Fix the build"},
+		Messages: []app.Message{
+			{
+				Info: opencode.UserMessage{ID: "msg_1", Role: opencode.UserMessageRoleUser, SessionID: "ses_1", Time: opencode.UserMessageTime{Created: 1000}},
+				Parts: []opencode.PartUnion{
+					opencode.TextPart{ID: "prt_1", MessageID: "msg_1", Type: opencode.TextPartTypeText, Text: "please fix main.go"},
+				},
+			},
+			{
+				Info: opencode.AssistantMessage{
+					ID:        "msg_2",
+					Role:      opencode.AssistantMessageRoleAssistant,
+					SessionID: "ses_1",
+					Time:      opencode.AssistantMessageTime{Created: 1100, Completed: 1600},
+				},
+				Parts: []opencode.PartUnion{
+					opencode.TextPart{ID: "prt_2", MessageID: "msg_2", Type: opencode.TextPartTypeText, Text: "main.go builds again."},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewOffline(t)
+	d.Send(replay.Load(path, false, replay.Options{})())
+	d.WaitFor("main.go builds again.")
+	if d.App.Transcript != path {
+		t.Errorf("the transcript %q is open, want %q", d.App.Transcript, path)
+	}
+
+	// Anything that needs the server fails instead of hanging or panicking
+	d.Send(app.SessionClearedMsg{})
+	d.Settle()
+	d.Type("hello")
+	d.Press("enter")
+	d.WaitFor("not connected")
+}
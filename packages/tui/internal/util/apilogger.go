@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"reflect"
 	"sync"
+	"time"
 
 	opencode "github.com/sst/opencode-api-go"
+	"github.com/sst/opencode/internal/logsink"
 )
 
 func sanitizeValue(val any) any {
@@ -27,48 +30,69 @@ func sanitizeValue(val any) any {
 	return val
 }
 
+// APILogHandler sends records to the server's log endpoint in batches and
+// keeps a rotating copy under the state directory. Handlers derived with
+// WithAttrs and WithGroup share one sink.
 type APILogHandler struct {
-	client  *opencode.Client
+	sink    *logsink.Sink
 	service string
 	level   slog.Level
 	attrs   []slog.Attr
 	groups  []string
 	mu      sync.Mutex
-	queue   chan opencode.AppLogParams
 }
 
-func NewAPILogHandler(ctx context.Context, client *opencode.Client, service string, level slog.Level) *APILogHandler {
-	result := &APILogHandler{
-		client:  client,
+// APILogOption configures NewAPILogHandler.
+type APILogOption func(*logsink.Options)
+
+// WithLogFile keeps a local copy of every record in a rotating file at path.
+func WithLogFile(path string) APILogOption {
+	return func(o *logsink.Options) {
+		o.File = path
+	}
+}
+
+// WithLogBatch sets how many records are sent together and how long a
+// partial batch may wait.
+func WithLogBatch(size int, interval time.Duration) APILogOption {
+	return func(o *logsink.Options) {
+		o.BatchSize = size
+		o.FlushInterval = interval
+	}
+}
+
+func NewAPILogHandler(ctx context.Context, client *opencode.Client, service string, level slog.Level, opts ...APILogOption) *APILogHandler {
+	options := logsink.Options{Service: service}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	// the server takes one record per request, so a batch is sent in order
+	// and stops at the first failure
+	send := func(ctx context.Context, batch []logsink.Entry) (int, error) {
+		for i, entry := range batch {
+			if _, err := client.App.Log(ctx, logParams(entry)); err != nil {
+				return i, err
+			}
+		}
+		return len(batch), nil
+	}
+	sink, err := logsink.New(ctx, send, options)
+	if err != nil {
+		// not logged through slog, which may be this handler
+		fmt.Fprintln(os.Stderr, "opencode: local log disabled:", err)
+	}
+	return &APILogHandler{
+		sink:    sink,
 		service: service,
 		level:   level,
 		attrs:   make([]slog.Attr, 0),
 		groups:  make([]string, 0),
-		queue:   make(chan opencode.AppLogParams, 100_000),
 	}
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case params := <-result.queue:
-				_, err := client.App.Log(context.Background(), params)
-				if err != nil {
-					slog.Error("Failed to log to API", "error", err)
-				}
-			}
-		}
-	}()
-	return result
-}
-
-func (h *APILogHandler) Enabled(_ context.Context, level slog.Level) bool {
-	return level >= h.level
 }
 
-func (h *APILogHandler) Handle(ctx context.Context, r slog.Record) error {
+func logParams(entry logsink.Entry) opencode.AppLogParams {
 	var apiLevel opencode.AppLogParamsLevel
-	switch r.Level {
+	switch entry.Level {
 	case slog.LevelDebug:
 		apiLevel = opencode.AppLogParamsLevelDebug
 	case slog.LevelInfo:
@@ -81,6 +105,44 @@ func (h *APILogHandler) Handle(ctx context.Context, r slog.Record) error {
 		apiLevel = opencode.AppLogParamsLevelInfo
 	}
 
+	params := opencode.AppLogParams{
+		Service: opencode.F(entry.Service),
+		Level:   opencode.F(apiLevel),
+		Message: opencode.F(entry.Message),
+	}
+
+	if len(entry.Extra) > 0 {
+		params.Extra = opencode.F(entry.Extra)
+	}
+	return params
+}
+
+// Flush sends everything buffered, even while backing off after failures.
+func (h *APILogHandler) Flush(ctx context.Context) error {
+	return h.sink.Flush(ctx)
+}
+
+// Close makes a final flush. Records handled afterwards only go to the local
+// file.
+func (h *APILogHandler) Close() error {
+	return h.sink.Close()
+}
+
+// Stats reports what was sent, dropped and is still pending.
+func (h *APILogHandler) Stats() logsink.Stats {
+	return h.sink.Stats()
+}
+
+// LogFile is the local log's path, or empty when there is none.
+func (h *APILogHandler) LogFile() string {
+	return h.sink.File()
+}
+
+func (h *APILogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *APILogHandler) Handle(ctx context.Context, r slog.Record) error {
 	extra := make(map[string]any)
 
 	h.mu.Lock()
@@ -96,17 +158,13 @@ func (h *APILogHandler) Handle(ctx context.Context, r slog.Record) error {
 		return true
 	})
 
-	params := opencode.AppLogParams{
-		Service: opencode.F(h.service),
-		Level:   opencode.F(apiLevel),
-		Message: opencode.F(r.Message),
-	}
-
-	if len(extra) > 0 {
-		params.Extra = opencode.F(extra)
-	}
-
-	h.queue <- params
+	h.sink.Add(logsink.Entry{
+		Time:    r.Time,
+		Level:   r.Level,
+		Service: h.service,
+		Message: r.Message,
+		Extra:   extra,
+	})
 
 	return nil
 }
@@ -118,12 +176,11 @@ func (h *APILogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	defer h.mu.Unlock()
 
 	newHandler := &APILogHandler{
-		client:  h.client,
+		sink:    h.sink,
 		service: h.service,
 		level:   h.level,
 		attrs:   make([]slog.Attr, len(h.attrs)+len(attrs)),
 		groups:  make([]string, len(h.groups)),
-		queue:   make(chan opencode.AppLogParams, 100_000),
 	}
 
 	copy(newHandler.attrs, h.attrs)
@@ -140,12 +197,11 @@ func (h *APILogHandler) WithGroup(name string) slog.Handler {
 	defer h.mu.Unlock()
 
 	newHandler := &APILogHandler{
-		client:  h.client,
+		sink:    h.sink,
 		service: h.service,
 		level:   h.level,
 		attrs:   make([]slog.Attr, len(h.attrs)),
 		groups:  make([]string, len(h.groups)+1),
-		queue:   make(chan opencode.AppLogParams, 100_000),
 	}
 
 	copy(newHandler.attrs, h.attrs)
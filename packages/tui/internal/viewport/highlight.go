@@ -15,6 +15,9 @@ import (
 // - matches do not overlap
 // - content is line terminated with \n only
 //
+// Content may be styled; matches are measured against the content with its
+// escape sequences stripped.
+//
 // We'll then convert the ranges into [highlightInfo]s, which hold the starting
 // line and the grapheme positions.
 func parseMatches(
@@ -30,8 +33,9 @@ func parseMatches(
 	previousLinesOffset := 0
 	bytePos := 0
 
+	content = ansi.Strip(content)
 	highlights := make([]highlightInfo, 0, len(matches))
-	gr := uniseg.NewGraphemes(ansi.Strip(content))
+	gr := uniseg.NewGraphemes(content)
 
 	for _, match := range matches {
 		byteStart, byteEnd := match[0], match[1]
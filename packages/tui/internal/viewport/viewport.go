@@ -654,6 +654,33 @@ func (m *Model) SetHighlights(matches [][]int) {
 	m.memo.Invalidate()
 }
 
+// ReplaceHighlights sets the highlight ranges like [Model.SetHighlights]
+// and focuses the one at index selected, or none when it is negative. Unlike
+// SetHighlights it keeps the scroll position; use [Model.SelectHighlight] to
+// bring a highlight into view.
+func (m *Model) ReplaceHighlights(matches [][]int, selected int) {
+	m.highlights = nil
+	if len(matches) > 0 && len(m.lines) > 0 {
+		m.highlights = parseMatches(m.GetContent(), matches)
+	}
+	m.hiIdx = -1
+	if selected < len(m.highlights) {
+		m.hiIdx = max(-1, selected)
+	}
+	m.memo.Invalidate()
+}
+
+// SelectHighlight focuses the highlight at index i and scrolls it into view.
+// A negative index removes the focus.
+func (m *Model) SelectHighlight(i int) {
+	if i >= len(m.highlights) {
+		return
+	}
+	m.hiIdx = max(-1, i)
+	m.showHighlight()
+	m.memo.Invalidate()
+}
+
 // ClearHighlights clears previously set highlights.
 func (m *Model) ClearHighlights() {
 	m.highlights = nil